@@ -4,19 +4,26 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/signal"
-	"strings"
-	"syscall"
 
+	"github.com/lumitut/lumi-go/internal/app"
 	"github.com/lumitut/lumi-go/internal/config"
-	"github.com/lumitut/lumi-go/internal/httpapi"
 	"github.com/lumitut/lumi-go/internal/observability/logger"
-	"github.com/lumitut/lumi-go/internal/observability/metrics"
 	"github.com/lumitut/lumi-go/internal/observability/tracing"
 	"go.uber.org/zap"
 )
 
 func main() {
+	// Offline configuration tooling: config validate|print|diff
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(config.RunCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	os.Exit(run())
+}
+
+// run starts the service and blocks until it stops, returning the process
+// exit code once deferred cleanup has run
+func run() int {
 	// Create root context
 	ctx := context.Background()
 
@@ -25,11 +32,13 @@ func main() {
 	// 1. Command-line flags: -config=/path/to/config.json -env=production
 	// 2. JSON config file: cmd/server/schema/lumi.json
 	// 3. Environment variables: LUMI_SERVICE_NAME, LUMI_DATABASE_HOST, etc.
-	// Priority: Env vars > Config file > Defaults
-	cfg, err := config.LoadDefault(ctx)
+	// 4. Overlays next to the config file: lumi.<env>.json, then lumi.local.json
+	// Priority: Env vars > Local override > Environment overlay > Config file > Defaults
+	loader := config.NewConfigLoader()
+	cfg, err := loader.LoadDefault(ctx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 
 	// Initialize logger
@@ -45,18 +54,13 @@ func main() {
 
 	if err := logger.Initialize(logConfig); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 	defer logger.Sync()
 
 	// Log configuration (with sensitive values redacted)
 	cfg.LogConfig(ctx)
 
-	// Initialize metrics (replace hyphens with underscores for Prometheus compatibility)
-	metricsNamespace := strings.ReplaceAll(cfg.Service.Name, "-", "_")
-	metrics.Initialize(metricsNamespace, "api")
-	metrics.StartUptimeCounter(ctx)
-
 	// Initialize tracing if enabled
 	if cfg.IsTracingEnabled() {
 		tracingConfig := tracing.Config{
@@ -99,56 +103,62 @@ func main() {
 
 	// Check for maintenance mode
 	if cfg.Features.MaintenanceMode {
-		logger.Warn(ctx, "Service is in maintenance mode")
+		logger.Warn(ctx, "Service is in maintenance mode, rejecting API requests")
 	}
 
-	// Create HTTP server
-	httpServer := httpapi.NewServer(cfg)
-
-	// Start HTTP server in goroutine
-	go func() {
-		if err := httpServer.Start(ctx); err != nil {
-			logger.Fatal(ctx, "Failed to start HTTP server",
-				zap.Error(err),
-			)
-		}
-	}()
-
-	// TODO: Start RPC server when implemented
-	// rpcServer := rpcapi.NewServer(cfg)
-	// go func() {
-	//     if err := rpcServer.Start(ctx); err != nil {
-	//         logger.Fatal(ctx, "Failed to start RPC server",
-	//             zap.Error(err),
-	//         )
-	//     }
-	// }()
-
-	// Wait for interrupt signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-quit
-
-	logger.Info(ctx, "Received shutdown signal",
-		zap.String("signal", sig.String()),
-	)
-
-	// Create shutdown context with timeout
-	shutdownCtx, cancel := context.WithTimeout(ctx, cfg.Server.GracefulShutdownTimeout)
-	defer cancel()
+	// Watch for configuration changes (file edits, SIGHUP and the admin API)
+	watcher := config.NewWatcher(loader, cfg)
+	watcher.Subscribe(func(event config.Event) {
+		onConfigChange(ctx, event)
+	})
 
-	// Shutdown HTTP server
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		logger.Error(ctx, "Failed to gracefully shutdown HTTP server", err)
+	// Create the application: HTTP gateway, RPC server, admin listeners,
+	// metrics, watchdog and configuration watcher
+	application, err := app.New(cfg, watcher)
+	if err != nil {
+		logger.Error(ctx, "Failed to create application", err)
+		return 1
 	}
 
-	// TODO: Shutdown RPC server when implemented
-	// if err := rpcServer.Shutdown(shutdownCtx); err != nil {
-	//     logger.Error(ctx, "Failed to gracefully shutdown RPC server", err)
-	// }
+	// Run until a shutdown signal or a component failure
+	if err := application.Run(ctx); err != nil {
+		logger.Error(ctx, "Service stopped with error", err)
+		return 1
+	}
 
 	// TODO: Close database connections when implemented
 	// TODO: Close Redis connections when implemented
 
 	logger.Info(ctx, "Service shutdown complete")
+	return 0
+}
+
+// onConfigChange applies reloaded settings owned by main and reports the
+// changes that only take effect on restart
+func onConfigChange(ctx context.Context, event config.Event) {
+	switch e := event.(type) {
+	case config.LogLevelChanged:
+		if err := logger.SetLevel(e.New); err != nil {
+			logger.Error(ctx, "Failed to change log level", err)
+			return
+		}
+		logger.Info(ctx, "Log level changed",
+			zap.String("old", e.Old),
+			zap.String("new", e.New),
+		)
+	case config.FeaturesChanged:
+		if e.New.MaintenanceMode != e.Old.MaintenanceMode {
+			if e.New.MaintenanceMode {
+				logger.Warn(ctx, "Service is in maintenance mode, rejecting API requests")
+			} else {
+				logger.Info(ctx, "Service left maintenance mode")
+			}
+		}
+	case config.Reloaded:
+		if len(e.RestartRequired) > 0 {
+			logger.Warn(ctx, "Configuration changes require a restart to take effect",
+				zap.Strings("sections", e.RestartRequired),
+			)
+		}
+	}
 }
@@ -3,107 +3,431 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/features"
 	"github.com/lumitut/lumi-go/internal/httpapi"
+	"github.com/lumitut/lumi-go/internal/middleware"
 	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"github.com/lumitut/lumi-go/internal/rpcapi"
+	"github.com/lumitut/lumi-go/internal/service"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
-// Application represents the main application
+// readyPollInterval is how often a component's dependencies are checked
+// for readiness while it waits to start
+const readyPollInterval = 10 * time.Millisecond
+
+// Application runs its components in dependency order and stops them in
+// reverse order on shutdown
 type Application struct {
 	cfg        *config.Config
+	health     *service.HealthService
 	httpServer *httpapi.Server
-	// Add other servers/services as needed
-	// rpcServer  *rpcapi.Server
-	// workers    []Worker
+	rpcServer  *rpcapi.Server
+	features   *features.Evaluator
+
+	// Keeps the rate limit counters of both servers, nil unless the store is Redis
+	rateLimitRedis *redis.Client
+
+	components []*managedComponent
+
+	mu       sync.Mutex
+	started  []*managedComponent // in start order
+	stopping chan struct{}       // closed when shutdown begins
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// managedComponent tracks a registered component while the application runs
+type managedComponent struct {
+	Component
+	dependsOn []string
+	exited    chan struct{} // closed when Start returns
 }
 
-// New creates a new application instance
-func New(cfg *config.Config) (*Application, error) {
-	app := &Application{
-		cfg: cfg,
+// New creates the application with the HTTP gateway, RPC server, admin
+// listeners, metrics, watchdog and configuration watcher components. The
+// servers apply the changes watcher publishes, and the admin API reads and
+// updates configuration through it. Feature flags, from the flags file if
+// configured, then the features configuration, turn on the servers' beta
+// features per request.
+func New(cfg *config.Config, watcher *config.Watcher) (*Application, error) {
+	// Initialize metrics (replace hyphens with underscores for Prometheus compatibility)
+	metrics.Initialize(strings.ReplaceAll(cfg.Service.Name, "-", "_"), "api")
+
+	// Services shared by the HTTP gateway and the RPC server
+	examples := service.NewExampleService()
+	health := service.NewHealthService(cfg)
+
+	app := NewEmpty(cfg, health)
+
+	// Feature flags from the flags file, if configured, then the configuration
+	providers := []features.Provider{features.NewConfigProvider(watcher)}
+	var flagsFile *features.FileProvider
+	if path := cfg.Features.FlagsFile; path != "" {
+		var err error
+		if flagsFile, err = features.NewFileProvider(path); err != nil {
+			return nil, err
+		}
+		providers = append([]features.Provider{flagsFile}, providers...)
+	}
+	app.features = features.NewEvaluator(providers...)
+
+	app.rateLimitRedis = middleware.NewRateLimitRedis(cfg)
+	if app.rateLimitRedis != nil {
+		// Ping the shared client rather than only dialing the Redis port
+		client := app.rateLimitRedis
+		health.Checker().Register("redis", func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		}, service.DefaultCheckConfig())
+	}
+	app.httpServer = httpapi.NewServerWithOptions(cfg, examples, health, httpapi.Options{
+		Redis:    app.rateLimitRedis,
+		Features: app.features,
+	})
+	app.rpcServer = rpcapi.NewServerWithOptions(cfg, examples, health, rpcapi.Options{
+		Redis:    app.rateLimitRedis,
+		Features: app.features,
+	})
+
+	components := []Component{
+		NewWorker("metrics", func(ctx context.Context) error {
+			metrics.StartUptimeCounter(ctx)
+			<-ctx.Done()
+			return nil
+		}),
+		// Detect a wedged process for the liveness probe
+		NewWorker("watchdog", func(ctx context.Context) error {
+			return health.Watchdog().Run(ctx, time.Second)
+		}),
+	}
+	// Admin listeners start first and stop last, so metrics cover the whole run
+	adminAPI := httpapi.NewAdminAPI(cfg.Server.AdminToken, watcher)
+	adminAPI.AddRateLimiter("http", app.httpServer.RateLimiter())
+	adminAPI.AddRateLimiter("rpc", app.rpcServer.RateLimiter())
+	for _, admin := range app.httpServer.AdminServers(adminAPI) {
+		components = append(components, admin)
+	}
+	components = append(components, app.rpcServer, app.httpServer)
+
+	// Reload flag definitions as the flags file changes
+	if flagsFile != nil {
+		components = append(components, NewWorker("feature-flags", func(ctx context.Context) error {
+			if err := flagsFile.Run(ctx); err != nil {
+				// Reloading is best effort; keep serving the loaded flags
+				logger.Error(ctx, "Feature flags watcher stopped", err)
+				<-ctx.Done()
+			}
+			return nil
+		}))
 	}
 
-	// Initialize HTTP server
-	app.httpServer = httpapi.NewServer(cfg)
+	// Watch for configuration changes (file edits and SIGHUP)
+	watcher.Subscribe(app.OnConfigChange)
+	components = append(components, NewWorker("config-watcher", func(ctx context.Context) error {
+		if err := watcher.Run(ctx); err != nil {
+			// Reloading is best effort; keep serving the loaded configuration
+			logger.Error(ctx, "Configuration watcher stopped", err)
+			<-ctx.Done()
+		}
+		return nil
+	}))
 
-	// Initialize other components as needed
-	// app.rpcServer = rpcapi.NewServer(cfg)
+	for _, c := range components {
+		if err := app.Register(c); err != nil {
+			return nil, errors.Join(err, app.closeServers())
+		}
+	}
 
 	return app, nil
 }
 
-// Run starts the application and blocks until shutdown
-func (a *Application) Run(ctx context.Context) error {
-	// Start HTTP server
-	httpErrCh := make(chan error, 1)
-	go func() {
-		logger.Info(ctx, "Starting HTTP server",
-			zap.String("port", a.cfg.Server.HTTPPort))
-		if err := a.httpServer.Start(ctx); err != nil && err != http.ErrServerClosed {
-			httpErrCh <- fmt.Errorf("HTTP server error: %w", err)
+// closeServers releases the servers' rate limiters, then the Redis client
+// they share
+func (a *Application) closeServers() error {
+	var errs []error
+	if a.httpServer != nil {
+		errs = append(errs, a.httpServer.Close())
+	}
+	if a.rpcServer != nil {
+		errs = append(errs, a.rpcServer.Close())
+	}
+	if a.rateLimitRedis != nil {
+		errs = append(errs, a.rateLimitRedis.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// NewEmpty creates an application with no components, reporting their
+// progress to the given health service
+func NewEmpty(cfg *config.Config, health *service.HealthService) *Application {
+	return &Application{
+		cfg:      cfg,
+		health:   health,
+		stopping: make(chan struct{}),
+	}
+}
+
+// Register adds a component that starts once every component named in
+// dependsOn is ready. Components must be registered before Run.
+func (a *Application) Register(c Component, dependsOn ...string) error {
+	for _, existing := range a.components {
+		if existing.Name() == c.Name() {
+			return fmt.Errorf("component %s is already registered", c.Name())
 		}
-	}()
+	}
+
+	a.components = append(a.components, &managedComponent{
+		Component: c,
+		dependsOn: dependsOn,
+		exited:    make(chan struct{}),
+	})
+	a.health.Lifecycle().Register(c.Name())
+	return nil
+}
+
+// Features returns the feature flag evaluator
+func (a *Application) Features() *features.Evaluator {
+	return a.features
+}
 
-	// Start other servers as needed
-	// rpcErrCh := make(chan error, 1)
-	// go func() {
-	//     if err := a.rpcServer.Start(ctx); err != nil {
-	//         rpcErrCh <- fmt.Errorf("RPC server error: %w", err)
-	//     }
-	// }()
+// HealthService returns the health service shared by the components
+func (a *Application) HealthService() *service.HealthService {
+	return a.health
+}
+
+// OnConfigChange forwards a reloaded configuration change to the servers
+func (a *Application) OnConfigChange(event config.Event) {
+	if a.httpServer != nil {
+		a.httpServer.OnConfigChange(event)
+	}
+	if a.rpcServer != nil {
+		a.rpcServer.OnConfigChange(event)
+	}
+}
+
+// Run starts the components and blocks until a shutdown signal, ctx is
+// cancelled or a component fails. It then shuts down and returns the first
+// component failure, if any.
+func (a *Application) Run(ctx context.Context) error {
+	order, err := a.startOrder()
+	if err != nil {
+		return err
+	}
 
-	// Wait for interrupt signal or error
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fatal := make(chan error, len(order))
+	go a.startAll(runCtx, order, fatal)
 
+	var runErr error
 	select {
 	case sig := <-sigCh:
 		logger.Info(ctx, "Received shutdown signal", zap.String("signal", sig.String()))
-		return a.Shutdown(ctx)
-	case err := <-httpErrCh:
-		return err
 	case <-ctx.Done():
-		return a.Shutdown(ctx)
+	case runErr = <-fatal:
+		logger.Error(ctx, "Component failed, stopping application", runErr)
+	}
+
+	// Shut down with a fresh deadline even when ctx is already cancelled
+	if err := a.Shutdown(context.WithoutCancel(ctx)); err != nil && runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
+
+// startAll starts each component once its dependencies are ready, stopping
+// early if shutdown begins
+func (a *Application) startAll(ctx context.Context, order []*managedComponent, fatal chan<- error) {
+	byName := make(map[string]*managedComponent, len(order))
+	for _, c := range order {
+		byName[c.Name()] = c
+	}
+
+	for _, c := range order {
+		for _, dep := range c.dependsOn {
+			if !a.waitReady(byName[dep]) {
+				return
+			}
+		}
+
+		a.mu.Lock()
+		select {
+		case <-a.stopping:
+			a.mu.Unlock()
+			return
+		default:
+		}
+		a.started = append(a.started, c)
+		a.mu.Unlock()
+
+		logger.Info(ctx, "Starting component", zap.String("component", c.Name()))
+		go a.run(ctx, c, fatal)
+		go a.markInitialized(c)
+	}
+}
+
+// run runs a component's Start, reporting a failure or an unexpected exit
+func (a *Application) run(ctx context.Context, c *managedComponent, fatal chan<- error) {
+	defer close(c.exited)
+
+	err := c.Start(ctx)
+	if err == nil {
+		select {
+		case <-a.stopping:
+			return
+		default:
+			err = fmt.Errorf("component %s exited unexpectedly", c.Name())
+		}
+	}
+	fatal <- err
+}
+
+// waitReady blocks until c is ready, returning false if it exits or
+// shutdown begins first
+func (a *Application) waitReady(c *managedComponent) bool {
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+
+	for !c.Ready() {
+		select {
+		case <-ticker.C:
+		case <-c.exited:
+			return false
+		case <-a.stopping:
+			return false
+		}
+	}
+	return true
+}
+
+// markInitialized reports c to the lifecycle once it is ready
+func (a *Application) markInitialized(c *managedComponent) {
+	if a.waitReady(c) {
+		a.health.Lifecycle().MarkInitialized(c.Name())
+	}
+}
+
+// startOrder sorts the components so each follows its dependencies, keeping
+// registration order otherwise
+func (a *Application) startOrder() ([]*managedComponent, error) {
+	byName := make(map[string]*managedComponent, len(a.components))
+	for _, c := range a.components {
+		byName[c.Name()] = c
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(a.components))
+	order := make([]*managedComponent, 0, len(a.components))
+
+	var visit func(c *managedComponent, path []string) error
+	visit = func(c *managedComponent, path []string) error {
+		path = append(path, c.Name())
+		switch state[c.Name()] {
+		case visiting:
+			return fmt.Errorf("component dependency cycle: %s", strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+
+		state[c.Name()] = visiting
+		for _, name := range c.dependsOn {
+			dep, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("component %s depends on unknown component %s", c.Name(), name)
+			}
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		state[c.Name()] = visited
+		order = append(order, c)
+		return nil
+	}
+
+	for _, c := range a.components {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
 	}
+	return order, nil
 }
 
-// Shutdown gracefully shuts down the application
+// Shutdown drains the service and stops the started components in reverse
+// start order within the graceful shutdown timeout. Only the first call has
+// any effect; later calls return its result.
 func (a *Application) Shutdown(ctx context.Context) error {
+	a.shutdownOnce.Do(func() {
+		a.shutdownErr = a.shutdown(ctx)
+	})
+	return a.shutdownErr
+}
+
+// shutdown stops the components and waits for their Start calls to return
+func (a *Application) shutdown(ctx context.Context) error {
 	logger.Info(ctx, "Shutting down application")
 
+	// Fail readiness while draining
+	a.health.Lifecycle().Drain()
+	defer a.health.Lifecycle().Stop()
+
+	// Stop starting new components
+	a.mu.Lock()
+	close(a.stopping)
+	started := append([]*managedComponent(nil), a.started...)
+	a.mu.Unlock()
+
 	// Create shutdown context with timeout
 	shutdownCtx, cancel := context.WithTimeout(ctx, a.cfg.Server.GracefulShutdownTimeout)
 	defer cancel()
 
-	// Shutdown HTTP server
-	if err := a.httpServer.Shutdown(shutdownCtx); err != nil {
-		logger.Error(ctx, "Failed to shutdown HTTP server", err)
-	}
+	// Release the servers' rate limiters once they have stopped, including
+	// those of servers that never started
+	defer a.closeServers()
 
-	// Shutdown other components
-	// if err := a.rpcServer.Shutdown(shutdownCtx); err != nil {
-	//     logger.Error(ctx, "Failed to shutdown RPC server", err)
-	// }
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		logger.Info(ctx, "Stopping component", zap.String("component", c.Name()))
+		if err := c.Stop(shutdownCtx); err != nil {
+			logger.Error(ctx, "Failed to stop component", err, zap.String("component", c.Name()))
+			errs = append(errs, fmt.Errorf("failed to stop component %s: %w", c.Name(), err))
+		}
+	}
 
-	// Wait for graceful shutdown or timeout
-	select {
-	case <-shutdownCtx.Done():
-		if shutdownCtx.Err() == context.DeadlineExceeded {
+	// Wait for the components to return from Start
+	for _, c := range started {
+		select {
+		case <-c.exited:
+		case <-shutdownCtx.Done():
 			logger.Warn(ctx, "Shutdown timeout exceeded, forcing shutdown")
+			return errors.Join(append(errs, fmt.Errorf("component %s did not stop: %w", c.Name(), shutdownCtx.Err()))...)
 		}
-	default:
-		logger.Info(ctx, "Graceful shutdown completed")
 	}
 
-	return nil
+	logger.Info(ctx, "Graceful shutdown completed")
+	return errors.Join(errs...)
 }
 
 // Health returns the health status of the application
@@ -119,20 +443,15 @@ func (a *Application) Health() map[string]interface{} {
 // Ready returns the readiness status of the application
 func (a *Application) Ready() map[string]interface{} {
 	ready := true
-	checks := make(map[string]bool)
+	checks := make(map[string]bool, len(a.components))
 
-	// Check HTTP server
-	if a.httpServer != nil && a.httpServer.IsReady() {
-		checks["http"] = true
-	} else {
-		checks["http"] = false
-		ready = false
+	for _, c := range a.components {
+		checks[c.Name()] = c.Ready()
+		if !checks[c.Name()] {
+			ready = false
+		}
 	}
 
-	// Add other readiness checks
-	// checks["database"] = a.checkDatabase()
-	// checks["redis"] = a.checkRedis()
-
 	return map[string]interface{}{
 		"ready":   ready,
 		"checks":  checks,
@@ -0,0 +1,93 @@
+// Package app provides application-level initialization and coordination
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Component is a long-running part of the application, such as a server or
+// background worker, whose lifecycle is managed by Application
+type Component interface {
+	// Name identifies the component in logs, dependencies and health checks
+	Name() string
+
+	// Start runs the component and blocks until it stops. Returning before
+	// Stop is called is treated as a fatal error that stops the application.
+	Start(ctx context.Context) error
+
+	// Stop gracefully stops the component, giving up when ctx expires
+	Stop(ctx context.Context) error
+
+	// Ready reports whether the component has started and can serve its dependents
+	Ready() bool
+}
+
+// worker runs a function as a Component
+type worker struct {
+	name  string
+	run   func(ctx context.Context) error
+	ready atomic.Bool
+
+	stopOnce sync.Once
+	stop     chan struct{} // closed by Stop
+	done     chan struct{} // closed when Start returns
+}
+
+// NewWorker returns a component that runs fn until it is stopped. fn must
+// return promptly once its context is cancelled.
+func NewWorker(name string, fn func(ctx context.Context) error) Component {
+	return &worker{
+		name: name,
+		run:  fn,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Name returns the worker name
+func (w *worker) Name() string {
+	return w.name
+}
+
+// Start runs the worker function until Stop cancels it
+func (w *worker) Start(ctx context.Context) error {
+	defer close(w.done)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-w.stop:
+			cancel()
+		case <-runCtx.Done():
+		}
+	}()
+
+	w.ready.Store(true)
+	defer w.ready.Store(false)
+
+	if err := w.run(runCtx); err != nil {
+		return fmt.Errorf("worker %s failed: %w", w.name, err)
+	}
+	return nil
+}
+
+// Stop cancels the worker and waits for Start to return
+func (w *worker) Stop(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.stop) })
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop worker %s: %w", w.name, ctx.Err())
+	}
+}
+
+// Ready reports whether the worker is running
+func (w *worker) Ready() bool {
+	return w.ready.Load()
+}
@@ -0,0 +1,270 @@
+// Package config provides configuration management for the application
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"text/tabwriter"
+)
+
+// Exit codes of the config command
+const (
+	ExitOK      = 0 // valid configuration, or no differences
+	ExitInvalid = 1 // invalid configuration, or differences found
+	ExitError   = 2 // usage error, or a diff side could not be loaded
+)
+
+const commandUsage = `Usage: config <command> [flags] <file>...
+
+Commands:
+  validate  Check config files, including overlays, defaults and LUMI_* variables
+  print     Print the effective configuration with secrets redacted
+  diff      Show the settings that differ between two configs or environments
+
+Run 'config <command> -h' for the flags of a command.
+`
+
+// RunCommand runs the config subcommand named by args[0] and returns its exit
+// code: ExitOK on success, ExitInvalid for an invalid configuration (or, for
+// diff, any difference) and ExitError for usage errors.
+func RunCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, commandUsage)
+		return ExitError
+	}
+
+	switch args[0] {
+	case "validate":
+		return runValidate(args[1:], stdout, stderr)
+	case "print":
+		return runPrint(args[1:], stdout, stderr)
+	case "diff":
+		return runDiff(args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, commandUsage)
+		return ExitOK
+	default:
+		fmt.Fprintf(stderr, "unknown config command %q\n\n%s", args[0], commandUsage)
+		return ExitError
+	}
+}
+
+// loadOptions are the flags shared by every config command
+type loadOptions struct {
+	env         string
+	strict      bool
+	skipSecrets bool
+}
+
+// register adds the shared flags to fs
+func (o *loadOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.env, "env", "", "Override environment (development/staging/production)")
+	fs.BoolVar(&o.strict, "strict", false, "Reject unknown keys in config files")
+	fs.BoolVar(&o.skipSecrets, "skip-secrets", false, "Do not resolve file:// and env:// secret references")
+}
+
+// load loads file with the options applied, for the given environment
+func (o *loadOptions) load(file, env string) (*Parser, *Config, error) {
+	p := NewParser()
+	p.SetStrict(o.strict)
+	p.SetSkipSecrets(o.skipSecrets)
+	p.SetEnvironment(env)
+
+	cfg, err := p.LoadConfig(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, cfg, nil
+}
+
+// newFlagSet creates a flag set for a config command that reports errors
+// instead of exiting
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: config %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args, returning the exit code to stop with if parsing
+// fails or help was requested
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK, false
+		}
+		return ExitError, false
+	}
+	return ExitOK, true
+}
+
+// runValidate implements "config validate"
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	var opts loadOptions
+	fs := newFlagSet("validate", "<file>...", stderr)
+	opts.register(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ExitError
+	}
+
+	code := ExitOK
+	for _, file := range fs.Args() {
+		if _, _, err := opts.load(file, opts.env); err != nil {
+			writeLoadError(stderr, file, err)
+			code = ExitInvalid
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: OK\n", file)
+	}
+	return code
+}
+
+// runPrint implements "config print"
+func runPrint(args []string, stdout, stderr io.Writer) int {
+	var opts loadOptions
+	fs := newFlagSet("print", "<file>", stderr)
+	opts.register(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ExitError
+	}
+
+	file := fs.Arg(0)
+	p, cfg, err := opts.load(file, opts.env)
+	if err != nil {
+		writeLoadError(stderr, file, err)
+		return ExitInvalid
+	}
+
+	if err := WriteSettings(stdout, p.Settings(cfg)); err != nil {
+		fmt.Fprintf(stderr, "failed to print configuration: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// runDiff implements "config diff", comparing two files or one file in two
+// environments
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	var opts loadOptions
+	var leftEnv, rightEnv string
+	fs := newFlagSet("diff", "<file> [other-file]", stderr)
+	opts.register(fs)
+	fs.StringVar(&leftEnv, "left-env", "", "Environment of the first config (default: -env)")
+	fs.StringVar(&rightEnv, "right-env", "", "Environment of the second config (default: -env)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return ExitError
+	}
+
+	leftFile, rightFile := fs.Arg(0), fs.Arg(0)
+	if fs.NArg() == 2 {
+		rightFile = fs.Arg(1)
+	}
+	if leftEnv == "" {
+		leftEnv = opts.env
+	}
+	if rightEnv == "" {
+		rightEnv = opts.env
+	}
+
+	_, left, err := opts.load(leftFile, leftEnv)
+	if err != nil {
+		writeLoadError(stderr, leftFile, err)
+		return ExitError
+	}
+	_, right, err := opts.load(rightFile, rightEnv)
+	if err != nil {
+		writeLoadError(stderr, rightFile, err)
+		return ExitError
+	}
+
+	changes := DiffSettings(left, right)
+	if len(changes) == 0 {
+		return ExitOK
+	}
+	if err := writeChanges(stdout, changes); err != nil {
+		fmt.Fprintf(stderr, "failed to print differences: %v\n", err)
+		return ExitError
+	}
+	return ExitInvalid
+}
+
+// SettingChange is a setting whose value differs between two configurations
+type SettingChange struct {
+	Key   string
+	Left  interface{}
+	Right interface{}
+}
+
+// DiffSettings returns the settings that differ between left and right.
+// Secrets are compared by value but reported redacted.
+func DiffSettings(left, right *Config) []SettingChange {
+	var leftRaw, rightRaw, leftShown, rightShown []Setting
+	collectSettings(reflect.ValueOf(left).Elem(), "", nil, &leftRaw)
+	collectSettings(reflect.ValueOf(right).Elem(), "", nil, &rightRaw)
+	collectSettings(reflect.ValueOf(left.Redacted()).Elem(), "", nil, &leftShown)
+	collectSettings(reflect.ValueOf(right.Redacted()).Elem(), "", nil, &rightShown)
+
+	// Both sides are the same struct, so settings line up by index
+	var changes []SettingChange
+	for i := range leftRaw {
+		if !reflect.DeepEqual(leftRaw[i].Value, rightRaw[i].Value) {
+			changes = append(changes, SettingChange{
+				Key:   leftRaw[i].Key,
+				Left:  leftShown[i].Value,
+				Right: rightShown[i].Value,
+			})
+		}
+	}
+	return changes
+}
+
+// writeChanges prints changes as an aligned KEY / LEFT / RIGHT table
+func writeChanges(w io.Writer, changes []SettingChange) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tLEFT\tRIGHT")
+	for _, c := range changes {
+		left, err := json.Marshal(c.Left)
+		if err != nil {
+			return fmt.Errorf("failed to format %s: %w", c.Key, err)
+		}
+		right, err := json.Marshal(c.Right)
+		if err != nil {
+			return fmt.Errorf("failed to format %s: %w", c.Key, err)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Key, left, right)
+	}
+	return tw.Flush()
+}
+
+// writeLoadError reports a failed load, listing each validation problem on its own line
+func writeLoadError(w io.Writer, file string, err error) {
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) < 2 {
+		fmt.Fprintf(w, "%s: %v\n", file, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%s: %d configuration errors:\n", file, len(validationErrs))
+	for _, e := range validationErrs {
+		fmt.Fprintf(w, "  %v\n", e)
+	}
+}
@@ -4,8 +4,12 @@ package config
 import (
 	"context"
 	"fmt"
+	"net"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/lumitut/lumi-go/internal/observability/logger"
@@ -50,14 +54,24 @@ type ServerConfig struct {
 	HTTPIdleTimeout  time.Duration `json:"httpIdleTimeout" mapstructure:"httpIdleTimeout"`
 
 	// RPC server
-	RPCPort         string        `json:"rpcPort" mapstructure:"rpcPort"`
-	RPCReadTimeout  time.Duration `json:"rpcReadTimeout" mapstructure:"rpcReadTimeout"`
-	RPCWriteTimeout time.Duration `json:"rpcWriteTimeout" mapstructure:"rpcWriteTimeout"`
+	RPCPort          string        `json:"rpcPort" mapstructure:"rpcPort"`
+	RPCReadTimeout   time.Duration `json:"rpcReadTimeout" mapstructure:"rpcReadTimeout"`
+	RPCWriteTimeout  time.Duration `json:"rpcWriteTimeout" mapstructure:"rpcWriteTimeout"`
+	EnableReflection bool          `json:"enableReflection" mapstructure:"enableReflection"` // ignored in production
 
 	// Common
 	GracefulShutdownTimeout time.Duration `json:"gracefulShutdownTimeout" mapstructure:"gracefulShutdownTimeout"`
+	PreStopDelay            time.Duration `json:"preStopDelay" mapstructure:"preStopDelay"` // keep serving while load balancers deregister
+	DrainTimeout            time.Duration `json:"drainTimeout" mapstructure:"drainTimeout"` // then close long-lived connections
 	EnablePProf             bool          `json:"enablePProf" mapstructure:"enablePProf"`
 	PProfPort               string        `json:"pprofPort" mapstructure:"pprofPort"`
+
+	// Admin listeners serving metrics (metricsPort) and pprof (pprofPort)
+	// away from the public API port, on loopback unless 0.0.0.0 is set
+	AdminBindAddress string `json:"adminBindAddress" mapstructure:"adminBindAddress"`
+	AdminHealth      bool   `json:"adminHealth" mapstructure:"adminHealth"`             // also serve the probes on the metrics listener
+	AdminOnly        bool   `json:"adminOnly" mapstructure:"adminOnly"`                 // keep metrics and pprof off the public router
+	AdminToken       string `json:"adminToken" mapstructure:"adminToken" secret:"true"` // bearer token for /admin; the admin API is off when empty
 }
 
 // ClientsConfig holds optional external client configurations
@@ -75,13 +89,13 @@ type ClientsConfig struct {
 // DatabaseClientConfig holds simplified database client configuration
 type DatabaseClientConfig struct {
 	Enabled bool   `json:"enabled" mapstructure:"enabled"`
-	URL     string `json:"url" mapstructure:"url"` // Connection string
+	URL     string `json:"url" mapstructure:"url" secret:"true"` // Connection string or secret reference
 }
 
 // RedisClientConfig holds simplified Redis client configuration
 type RedisClientConfig struct {
 	Enabled bool   `json:"enabled" mapstructure:"enabled"`
-	URL     string `json:"url" mapstructure:"url"` // Connection string
+	URL     string `json:"url" mapstructure:"url" secret:"true"` // Connection string or secret reference
 }
 
 // TracingClientConfig holds simplified tracing client configuration
@@ -120,7 +134,18 @@ type MiddlewareConfig struct {
 	RateLimitEnabled bool   `json:"rateLimitEnabled" mapstructure:"rateLimitEnabled"`
 	RateLimitRate    int    `json:"rateLimitRate" mapstructure:"rateLimitRate"` // requests per minute
 	RateLimitBurst   int    `json:"rateLimitBurst" mapstructure:"rateLimitBurst"`
-	RateLimitType    string `json:"rateLimitType" mapstructure:"rateLimitType"` // "ip", "user", "api_key"
+	RateLimitType    string `json:"rateLimitType" mapstructure:"rateLimitType"`       // "ip", "user", "api_key"
+	RateLimitHeaders string `json:"rateLimitHeaders" mapstructure:"rateLimitHeaders"` // "legacy", "ietf", "both"
+
+	// Distributed rate limiting (shared by all replicas through clients.redis)
+	RateLimitStore       string `json:"rateLimitStore" mapstructure:"rateLimitStore"`             // "memory", "redis"
+	RateLimitAlgorithm   string `json:"rateLimitAlgorithm" mapstructure:"rateLimitAlgorithm"`     // "token_bucket", "sliding_window" (redis only)
+	RateLimitFailureMode string `json:"rateLimitFailureMode" mapstructure:"rateLimitFailureMode"` // "local", "open", "closed" while redis is down
+
+	// Rate limit policies and API key plans, applied together with the global limit
+	RateLimitPolicies    []RateLimitPolicyConfig        `json:"rateLimitPolicies" mapstructure:"rateLimitPolicies"`
+	RateLimitPlans       map[string]RateLimitPlanConfig `json:"rateLimitPlans" mapstructure:"rateLimitPlans"`
+	RateLimitAPIKeyPlans []string                       `json:"rateLimitAPIKeyPlans" mapstructure:"rateLimitAPIKeyPlans" secret:"true"` // "apikey=plan"
 
 	// Recovery
 	RecoveryStackTrace bool `json:"recoveryStackTrace" mapstructure:"recoveryStackTrace"`
@@ -139,6 +164,79 @@ type MiddlewareConfig struct {
 	LogRequestBody   bool          `json:"logRequestBody" mapstructure:"logRequestBody"`
 	LogResponseBody  bool          `json:"logResponseBody" mapstructure:"logResponseBody"`
 	LogSlowThreshold time.Duration `json:"logSlowThreshold" mapstructure:"logSlowThreshold"`
+
+	// Maintenance mode (toggled by features.maintenanceMode)
+	MaintenanceRetryAfter   time.Duration `json:"maintenanceRetryAfter" mapstructure:"maintenanceRetryAfter"`
+	MaintenanceAllowIPs     []string      `json:"maintenanceAllowIPs" mapstructure:"maintenanceAllowIPs"` // IPs or CIDR ranges
+	MaintenanceAllowAPIKeys []string      `json:"maintenanceAllowAPIKeys" mapstructure:"maintenanceAllowAPIKeys" secret:"true"`
+
+	// Request timeouts
+	RequestTimeout       time.Duration `json:"requestTimeout" mapstructure:"requestTimeout"`             // 0 disables
+	RequestTimeoutRoutes []string      `json:"requestTimeoutRoutes" mapstructure:"requestTimeoutRoutes"` // "prefix=duration"
+
+	// Compression
+	CompressionEnabled      bool     `json:"compressionEnabled" mapstructure:"compressionEnabled"`
+	CompressionMinSize      int      `json:"compressionMinSize" mapstructure:"compressionMinSize"`           // bytes
+	CompressionContentTypes []string `json:"compressionContentTypes" mapstructure:"compressionContentTypes"` // "text/" matches all text types
+	DecompressionMaxSize    int64    `json:"decompressionMaxSize" mapstructure:"decompressionMaxSize"`       // bytes, 0 rejects compressed requests
+}
+
+// RateLimitPolicyConfig is a rate limit on the requests matching its routes and methods
+type RateLimitPolicyConfig struct {
+	Name      string        `json:"name" mapstructure:"name"`
+	Routes    []string      `json:"routes" mapstructure:"routes"`       // HTTP routes or gRPC full methods, "*" suffix for prefixes; empty matches all
+	Methods   []string      `json:"methods" mapstructure:"methods"`     // HTTP methods; empty matches all
+	Identity  string        `json:"identity" mapstructure:"identity"`   // "ip", "user", "api_key", "tenant"
+	Algorithm string        `json:"algorithm" mapstructure:"algorithm"` // "token_bucket", "sliding_window"
+	Rate      int           `json:"rate" mapstructure:"rate"`           // requests per window
+	Burst     int           `json:"burst" mapstructure:"burst"`         // defaults to rate
+	Window    time.Duration `json:"window" mapstructure:"window"`       // defaults to 1m
+}
+
+// RateLimitPlanConfig is a quota for each API key on the plan
+type RateLimitPlanConfig struct {
+	Algorithm string        `json:"algorithm" mapstructure:"algorithm"` // "token_bucket", "sliding_window"
+	Rate      int           `json:"rate" mapstructure:"rate"`           // requests per window
+	Burst     int           `json:"burst" mapstructure:"burst"`         // defaults to rate
+	Window    time.Duration `json:"window" mapstructure:"window"`       // defaults to 1m
+}
+
+// RateLimitAPIKeyPlanMap parses the API key plan assignments into plan
+// names keyed by API key
+func (m MiddlewareConfig) RateLimitAPIKeyPlanMap() (map[string]string, error) {
+	plans := make(map[string]string, len(m.RateLimitAPIKeyPlans))
+	for i, entry := range m.RateLimitAPIKeyPlans {
+		// Report entries by position; they hold API keys
+		key, plan, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid rate limit API key plan %d: expected apikey=plan", i)
+		}
+		// Plan names are lower-cased when the configuration is read
+		plan = strings.ToLower(plan)
+		if _, ok := m.RateLimitPlans[plan]; !ok {
+			return nil, fmt.Errorf("invalid rate limit API key plan %d: unknown plan %q", i, plan)
+		}
+		plans[key] = plan
+	}
+	return plans, nil
+}
+
+// RequestTimeoutRouteMap parses the per-route request timeouts, keyed by HTTP
+// path or gRPC full method prefix
+func (m MiddlewareConfig) RequestTimeoutRouteMap() (map[string]time.Duration, error) {
+	routes := make(map[string]time.Duration, len(m.RequestTimeoutRoutes))
+	for _, entry := range m.RequestTimeoutRoutes {
+		prefix, value, ok := strings.Cut(entry, "=")
+		if !ok || prefix == "" {
+			return nil, fmt.Errorf("invalid request timeout route %q: expected prefix=duration", entry)
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid request timeout route %q: invalid duration", entry)
+		}
+		routes[prefix] = timeout
+	}
+	return routes, nil
 }
 
 // FeaturesConfig holds feature flags
@@ -146,6 +244,24 @@ type FeaturesConfig struct {
 	EnableNewAPI       bool `json:"enableNewAPI" mapstructure:"enableNewAPI"`
 	EnableBetaFeatures bool `json:"enableBetaFeatures" mapstructure:"enableBetaFeatures"`
 	MaintenanceMode    bool `json:"maintenanceMode" mapstructure:"maintenanceMode"`
+
+	// FlagsFile is a JSON file of targeted flag definitions, reloaded on change
+	FlagsFile string `json:"flagsFile" mapstructure:"flagsFile"`
+}
+
+// Flags returns pointers to the feature flags keyed by their configuration name
+func (f *FeaturesConfig) Flags() map[string]*bool {
+	flags := make(map[string]*bool)
+	v := reflect.ValueOf(f).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type.Kind() != reflect.Bool {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		flags[name] = v.Field(i).Addr().Interface().(*bool)
+	}
+	return flags
 }
 
 // Load loads configuration using viper from JSON file and environment variables
@@ -196,58 +312,228 @@ func LoadWithDefaults() (*Config, error) {
 	return &cfg, nil
 }
 
-// Validate validates the configuration
+// Default shutdown timings, kept when they fit the graceful shutdown timeout
+const (
+	defaultPreStopDelay = 5 * time.Second
+	defaultDrainTimeout = 20 * time.Second
+)
+
+// fitShutdownTimings derives the pre-stop delay and drain timeout that were
+// not set from the graceful shutdown timeout, so the defaults never exceed a
+// shorter timeout. Each keeps its default when it fits, always leaving a
+// sixth of the timeout for stopping the rest of the application.
+func (s *ServerConfig) fitShutdownTimings(preStopSet, drainSet bool) {
+	timeout := s.GracefulShutdownTimeout
+	if timeout <= 0 {
+		return
+	}
+	headroom := timeout / 6
+
+	if !preStopSet {
+		s.PreStopDelay = min(defaultPreStopDelay, headroom)
+		if drainSet {
+			s.PreStopDelay = max(min(s.PreStopDelay, timeout-s.DrainTimeout-headroom), 0)
+		}
+	}
+	if !drainSet {
+		s.DrainTimeout = max(min(defaultDrainTimeout, timeout-s.PreStopDelay-headroom), 0)
+	}
+}
+
+// validateRateLimitPolicies checks the rate limit policies and plans
+func validateRateLimitPolicies(m MiddlewareConfig) []error {
+	var errs []error
+
+	names := make(map[string]bool)
+	for i, policy := range m.RateLimitPolicies {
+		switch {
+		case policy.Name == "":
+			errs = append(errs, fmt.Errorf("rate limit policy %d: name is required", i))
+		case policy.Name == "global":
+			errs = append(errs, fmt.Errorf("rate limit policy %d: name global is reserved for the global limit", i))
+		case names[policy.Name]:
+			errs = append(errs, fmt.Errorf("rate limit policy %d: duplicate name %s", i, policy.Name))
+		}
+		names[policy.Name] = true
+
+		if policy.Identity != "" && !slices.Contains(rateLimitIdentities, policy.Identity) {
+			errs = append(errs, fmt.Errorf("rate limit policy %s: invalid identity: %s", policy.Name, policy.Identity))
+		}
+		errs = append(errs, validateRateLimit("rate limit policy "+policy.Name, policy.Algorithm, policy.Rate, policy.Burst, policy.Window)...)
+	}
+
+	plans := make([]string, 0, len(m.RateLimitPlans))
+	for name := range m.RateLimitPlans {
+		plans = append(plans, name)
+	}
+	slices.Sort(plans)
+	for _, name := range plans {
+		plan := m.RateLimitPlans[name]
+		errs = append(errs, validateRateLimit("rate limit plan "+name, plan.Algorithm, plan.Rate, plan.Burst, plan.Window)...)
+	}
+	if _, err := m.RateLimitAPIKeyPlanMap(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// validateRateLimit checks the limits of a rate limit policy or plan
+func validateRateLimit(name, algorithm string, rate, burst int, window time.Duration) []error {
+	var errs []error
+	if algorithm != "" && !slices.Contains(rateLimitAlgorithms, algorithm) {
+		errs = append(errs, fmt.Errorf("%s: invalid algorithm: %s", name, algorithm))
+	}
+	if rate <= 0 {
+		errs = append(errs, fmt.Errorf("%s: rate must be positive", name))
+	}
+	if burst < 0 {
+		errs = append(errs, fmt.Errorf("%s: invalid burst: %d", name, burst))
+	}
+	if window < 0 {
+		errs = append(errs, fmt.Errorf("%s: invalid window: %s", name, window))
+	}
+	return errs
+}
+
+// Allowed values, shared by Validate and the generated JSON Schema
+var (
+	environments   = []string{"development", "staging", "production"}
+	logLevels      = []string{"debug", "info", "warn", "error", "fatal"}
+	rateLimitTypes = []string{"ip", "user", "api_key"}
+
+	rateLimitHeaderFormats = []string{"legacy", "ietf", "both"}
+
+	rateLimitStores       = []string{"memory", "redis"}
+	rateLimitAlgorithms   = []string{"token_bucket", "sliding_window"}
+	rateLimitFailureModes = []string{"local", "open", "closed"}
+	rateLimitIdentities   = []string{"ip", "user", "api_key", "tenant"}
+)
+
+// ValidationErrors aggregates every problem found in a configuration
+type ValidationErrors []error
+
+// Error lists all validation errors
+func (e ValidationErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d configuration errors: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual errors for errors.Is and errors.As
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
+
+// Validate validates the configuration, reporting every problem found as ValidationErrors
 func (c *Config) Validate() error {
+	var errs ValidationErrors
+
 	// Validate service name
 	if c.Service.Name == "" {
-		return fmt.Errorf("service name is required")
+		errs = append(errs, fmt.Errorf("service name is required"))
 	}
 
 	// Validate environment
-	validEnvs := map[string]bool{
-		"development": true,
-		"staging":     true,
-		"production":  true,
-	}
-	if !validEnvs[c.Service.Environment] {
-		return fmt.Errorf("invalid environment: %s", c.Service.Environment)
+	if !slices.Contains(environments, c.Service.Environment) {
+		errs = append(errs, fmt.Errorf("invalid environment: %s", c.Service.Environment))
 	}
 
 	// Validate log level
-	validLevels := map[string]bool{
-		"debug": true,
-		"info":  true,
-		"warn":  true,
-		"error": true,
-		"fatal": true,
+	if !slices.Contains(logLevels, c.Service.LogLevel) {
+		errs = append(errs, fmt.Errorf("invalid log level: %s", c.Service.LogLevel))
 	}
-	if !validLevels[c.Service.LogLevel] {
-		return fmt.Errorf("invalid log level: %s", c.Service.LogLevel)
+	if c.Observability.LogLevel != "" && !slices.Contains(logLevels, c.Observability.LogLevel) {
+		errs = append(errs, fmt.Errorf("invalid observability log level: %s", c.Observability.LogLevel))
 	}
 
 	// Validate ports
 	if err := validatePort(c.Server.HTTPPort); err != nil {
-		return fmt.Errorf("invalid HTTP port: %w", err)
+		errs = append(errs, fmt.Errorf("invalid HTTP port: %w", err))
 	}
 	if err := validatePort(c.Server.RPCPort); err != nil {
-		return fmt.Errorf("invalid RPC port: %w", err)
+		errs = append(errs, fmt.Errorf("invalid RPC port: %w", err))
+	}
+
+	// Validate shutdown timings
+	if c.Server.PreStopDelay < 0 {
+		errs = append(errs, fmt.Errorf("invalid pre-stop delay: %s", c.Server.PreStopDelay))
+	}
+	if c.Server.DrainTimeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid drain timeout: %s", c.Server.DrainTimeout))
+	}
+	if budget := c.Server.PreStopDelay + c.Server.DrainTimeout; c.Server.GracefulShutdownTimeout > 0 && budget >= c.Server.GracefulShutdownTimeout {
+		errs = append(errs, fmt.Errorf("pre-stop delay plus drain timeout (%s) must be less than the graceful shutdown timeout (%s)",
+			budget, c.Server.GracefulShutdownTimeout))
 	}
 
 	// Validate rate limit type
-	validRateLimitTypes := map[string]bool{
-		"ip":      true,
-		"user":    true,
-		"api_key": true,
+	if c.Middleware.RateLimitEnabled && !slices.Contains(rateLimitTypes, c.Middleware.RateLimitType) {
+		errs = append(errs, fmt.Errorf("invalid rate limit type: %s", c.Middleware.RateLimitType))
+	}
+	if c.Middleware.RateLimitEnabled && c.Middleware.RateLimitHeaders != "" && !slices.Contains(rateLimitHeaderFormats, c.Middleware.RateLimitHeaders) {
+		errs = append(errs, fmt.Errorf("invalid rate limit headers: %s", c.Middleware.RateLimitHeaders))
+	}
+	if c.Middleware.RateLimitEnabled && c.Middleware.RateLimitStore == "redis" {
+		if _, ok := c.GetRedisURL(); !ok {
+			errs = append(errs, fmt.Errorf("redis rate limit store requires clients.redis to be enabled with a url"))
+		}
+		if !slices.Contains(rateLimitAlgorithms, c.Middleware.RateLimitAlgorithm) {
+			errs = append(errs, fmt.Errorf("invalid rate limit algorithm: %s", c.Middleware.RateLimitAlgorithm))
+		}
+		if !slices.Contains(rateLimitFailureModes, c.Middleware.RateLimitFailureMode) {
+			errs = append(errs, fmt.Errorf("invalid rate limit failure mode: %s", c.Middleware.RateLimitFailureMode))
+		}
+	}
+	if c.Middleware.RateLimitEnabled && c.Middleware.RateLimitStore != "" && !slices.Contains(rateLimitStores, c.Middleware.RateLimitStore) {
+		errs = append(errs, fmt.Errorf("invalid rate limit store: %s", c.Middleware.RateLimitStore))
 	}
-	if c.Middleware.RateLimitEnabled && !validRateLimitTypes[c.Middleware.RateLimitType] {
-		return fmt.Errorf("invalid rate limit type: %s", c.Middleware.RateLimitType)
+	if c.Middleware.RateLimitEnabled {
+		errs = append(errs, validateRateLimitPolicies(c.Middleware)...)
 	}
 
+	// Validate request timeouts
+	if c.Middleware.RequestTimeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid request timeout: %s", c.Middleware.RequestTimeout))
+	}
+	if _, err := c.Middleware.RequestTimeoutRouteMap(); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate compression
+	if c.Middleware.CompressionMinSize < 0 {
+		errs = append(errs, fmt.Errorf("invalid compression min size: %d", c.Middleware.CompressionMinSize))
+	}
+	if c.Middleware.DecompressionMaxSize < 0 {
+		errs = append(errs, fmt.Errorf("invalid decompression max size: %d", c.Middleware.DecompressionMaxSize))
+	}
+
+	// Validate maintenance mode
+	if c.Middleware.MaintenanceRetryAfter < 0 {
+		errs = append(errs, fmt.Errorf("invalid maintenance retry after: %s", c.Middleware.MaintenanceRetryAfter))
+	}
+	for _, entry := range c.Middleware.MaintenanceAllowIPs {
+		if !validIPOrCIDR(entry) {
+			errs = append(errs, fmt.Errorf("invalid maintenance allowlist entry: %s", entry))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
 // LogConfig logs the configuration (with sensitive values redacted)
 func (c *Config) LogConfig(ctx context.Context) {
+	// Log from the redacted copy so secrets can never be printed
+	c = c.Redacted()
+
 	logger.Info(ctx, "Configuration loaded",
 		zap.String("service_name", c.Service.Name),
 		zap.String("service_version", c.Service.Version),
@@ -289,6 +575,15 @@ func (c *Config) IsTracingEnabled() bool {
 
 // Helper functions
 
+// validIPOrCIDR reports whether entry is an IP address or CIDR range
+func validIPOrCIDR(entry string) bool {
+	if strings.Contains(entry, "/") {
+		_, _, err := net.ParseCIDR(entry)
+		return err == nil
+	}
+	return net.ParseIP(entry) != nil
+}
+
 func validatePort(port string) error {
 	p, err := strconv.Atoi(port)
 	if err != nil {
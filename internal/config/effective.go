@@ -0,0 +1,75 @@
+// Package config provides configuration management for the application
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Setting is an effective configuration value and the layer that set it
+type Setting struct {
+	Key    string
+	Value  interface{}
+	Source string // "default", a config file path, "env LUMI_..." or "flag -env"
+}
+
+// WriteSettings prints settings as an aligned KEY / VALUE / SOURCE table
+func WriteSettings(w io.Writer, settings []Setting) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, s := range settings {
+		value, err := json.Marshal(s.Value)
+		if err != nil {
+			return fmt.Errorf("failed to format %s: %w", s.Key, err)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, value, s.Source)
+	}
+	return tw.Flush()
+}
+
+// collectSettings appends the leaf values of a configuration struct in
+// declaration order, looking up their sources by lower-cased key
+func collectSettings(v reflect.Value, prefix string, sources map[string]string, settings *[]Setting) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := configKey(t.Field(i))
+		if name == "" {
+			continue
+		}
+		path := joinKey(prefix, name)
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			collectSettings(field, path, sources, settings)
+			continue
+		}
+
+		value := field.Interface()
+		if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+
+		source, ok := sources[strings.ToLower(path)]
+		if !ok {
+			source = nestedSource(sources, strings.ToLower(path))
+		}
+
+		*settings = append(*settings, Setting{Key: path, Value: value, Source: source})
+	}
+}
+
+// nestedSource returns the source of a setting whose file keys are nested
+// below it, such as the entries of a map, or "default"
+func nestedSource(sources map[string]string, key string) string {
+	for k, source := range sources {
+		if strings.HasPrefix(k, key+".") {
+			return source
+		}
+	}
+	return "default"
+}
@@ -0,0 +1,132 @@
+// Package config provides configuration management for the application
+package config
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Event is a typed configuration change published by the Watcher after a
+// successful reload
+type Event interface {
+	configEvent()
+}
+
+// LogLevelChanged is published when observability.logLevel changes
+type LogLevelChanged struct {
+	Old string
+	New string
+}
+
+// RateLimitChanged is published when the rate limit rate or burst changes
+type RateLimitChanged struct {
+	Rate  int
+	Burst int
+	Type  string
+}
+
+// CORSOriginsChanged is published when the allowed CORS origins change
+type CORSOriginsChanged struct {
+	Origins []string
+}
+
+// FeaturesChanged is published when any feature flag changes
+type FeaturesChanged struct {
+	Old FeaturesConfig
+	New FeaturesConfig
+}
+
+// Reloaded is published after every successful reload, after the more
+// specific events
+type Reloaded struct {
+	Old *Config
+	New *Config
+	// RestartRequired lists changed sections that only take effect on restart
+	RestartRequired []string
+}
+
+func (LogLevelChanged) configEvent()    {}
+func (RateLimitChanged) configEvent()   {}
+func (CORSOriginsChanged) configEvent() {}
+func (FeaturesChanged) configEvent()    {}
+func (Reloaded) configEvent()           {}
+
+// Diff returns the events describing the changes from old to new
+func Diff(old, new *Config) []Event {
+	var events []Event
+
+	if old.Observability.LogLevel != new.Observability.LogLevel {
+		events = append(events, LogLevelChanged{
+			Old: old.Observability.LogLevel,
+			New: new.Observability.LogLevel,
+		})
+	}
+
+	if old.Middleware.RateLimitRate != new.Middleware.RateLimitRate ||
+		old.Middleware.RateLimitBurst != new.Middleware.RateLimitBurst {
+		events = append(events, RateLimitChanged{
+			Rate:  new.Middleware.RateLimitRate,
+			Burst: new.Middleware.RateLimitBurst,
+			Type:  new.Middleware.RateLimitType,
+		})
+	}
+
+	if !slices.Equal(old.Middleware.CORSAllowOrigins, new.Middleware.CORSAllowOrigins) {
+		events = append(events, CORSOriginsChanged{
+			Origins: new.Middleware.CORSAllowOrigins,
+		})
+	}
+
+	if old.Features != new.Features {
+		events = append(events, FeaturesChanged{
+			Old: old.Features,
+			New: new.Features,
+		})
+	}
+
+	return append(events, Reloaded{
+		Old:             old,
+		New:             new,
+		RestartRequired: restartRequired(old, new),
+	})
+}
+
+// restartRequired lists the changed settings that are not applied at runtime
+func restartRequired(old, new *Config) []string {
+	var sections []string
+
+	if old.Service != new.Service {
+		sections = append(sections, "service")
+	}
+	if old.Server != new.Server {
+		sections = append(sections, "server")
+	}
+	if old.Clients != new.Clients {
+		sections = append(sections, "clients")
+	}
+	if stripObservabilityLogLevel(old.Observability) != stripObservabilityLogLevel(new.Observability) {
+		sections = append(sections, "observability")
+	}
+	if !reflect.DeepEqual(stripReloadableMiddleware(old.Middleware), stripReloadableMiddleware(new.Middleware)) {
+		sections = append(sections, "middleware")
+	}
+	if old.Features.FlagsFile != new.Features.FlagsFile {
+		sections = append(sections, "features")
+	}
+
+	return sections
+}
+
+// stripObservabilityLogLevel clears the observability settings applied at runtime
+func stripObservabilityLogLevel(c ObservabilityConfig) ObservabilityConfig {
+	c.LogLevel = ""
+	return c
+}
+
+// stripReloadableMiddleware clears the middleware settings applied at runtime
+func stripReloadableMiddleware(c MiddlewareConfig) MiddlewareConfig {
+	c.RateLimitRate = 0
+	c.RateLimitBurst = 0
+	c.CORSAllowOrigins = nil
+	return c
+}
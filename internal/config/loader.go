@@ -22,31 +22,40 @@ func NewConfigLoader() *ConfigLoader {
 	}
 }
 
+// SetStrict makes the loader reject config files containing unknown keys
+func (cl *ConfigLoader) SetStrict(strict bool) {
+	cl.parser.SetStrict(strict)
+}
+
+// RegisterSecretProvider adds a provider for secret references, e.g. a
+// Vault backend; file:// and env:// are supported by default
+func (cl *ConfigLoader) RegisterSecretProvider(provider SecretProvider) {
+	cl.parser.RegisterSecretProvider(provider)
+}
+
 // LoadFromFlags loads configuration using command-line flags
 func (cl *ConfigLoader) LoadFromFlags() (*Config, error) {
 	// Define command-line flags
 	configFile := flag.String("config", "", "Path to configuration file (default: cmd/server/schema/lumi.json)")
 	envOverride := flag.String("env", "", "Override environment (development/staging/production)")
+	strict := flag.Bool("strict", false, "Reject unknown keys in the configuration file")
 	flag.Parse()
 
+	if *strict {
+		cl.SetStrict(true)
+	}
+
 	// Set config path
 	cl.configPath = *configFile
 	if cl.configPath == "" {
 		cl.configPath = "cmd/server/schema/lumi.json"
 	}
 
-	// Load configuration
-	cfg, err := cl.parser.LoadConfig(cl.configPath)
-	if err != nil {
-		return nil, err
-	}
-
-	// Override environment if specified
-	if *envOverride != "" {
-		cfg.Service.Environment = *envOverride
-	}
+	// Override environment if specified; this also selects the environment overlay
+	cl.parser.SetEnvironment(*envOverride)
 
-	return cfg, nil
+	// Load configuration
+	return cl.parser.LoadConfig(cl.configPath)
 }
 
 // LoadFromFile loads configuration from a specific file
@@ -57,31 +66,29 @@ func (cl *ConfigLoader) LoadFromFile(configPath string) (*Config, error) {
 
 // LoadFromEnvironment loads configuration from environment variables only
 func (cl *ConfigLoader) LoadFromEnvironment() (*Config, error) {
-	// Create a parser that doesn't require a config file
-	parser := NewParser()
-
 	// Don't set a config file, just use defaults and env vars
-	var cfg Config
-	if err := parser.GetViper().Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to load from environment: %w", err)
-	}
-
-	// Validate configuration
-	if err := cfg.Validate(); err != nil {
-		return nil, err
-	}
+	cl.configPath = ""
+	return cl.parser.LoadConfig("")
+}
 
-	return &cfg, nil
+// Reload re-reads configuration from the sources used by the last load,
+// re-applying any -env override. It returns an error, and no configuration,
+// if the result is invalid.
+func (cl *ConfigLoader) Reload() (*Config, error) {
+	return cl.parser.LoadConfig(cl.configPath)
 }
 
 // LoadDefault loads the default configuration
-// Priority order: Environment variables > Config file > Defaults
+// Priority order: Environment variables > lumi.local.json > lumi.<env>.json > Config file > Defaults
 func LoadDefault(ctx context.Context) (*Config, error) {
-	loader := NewConfigLoader()
+	return NewConfigLoader().LoadDefault(ctx)
+}
 
+// LoadDefault loads the default configuration, remembering its source for Reload
+func (cl *ConfigLoader) LoadDefault(ctx context.Context) (*Config, error) {
 	// Check if running with flags
 	if len(os.Args) > 1 {
-		return loader.LoadFromFlags()
+		return cl.LoadFromFlags()
 	}
 
 	// Try to load from default config file
@@ -89,7 +96,7 @@ func LoadDefault(ctx context.Context) (*Config, error) {
 
 	// Check if we're in the root directory or need to find it
 	if _, err := os.Stat(defaultPath); err == nil {
-		return loader.LoadFromFile(defaultPath)
+		return cl.LoadFromFile(defaultPath)
 	}
 
 	// Try to find config file relative to executable
@@ -97,12 +104,12 @@ func LoadDefault(ctx context.Context) (*Config, error) {
 		exeDir := filepath.Dir(exe)
 		configPath := filepath.Join(exeDir, "schema", "lumi.json")
 		if _, err := os.Stat(configPath); err == nil {
-			return loader.LoadFromFile(configPath)
+			return cl.LoadFromFile(configPath)
 		}
 	}
 
 	// Fall back to environment variables and defaults only
-	return loader.LoadFromEnvironment()
+	return cl.LoadFromEnvironment()
 }
 
 // MustLoad loads configuration and panics on error
@@ -118,3 +125,15 @@ func MustLoad(ctx context.Context) *Config {
 func (cl *ConfigLoader) GetConfigPath() string {
 	return cl.configPath
 }
+
+// GetConfigFiles returns the base config file and its overlays, in merge
+// order, including overlays that do not exist yet
+func (cl *ConfigLoader) GetConfigFiles() []string {
+	return cl.parser.Files()
+}
+
+// Settings returns every value of cfg, with secrets redacted, and the layer
+// that set it: a default, a config file, an environment variable or -env
+func (cl *ConfigLoader) Settings(cfg *Config) []Setting {
+	return cl.parser.Settings(cfg)
+}
@@ -2,7 +2,11 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -10,14 +14,27 @@ import (
 
 // Parser handles configuration parsing using viper
 type Parser struct {
-	viper *viper.Viper
+	viper       *viper.Viper
+	secrets     *SecretResolver
+	strict      bool
+	skipSecrets bool
+	environment string
+
+	// Results of the last LoadConfig
+	sources map[string]string
+	files   []string
 }
 
 // NewParser creates a new configuration parser
 func NewParser() *Parser {
 	v := viper.New()
+	setDefaults(v)
+
+	return &Parser{viper: v, secrets: DefaultSecretResolver()}
+}
 
-	// Set configuration defaults
+// setDefaults registers the configuration defaults
+func setDefaults(v *viper.Viper) {
 	v.SetDefault("service.name", "lumi-go")
 	v.SetDefault("service.version", "unknown")
 	v.SetDefault("service.environment", "development")
@@ -30,9 +47,16 @@ func NewParser() *Parser {
 	v.SetDefault("server.httpIdleTimeout", "60s")
 	v.SetDefault("server.rpcReadTimeout", "30s")
 	v.SetDefault("server.rpcWriteTimeout", "30s")
+	v.SetDefault("server.enableReflection", false)
 	v.SetDefault("server.gracefulShutdownTimeout", "30s")
+	v.SetDefault("server.preStopDelay", defaultPreStopDelay.String())
+	v.SetDefault("server.drainTimeout", defaultDrainTimeout.String())
 	v.SetDefault("server.enablePProf", false)
 	v.SetDefault("server.pprofPort", "6060")
+	v.SetDefault("server.adminBindAddress", "127.0.0.1")
+	v.SetDefault("server.adminHealth", false)
+	v.SetDefault("server.adminOnly", false)
+	v.SetDefault("server.adminToken", "")
 
 	// Client defaults (simplified - just connection strings)
 	v.SetDefault("clients.database.enabled", false)
@@ -60,6 +84,10 @@ func NewParser() *Parser {
 	v.SetDefault("middleware.rateLimitRate", 60)
 	v.SetDefault("middleware.rateLimitBurst", 10)
 	v.SetDefault("middleware.rateLimitType", "ip")
+	v.SetDefault("middleware.rateLimitHeaders", "both")
+	v.SetDefault("middleware.rateLimitStore", "memory")
+	v.SetDefault("middleware.rateLimitAlgorithm", "token_bucket")
+	v.SetDefault("middleware.rateLimitFailureMode", "local")
 	v.SetDefault("middleware.recoveryStackTrace", true)
 	v.SetDefault("middleware.recoveryStackSize", 4096)
 	v.SetDefault("middleware.recoveryPrintStack", false)
@@ -69,49 +97,192 @@ func NewParser() *Parser {
 	v.SetDefault("middleware.logRequestBody", false)
 	v.SetDefault("middleware.logResponseBody", false)
 	v.SetDefault("middleware.logSlowThreshold", "1s")
+	v.SetDefault("middleware.maintenanceRetryAfter", "5m")
+	v.SetDefault("middleware.requestTimeout", "10s")
+	v.SetDefault("middleware.compressionEnabled", true)
+	v.SetDefault("middleware.compressionMinSize", 1024)
+	v.SetDefault("middleware.compressionContentTypes", []string{"application/json", "application/problem+json", "application/javascript", "application/xml", "image/svg+xml", "text/"})
+	v.SetDefault("middleware.decompressionMaxSize", 10485760)
 
 	v.SetDefault("features.enableNewAPI", false)
 	v.SetDefault("features.enableBetaFeatures", false)
 	v.SetDefault("features.maintenanceMode", false)
+	v.SetDefault("features.flagsFile", "")
+}
+
+// SetStrict makes LoadConfig reject config files containing unknown keys
+func (p *Parser) SetStrict(strict bool) {
+	p.strict = strict
+}
 
-	return &Parser{viper: v}
+// RegisterSecretProvider adds a provider for secret references, replacing
+// any provider for the same scheme
+func (p *Parser) RegisterSecretProvider(provider SecretProvider) {
+	p.secrets.Register(provider)
 }
 
-// LoadConfig loads configuration from file and environment variables
+// SetSkipSecrets leaves secret references unresolved, for validating
+// configuration where the referenced files and variables are not available
+func (p *Parser) SetSkipSecrets(skip bool) {
+	p.skipSecrets = skip
+}
+
+// SetEnvironment overrides service.environment, including when selecting
+// the environment overlay file
+func (p *Parser) SetEnvironment(environment string) {
+	p.environment = environment
+}
+
+// LoadConfig loads configuration in layers, each overriding the previous:
+//  1. defaults
+//  2. configFile (required unless empty)
+//  3. <name>.<environment>.<ext> next to configFile (optional)
+//  4. <name>.local.<ext> next to configFile (optional)
+//  5. LUMI_* environment variables
+//  6. the SetEnvironment override
+//
+// Nested sections are merged key by key; lists are replaced as a whole. The
+// format of each file (JSON, YAML or TOML) is detected from its extension.
 func (p *Parser) LoadConfig(configFile string) (*Config, error) {
-	// Set config file
-	p.viper.SetConfigFile(configFile)
-	p.viper.SetConfigType("json")
+	v := viper.New()
+	setDefaults(v)
 
 	// Enable environment variable override
 	// Environment variables will be in format: LUMI_SECTION_KEY
 	// For example: LUMI_SERVICE_NAME, LUMI_DATABASE_HOST
-	p.viper.SetEnvPrefix("LUMI")
-	p.viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	p.viper.AutomaticEnv()
-
-	// Read config file
-	if err := p.viper.ReadInConfig(); err != nil {
-		// It's okay if config file doesn't exist, we'll use defaults and env vars
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("failed to read config file: %w", err)
+	v.SetEnvPrefix("LUMI")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if p.environment != "" {
+		v.Set("service.environment", p.environment)
+	}
+
+	sources := make(map[string]string)
+	var files []string
+
+	if configFile != "" {
+		// Base file
+		if err := p.mergeFile(v, configFile, sources); err != nil {
+			return nil, err
+		}
+
+		// Overlays, selected by the environment resolved so far
+		overlays := overlayFiles(configFile, v.GetString("service.environment"))
+		for _, overlay := range overlays {
+			if _, err := os.Stat(overlay); os.IsNotExist(err) {
+				continue
+			}
+			if err := p.mergeFile(v, overlay, sources); err != nil {
+				return nil, err
+			}
+		}
+		files = append([]string{configFile}, overlays...)
+	}
+
+	// Record values set by environment variables and the override
+	for _, key := range v.AllKeys() {
+		envVar := "LUMI_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		if os.Getenv(envVar) != "" {
+			sources[key] = "env " + envVar
 		}
 	}
+	if p.environment != "" {
+		sources["service.environment"] = "flag -env"
+	}
 
 	// Unmarshal config
 	var cfg Config
-	if err := p.viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	// Fit the shutdown timings left unset within the shutdown timeout
+	_, preStopSet := sources["server.prestopdelay"]
+	_, drainSet := sources["server.draintimeout"]
+	cfg.Server.fitShutdownTimings(preStopSet, drainSet)
+
+	// Resolve secret references (file://, env://, ...)
+	if !p.skipSecrets {
+		if err := p.secrets.ResolveConfig(context.Background(), &cfg); err != nil {
+			return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+		}
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	p.viper = v
+	p.sources = sources
+	p.files = files
+
 	return &cfg, nil
 }
 
+// mergeFile deep-merges a config file into v, recording it as the source of its keys
+func (p *Parser) mergeFile(v *viper.Viper, file string, sources map[string]string) error {
+	format, err := configFormat(file)
+	if err != nil {
+		return err
+	}
+
+	// In strict mode, reject unknown keys before viper silently ignores them
+	if p.strict {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := checkUnknownKeys(file, data); err != nil {
+			return fmt.Errorf("invalid config file: %w", err)
+		}
+	}
+
+	layer := viper.New()
+	layer.SetConfigFile(file)
+	layer.SetConfigType(format)
+	if err := layer.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := v.MergeConfigMap(layer.AllSettings()); err != nil {
+		return fmt.Errorf("failed to merge config file %s: %w", file, err)
+	}
+	for _, key := range layer.AllKeys() {
+		sources[key] = file
+	}
+
+	return nil
+}
+
+// overlayFiles returns the environment and local override files for a base
+// config file, e.g. lumi.production.json and lumi.local.json for lumi.json
+func overlayFiles(configFile, environment string) []string {
+	ext := filepath.Ext(configFile)
+	stem := strings.TrimSuffix(configFile, ext)
+
+	var files []string
+	if environment != "" {
+		files = append(files, stem+"."+environment+ext)
+	}
+	return append(files, stem+".local"+ext)
+}
+
+// Files returns the config files considered by the last LoadConfig, in
+// merge order, including optional overlays that do not exist
+func (p *Parser) Files() []string {
+	return p.files
+}
+
+// Settings returns every value of cfg, with secrets redacted, and the layer
+// that set it in the last LoadConfig
+func (p *Parser) Settings(cfg *Config) []Setting {
+	var settings []Setting
+	collectSettings(reflect.ValueOf(cfg.Redacted()).Elem(), "", p.sources, &settings)
+	return settings
+}
+
 // GetViper returns the underlying viper instance for advanced usage
 func (p *Parser) GetViper() *viper.Viper {
 	return p.viper
@@ -0,0 +1,140 @@
+// Package config provides configuration management for the application
+package config
+
+//go:generate go run ./schemagen ../../cmd/server/schema/lumi.schema.json
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// durationPattern matches Go duration strings such as "15s" or "1h30m"
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// schemaEnums lists the allowed values of enumerated settings by key path
+var schemaEnums = map[string][]string{
+	"service.environment":             environments,
+	"service.logLevel":                logLevels,
+	"observability.logLevel":          logLevels,
+	"middleware.rateLimitType":        rateLimitTypes,
+	"middleware.rateLimitHeaders":     rateLimitHeaderFormats,
+	"middleware.rateLimitStore":       rateLimitStores,
+	"middleware.rateLimitAlgorithm":   rateLimitAlgorithms,
+	"middleware.rateLimitFailureMode": rateLimitFailureModes,
+
+	"middleware.rateLimitPolicies.identity":  rateLimitIdentities,
+	"middleware.rateLimitPolicies.algorithm": rateLimitAlgorithms,
+	"middleware.rateLimitPlans.algorithm":    rateLimitAlgorithms,
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// JSONSchema returns a JSON Schema for configuration files, generated from the
+// Config struct tags with the defaults registered in NewParser
+func JSONSchema() ([]byte, error) {
+	root := objectSchema(reflect.TypeOf(Config{}), "", NewParser().viper)
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "lumi-go configuration"
+
+	// Allow config files to reference the schema for editor support
+	root["properties"].(map[string]interface{})["$schema"] = map[string]interface{}{
+		"type": "string",
+	}
+
+	schema, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(schema, '\n'), nil
+}
+
+// objectSchema describes a configuration struct, rejecting unknown keys
+func objectSchema(t reflect.Type, prefix string, defaults *viper.Viper) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := configKey(sf)
+		if name == "" {
+			continue
+		}
+		path := joinKey(prefix, name)
+		properties[name] = fieldSchema(sf, path, defaults)
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// fieldSchema describes a single configuration setting
+func fieldSchema(sf reflect.StructField, path string, defaults *viper.Viper) map[string]interface{} {
+	t := sf.Type
+	if t.Kind() == reflect.Struct {
+		return objectSchema(t, path, defaults)
+	}
+
+	schema := make(map[string]interface{})
+	switch {
+	case t == durationType:
+		schema["type"] = "string"
+		schema["pattern"] = durationPattern
+	case t.Kind() == reflect.String:
+		schema["type"] = "string"
+	case t.Kind() == reflect.Bool:
+		schema["type"] = "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema["type"] = "integer"
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		schema["type"] = "array"
+		schema["items"] = map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct:
+		// Items share the list's key path, e.g. for enums
+		schema["type"] = "array"
+		schema["items"] = objectSchema(t.Elem(), path, defaults)
+	case t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Struct:
+		schema["type"] = "object"
+		schema["additionalProperties"] = objectSchema(t.Elem(), path, defaults)
+	}
+
+	if enum, ok := schemaEnums[path]; ok {
+		schema["enum"] = enum
+	}
+	if sf.Tag.Get("secret") == "true" {
+		schema["description"] = "Secret value or reference (file://, env://)"
+	}
+	if value := defaults.Get(path); value != nil {
+		schema["default"] = value
+	}
+
+	return schema
+}
+
+// configKey returns the configuration key of a struct field, or "" if the
+// field is not configurable
+func configKey(sf reflect.StructField) string {
+	if !sf.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+// joinKey joins configuration key path segments with dots
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
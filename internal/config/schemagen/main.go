@@ -0,0 +1,27 @@
+// Command schemagen writes the JSON Schema for lumi.json config files
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lumitut/lumi-go/internal/config"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: schemagen <output file>")
+		os.Exit(2)
+	}
+
+	schema, err := config.JSONSchema()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(os.Args[1], schema, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write schema: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,160 @@
+// Package config provides configuration management for the application
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// redactedValue replaces secret values in redacted configuration
+const redactedValue = "[REDACTED]"
+
+// SecretProvider resolves secret references of the form scheme://ref
+type SecretProvider interface {
+	// Scheme returns the reference scheme handled by the provider, e.g. "file"
+	Scheme() string
+	// Resolve returns the secret value for ref, the part after "scheme://"
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// FileSecretProvider resolves file:// references by reading the file, e.g.
+// file:///run/secrets/db_url. A trailing newline is removed.
+type FileSecretProvider struct{}
+
+// Scheme returns "file"
+func (FileSecretProvider) Scheme() string {
+	return "file"
+}
+
+// Resolve reads the secret from the file at path
+func (FileSecretProvider) Resolve(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EnvSecretProvider resolves env:// references from environment variables,
+// e.g. env://DB_URL
+type EnvSecretProvider struct{}
+
+// Scheme returns "env"
+func (EnvSecretProvider) Scheme() string {
+	return "env"
+}
+
+// Resolve reads the secret from the named environment variable
+func (EnvSecretProvider) Resolve(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// SecretResolver resolves secret references in configuration fields tagged
+// secret:"true" using the registered providers
+type SecretResolver struct {
+	providers map[string]SecretProvider
+}
+
+// NewSecretResolver creates a resolver with the given providers
+func NewSecretResolver(providers ...SecretProvider) *SecretResolver {
+	r := &SecretResolver{providers: make(map[string]SecretProvider)}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+// DefaultSecretResolver creates a resolver for file:// and env:// references
+func DefaultSecretResolver() *SecretResolver {
+	return NewSecretResolver(FileSecretProvider{}, EnvSecretProvider{})
+}
+
+// Register adds a provider, replacing any provider for the same scheme
+func (r *SecretResolver) Register(p SecretProvider) {
+	r.providers[p.Scheme()] = p
+}
+
+// Resolve returns the secret a reference points to. Values that are not
+// references to a registered scheme, such as postgres:// URLs, are returned
+// unchanged.
+func (r *SecretResolver) Resolve(ctx context.Context, value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok {
+		return value, nil
+	}
+	p, ok := r.providers[scheme]
+	if !ok {
+		return value, nil
+	}
+	if ref == "" {
+		return "", fmt.Errorf("empty %s:// secret reference", scheme)
+	}
+	return p.Resolve(ctx, ref)
+}
+
+// ResolveConfig resolves the secret references in cfg in place
+func (r *SecretResolver) ResolveConfig(ctx context.Context, cfg *Config) error {
+	return walkSecrets(reflect.ValueOf(cfg).Elem(), "", func(path string, field reflect.Value) error {
+		resolved, err := r.Resolve(ctx, field.String())
+		if err != nil {
+			return fmt.Errorf("failed to resolve secret %s: %w", path, err)
+		}
+		field.SetString(resolved)
+		return nil
+	})
+}
+
+// Redacted returns a copy of the configuration with secret values replaced,
+// safe for logging and printing
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	_ = walkSecrets(reflect.ValueOf(&redacted).Elem(), "", func(_ string, field reflect.Value) error {
+		if field.String() != "" {
+			field.SetString(redactedValue)
+		}
+		return nil
+	})
+	return &redacted
+}
+
+// walkSecrets calls fn for every string field, or string list element, tagged
+// secret:"true", with the field's dotted JSON path
+func walkSecrets(v reflect.Value, prefix string, fn func(path string, field reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := configKey(sf)
+		if name == "" {
+			continue
+		}
+		path := joinKey(prefix, name)
+
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct:
+			if err := walkSecrets(field, path, fn); err != nil {
+				return err
+			}
+		case field.Kind() == reflect.String && sf.Tag.Get("secret") == "true":
+			if err := fn(path, field); err != nil {
+				return err
+			}
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String && sf.Tag.Get("secret") == "true":
+			// Copy the list first; configuration copies share its backing array
+			field.Set(reflect.AppendSlice(reflect.MakeSlice(field.Type(), 0, field.Len()), field))
+			for j := 0; j < field.Len(); j++ {
+				if err := fn(fmt.Sprintf("%s[%d]", path, j), field.Index(j)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+// Package config provides configuration management for the application
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// configFormats maps config file extensions onto viper config types
+var configFormats = map[string]string{
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+}
+
+// configFormat returns the format of a config file from its extension
+func configFormat(file string) (string, error) {
+	format, ok := configFormats[strings.ToLower(filepath.Ext(file))]
+	if !ok {
+		return "", fmt.Errorf("unsupported config file format %q (use .json, .yaml, .yml or .toml)", filepath.Ext(file))
+	}
+	return format, nil
+}
+
+// fileKey is a key found in a config file, with its position
+type fileKey struct {
+	path      []string // key segments from the document root
+	line, col int
+}
+
+// checkUnknownKeys reports every key in a config file that does not
+// correspond to a Config field, with its file, line and column. Keys are
+// matched case-insensitively, like viper does.
+func checkUnknownKeys(filename string, data []byte) error {
+	format, err := configFormat(filename)
+	if err != nil {
+		return err
+	}
+
+	var keys []fileKey
+	switch format {
+	case "yaml":
+		keys, err = yamlKeys(filename, data)
+	case "toml":
+		keys, err = tomlKeys(filename, data)
+	default:
+		keys, err = jsonKeys(filename, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	var errs ValidationErrors
+	for _, key := range keys {
+		parent, ok := fieldType(key.path[:len(key.path)-1])
+		if !ok || parent.Kind() != reflect.Struct {
+			// Reported at the unknown parent, or a type mismatch left to decoding
+			continue
+		}
+		name := key.path[len(key.path)-1]
+		if len(key.path) == 1 && name == "$schema" {
+			continue
+		}
+		if _, ok := structField(parent, name); !ok {
+			errs = append(errs, fmt.Errorf("%s:%d:%d: unknown key %q", filename, key.line, key.col, strings.Join(key.path, ".")))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// fieldType returns the type of the Config field at path
+func fieldType(path []string) (reflect.Type, bool) {
+	t := reflect.TypeOf(Config{})
+	for _, name := range path {
+		if t.Kind() != reflect.Struct {
+			return nil, false
+		}
+		sf, ok := structField(t, name)
+		if !ok {
+			return nil, false
+		}
+		t = sf.Type
+	}
+	return t, true
+}
+
+// structField finds a field by configuration key, case-insensitively
+func structField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if key := configKey(t.Field(i)); key != "" && strings.EqualFold(key, name) {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// jsonKeys lists the object keys of a JSON document
+func jsonKeys(filename string, data []byte) ([]fileKey, error) {
+	var keys []fileKey
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := collectJSONKeys(dec, data, nil, &keys); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, col := position(data, syntaxErr.Offset)
+			return nil, fmt.Errorf("%s:%d:%d: invalid JSON: %w", filename, line, col, err)
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: invalid JSON: unexpected end of file", filename)
+		}
+		return nil, fmt.Errorf("%s: invalid JSON: %w", filename, err)
+	}
+	return keys, nil
+}
+
+// collectJSONKeys records the keys of the JSON value at the decoder's
+// position, descending into nested objects
+func collectJSONKeys(dec *json.Decoder, data []byte, prefix []string, keys *[]fileKey) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return skipValue(dec, tok)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		path := appendKey(prefix, tok.(string))
+		line, col := position(data, keyStart(data, dec.InputOffset()))
+		*keys = append(*keys, fileKey{path: path, line: line, col: col})
+
+		if err := collectJSONKeys(dec, data, path, keys); err != nil {
+			return err
+		}
+	}
+
+	// Consume the closing brace
+	_, err = dec.Token()
+	return err
+}
+
+// skipValue skips the rest of a value whose first token has been read
+func skipValue(dec *json.Decoder, tok json.Token) error {
+	if _, ok := tok.(json.Delim); !ok {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			if delim == '{' || delim == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+// keyStart returns the offset of the opening quote of the key ending at end
+func keyStart(data []byte, end int64) int64 {
+	i := end - 2 // skip the closing quote
+	for ; i > 0; i-- {
+		if data[i] == '"' && data[i-1] != '\\' {
+			break
+		}
+	}
+	return i
+}
+
+// position converts a byte offset into a 1-based line and column
+func position(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// yamlKeys lists the mapping keys of a YAML document
+func yamlKeys(filename string, data []byte) ([]fileKey, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: invalid YAML: %w", filename, err)
+	}
+
+	var keys []fileKey
+	if len(doc.Content) > 0 {
+		collectYAMLKeys(doc.Content[0], nil, &keys)
+	}
+	return keys, nil
+}
+
+// collectYAMLKeys records the keys of a YAML mapping, descending into nested mappings
+func collectYAMLKeys(node *yaml.Node, prefix []string, keys *[]fileKey) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := appendKey(prefix, key.Value)
+		*keys = append(*keys, fileKey{path: path, line: key.Line, col: key.Column})
+		collectYAMLKeys(value, path, keys)
+	}
+}
+
+// tomlKeys lists the keys of a TOML document, including each segment of
+// dotted keys and table headers
+func tomlKeys(filename string, data []byte) ([]fileKey, error) {
+	p := &unstable.Parser{}
+	p.Reset(data)
+
+	var keys []fileKey
+	var table []string
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = collectTOMLKey(p, expr.Key(), nil, &keys)
+		case unstable.KeyValue:
+			collectTOMLKeyValue(p, expr, table, &keys)
+		}
+	}
+
+	if err := p.Error(); err != nil {
+		var parserErr *unstable.ParserError
+		if errors.As(err, &parserErr) && len(parserErr.Highlight) > 0 {
+			shape := p.Shape(p.Range(parserErr.Highlight))
+			return nil, fmt.Errorf("%s:%d:%d: invalid TOML: %w", filename, shape.Start.Line, shape.Start.Column, err)
+		}
+		return nil, fmt.Errorf("%s: invalid TOML: %w", filename, err)
+	}
+	return keys, nil
+}
+
+// collectTOMLKeyValue records the key of a key/value pair and the keys of an inline table value
+func collectTOMLKeyValue(p *unstable.Parser, kv *unstable.Node, prefix []string, keys *[]fileKey) {
+	path := collectTOMLKey(p, kv.Key(), prefix, keys)
+
+	if value := kv.Value(); value.Kind == unstable.InlineTable {
+		children := value.Children()
+		for children.Next() {
+			if child := children.Node(); child.Kind == unstable.KeyValue {
+				collectTOMLKeyValue(p, child, path, keys)
+			}
+		}
+	}
+}
+
+// collectTOMLKey records every segment of a possibly dotted key and returns its full path
+func collectTOMLKey(p *unstable.Parser, it unstable.Iterator, prefix []string, keys *[]fileKey) []string {
+	path := prefix
+	for it.Next() {
+		part := it.Node()
+		path = appendKey(path, string(part.Data))
+		shape := p.Shape(part.Raw)
+		*keys = append(*keys, fileKey{path: path, line: shape.Start.Line, col: shape.Start.Column})
+	}
+	return path
+}
+
+// appendKey returns a new key path with name appended
+func appendKey(prefix []string, name string) []string {
+	path := make([]string, len(prefix), len(prefix)+1)
+	copy(path, prefix)
+	return append(path, name)
+}
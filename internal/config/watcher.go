@@ -0,0 +1,226 @@
+// Package config provides configuration management for the application
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"go.uber.org/zap"
+)
+
+// reloadDebounce coalesces the bursts of file events editors and
+// Kubernetes ConfigMap updates produce into a single reload
+const reloadDebounce = 100 * time.Millisecond
+
+// Reloader re-reads configuration from its source
+type Reloader interface {
+	Reload() (*Config, error)
+}
+
+// Watcher reloads configuration when a config file changes or the process
+// receives SIGHUP. Each reload is re-validated; valid configurations are
+// swapped in atomically and published to subscribers as typed events, invalid
+// ones are rejected and the previous configuration is kept.
+type Watcher struct {
+	source  Reloader
+	paths   []string
+	current atomic.Pointer[Config]
+
+	reloadMu sync.Mutex // serializes reloads so events are published in order
+
+	mu          sync.RWMutex
+	subscribers map[int]func(Event)
+	nextID      int
+}
+
+// NewWatcher creates a watcher for the configuration loaded by loader,
+// watching the config file and its overlays
+func NewWatcher(loader *ConfigLoader, initial *Config) *Watcher {
+	return NewWatcherWithSource(loader, loader.GetConfigFiles(), initial)
+}
+
+// NewWatcherWithSource creates a watcher that reloads from source and watches
+// paths for changes. With no paths file watching is disabled; SIGHUP and
+// Reload still work.
+func NewWatcherWithSource(source Reloader, paths []string, initial *Config) *Watcher {
+	w := &Watcher{
+		source:      source,
+		paths:       paths,
+		subscribers: make(map[int]func(Event)),
+	}
+	w.current.Store(initial)
+	return w
+}
+
+// Config returns the current configuration
+func (w *Watcher) Config() *Config {
+	return w.current.Load()
+}
+
+// Subscribe registers fn to receive change events and returns a function
+// that removes the subscription. Events are delivered synchronously, in
+// order, from the goroutine performing the reload.
+func (w *Watcher) Subscribe(fn func(Event)) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.subscribers[id] = fn
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subscribers, id)
+	}
+}
+
+// Reload re-reads and validates the configuration. On success the new
+// configuration is swapped in and change events are published; on failure
+// the previous configuration is kept and the error is returned.
+func (w *Watcher) Reload(ctx context.Context) error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	cfg, err := w.source.Reload()
+	if err != nil {
+		logger.Error(ctx, "Rejected configuration reload, keeping previous configuration", err,
+			zap.Strings("config_files", w.paths),
+		)
+		return fmt.Errorf("failed to reload configuration: %w", err)
+	}
+
+	events := w.swap(cfg)
+	logger.Info(ctx, "Configuration reloaded",
+		zap.Strings("config_files", w.paths),
+		zap.Int("changes", len(events)-1),
+	)
+	return nil
+}
+
+// Update applies fn to a copy of the current configuration and, if the result
+// is valid, swaps it in and publishes change events as a reload does. Updates
+// are runtime overrides that last until the next reload from the config
+// files. fn must replace slices and maps rather than modify them in place.
+func (w *Watcher) Update(ctx context.Context, fn func(cfg *Config)) error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	cfg := *w.current.Load()
+	fn(&cfg)
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("failed to update configuration: %w", err)
+	}
+
+	events := w.swap(&cfg)
+	logger.Info(ctx, "Configuration updated at runtime",
+		zap.Int("changes", len(events)-1),
+	)
+	return nil
+}
+
+// swap makes cfg current and publishes the change events to subscribers.
+// The caller must hold reloadMu.
+func (w *Watcher) swap(cfg *Config) []Event {
+	old := w.current.Swap(cfg)
+	events := Diff(old, cfg)
+
+	w.mu.RLock()
+	subscribers := make([]func(Event), 0, len(w.subscribers))
+	for _, fn := range w.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	w.mu.RUnlock()
+
+	for _, event := range events {
+		for _, fn := range subscribers {
+			fn(event)
+		}
+	}
+	return events
+}
+
+// Run watches the config files and SIGHUP, reloading on either, until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) error {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	var fileEvents <-chan fsnotify.Event
+	var fileErrors <-chan error
+	if len(w.paths) > 0 {
+		fsw, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("failed to create config file watcher: %w", err)
+		}
+		defer fsw.Close()
+
+		// Watch the directories rather than the files so that atomic saves,
+		// newly created overlays and Kubernetes ConfigMap symlink swaps are seen
+		for _, path := range w.paths {
+			if err := fsw.Add(filepath.Dir(path)); err != nil {
+				return fmt.Errorf("failed to watch config directory: %w", err)
+			}
+		}
+		fileEvents = fsw.Events
+		fileErrors = fsw.Errors
+	}
+
+	// Resolved targets of the watched files, to detect symlink swaps
+	realPaths := make(map[string]string, len(w.paths))
+	for _, path := range w.paths {
+		realPaths[filepath.Clean(path)], _ = filepath.EvalSymlinks(path)
+	}
+
+	// Debounce timer, stopped until the first file event
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	logger.Info(ctx, "Watching configuration for changes",
+		zap.Strings("config_files", w.paths),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-sighup:
+			logger.Info(ctx, "Received SIGHUP, reloading configuration")
+			_ = w.Reload(ctx)
+
+		case event := <-fileEvents:
+			// Reload when a watched file is written, created or replaced, or
+			// when the symlink one resolves through now points elsewhere
+			changed := false
+			if _, watched := realPaths[filepath.Clean(event.Name)]; watched {
+				changed = event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0
+			}
+			for path, realPath := range realPaths {
+				if current, _ := filepath.EvalSymlinks(path); current != realPath {
+					realPaths[path] = current
+					changed = true
+				}
+			}
+			if changed {
+				debounce.Reset(reloadDebounce)
+			}
+
+		case <-debounce.C:
+			_ = w.Reload(ctx)
+
+		case err := <-fileErrors:
+			logger.Error(ctx, "Configuration file watcher error", err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Package features provides feature flags evaluated per request
+package features
+
+import (
+	"sort"
+
+	"github.com/lumitut/lumi-go/internal/config"
+)
+
+// Flags defined in FeaturesConfig
+const (
+	// FlagNewAPI is the enableNewAPI configuration flag
+	FlagNewAPI = "enableNewAPI"
+	// FlagBetaFeatures is the enableBetaFeatures configuration flag, which
+	// turns on beta behaviour such as matching example filters against metadata
+	FlagBetaFeatures = "enableBetaFeatures"
+)
+
+// ConfigProvider serves the boolean flags of FeaturesConfig, such as
+// enableNewAPI, as flags that are on or off for everyone. It reads the
+// watcher's current configuration, so reloads and admin API changes apply.
+type ConfigProvider struct {
+	watcher *config.Watcher
+}
+
+// NewConfigProvider creates a provider for the feature flags in watcher's configuration
+func NewConfigProvider(watcher *config.Watcher) *ConfigProvider {
+	return &ConfigProvider{watcher: watcher}
+}
+
+// Flag returns a configuration flag
+func (p *ConfigProvider) Flag(name string) (Flag, bool) {
+	features := p.watcher.Config().Features
+	enabled, ok := features.Flags()[name]
+	if !ok {
+		return Flag{}, false
+	}
+	return Flag{Name: name, Enabled: *enabled}, true
+}
+
+// Flags returns every configuration flag, sorted by name
+func (p *ConfigProvider) Flags() []Flag {
+	features := p.watcher.Config().Features
+	var flags []Flag
+	for name, enabled := range features.Flags() {
+		flags = append(flags, Flag{Name: name, Enabled: *enabled})
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+	return flags
+}
@@ -0,0 +1,191 @@
+// Package features provides feature flags evaluated per request
+package features
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"google.golang.org/grpc/metadata"
+)
+
+// Flag defines a feature flag and who it is on for. A disabled flag is off
+// for everyone. An enabled flag is on for targeted users, tenants and
+// requests carrying a targeted header value, and for Percentage of the
+// remaining users (or tenants, for requests without a user).
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Enabled     bool   `json:"enabled"`
+
+	// Targeting
+	Users   []string            `json:"users,omitempty"`
+	Tenants []string            `json:"tenants,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"` // header name -> values
+
+	// Percentage rolls the flag out to a stable share of users, 0-100. Nil
+	// means everyone for a flag without targeting and no one else for a
+	// targeted flag. Requests with neither a user nor a tenant ID are only
+	// included at 100.
+	Percentage *float64 `json:"percentage,omitempty"`
+}
+
+// Validate checks the flag definition
+func (f Flag) Validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("flag name is required")
+	}
+	if f.Percentage != nil && (*f.Percentage < 0 || *f.Percentage > 100) {
+		return fmt.Errorf("invalid flag %s: percentage must be between 0 and 100", f.Name)
+	}
+	return nil
+}
+
+// Target identifies who a flag is evaluated for
+type Target struct {
+	UserID   string
+	TenantID string
+	Headers  http.Header
+}
+
+// TargetFromContext returns the target of a request from the user and tenant
+// IDs set by the correlation middleware or interceptor, and the incoming gRPC
+// metadata as headers
+func TargetFromContext(ctx context.Context) Target {
+	target := Target{
+		UserID:   contextString(ctx, logger.UserIDKey),
+		TenantID: contextString(ctx, logger.TenantIDKey),
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		target.Headers = make(http.Header, len(md))
+		for key, values := range md {
+			for _, value := range values {
+				target.Headers.Add(key, value)
+			}
+		}
+	}
+	return target
+}
+
+// contextString returns a string context value, or "" if unset
+func contextString(ctx context.Context, key logger.ContextKey) string {
+	value, _ := ctx.Value(key).(string)
+	return value
+}
+
+// Evaluate reports whether the flag is on for target
+func (f Flag) Evaluate(target Target) bool {
+	if !f.Enabled {
+		return false
+	}
+	if f.targets(target) {
+		return true
+	}
+	if f.Percentage == nil {
+		return len(f.Users) == 0 && len(f.Tenants) == 0 && len(f.Headers) == 0
+	}
+	return f.inRollout(target)
+}
+
+// targets reports whether target is explicitly targeted
+func (f Flag) targets(target Target) bool {
+	if target.UserID != "" && slices.Contains(f.Users, target.UserID) {
+		return true
+	}
+	if target.TenantID != "" && slices.Contains(f.Tenants, target.TenantID) {
+		return true
+	}
+	for name, values := range f.Headers {
+		if value := target.Headers.Get(name); value != "" && slices.Contains(values, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// inRollout reports whether target falls within the rollout percentage. The
+// same user always gets the same result for a flag, and raising the
+// percentage only adds users.
+func (f Flag) inRollout(target Target) bool {
+	percentage := *f.Percentage
+	if percentage >= 100 {
+		return true
+	}
+
+	key := target.UserID
+	if key == "" {
+		key = target.TenantID
+	}
+	if key == "" || percentage <= 0 {
+		return false
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(f.Name + ":" + key))
+	return float64(h.Sum32()%10000) < percentage*100
+}
+
+// Provider supplies flag definitions
+type Provider interface {
+	// Flag returns the definition of a flag, false if the provider does not define it
+	Flag(name string) (Flag, bool)
+	// Flags returns every flag the provider defines
+	Flags() []Flag
+}
+
+// Evaluator evaluates flags from a list of providers; the first provider
+// defining a flag wins. Unknown flags are off.
+type Evaluator struct {
+	providers []Provider
+}
+
+// NewEvaluator creates an evaluator over providers, in priority order
+func NewEvaluator(providers ...Provider) *Evaluator {
+	return &Evaluator{providers: providers}
+}
+
+// Enabled reports whether a flag is on for the request in ctx
+func (e *Evaluator) Enabled(ctx context.Context, name string) bool {
+	return e.EnabledFor(name, TargetFromContext(ctx))
+}
+
+// EnabledFor reports whether a flag is on for target, recording the result
+func (e *Evaluator) EnabledFor(name string, target Target) bool {
+	flag, ok := e.Flag(name)
+	enabled := ok && flag.Evaluate(target)
+	metrics.RecordFeatureFlagEvaluation(name, enabled)
+	return enabled
+}
+
+// Flag returns the definition of a flag from the first provider defining it
+func (e *Evaluator) Flag(name string) (Flag, bool) {
+	for _, p := range e.providers {
+		if flag, ok := p.Flag(name); ok {
+			return flag, true
+		}
+	}
+	return Flag{}, false
+}
+
+// Flags returns every defined flag, sorted by name
+func (e *Evaluator) Flags() []Flag {
+	seen := make(map[string]bool)
+	var flags []Flag
+	for _, p := range e.providers {
+		for _, flag := range p.Flags() {
+			if !seen[flag.Name] {
+				seen[flag.Name] = true
+				flags = append(flags, flag)
+			}
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+	return flags
+}
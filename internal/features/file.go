@@ -0,0 +1,136 @@
+// Package features provides feature flags evaluated per request
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"go.uber.org/zap"
+)
+
+// reloadDebounce coalesces bursts of file events into a single reload
+const reloadDebounce = 100 * time.Millisecond
+
+// fileFormat is the JSON format of a flag definitions file, keyed by flag name
+type fileFormat struct {
+	Flags map[string]Flag `json:"flags"`
+}
+
+// FileProvider serves flag definitions from a JSON file and reloads them
+// when the file changes
+type FileProvider struct {
+	path  string
+	flags atomic.Pointer[map[string]Flag]
+}
+
+// NewFileProvider loads the flag definitions in the file at path
+func NewFileProvider(path string) (*FileProvider, error) {
+	p := &FileProvider{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Flag returns the definition of a flag
+func (p *FileProvider) Flag(name string) (Flag, bool) {
+	flag, ok := (*p.flags.Load())[name]
+	return flag, ok
+}
+
+// Flags returns every flag in the file, sorted by name
+func (p *FileProvider) Flags() []Flag {
+	flags := make([]Flag, 0, len(*p.flags.Load()))
+	for _, flag := range *p.flags.Load() {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+	return flags
+}
+
+// Reload re-reads the file. Invalid definitions are rejected and the
+// previous ones kept.
+func (p *FileProvider) Reload() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read feature flags file: %w", err)
+	}
+
+	var file fileFormat
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse feature flags file: %w", err)
+	}
+
+	flags := make(map[string]Flag, len(file.Flags))
+	for name, flag := range file.Flags {
+		flag.Name = name
+		if err := flag.Validate(); err != nil {
+			return fmt.Errorf("failed to load feature flags file: %w", err)
+		}
+		flags[name] = flag
+	}
+
+	p.flags.Store(&flags)
+	return nil
+}
+
+// Run reloads the flag definitions when the file changes, until ctx is cancelled
+func (p *FileProvider) Run(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create feature flags file watcher: %w", err)
+	}
+	defer fsw.Close()
+
+	// Watch the directory so atomic saves and ConfigMap symlink swaps are seen
+	if err := fsw.Add(filepath.Dir(p.path)); err != nil {
+		return fmt.Errorf("failed to watch feature flags directory: %w", err)
+	}
+	realPath, _ := filepath.EvalSymlinks(p.path)
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event := <-fsw.Events:
+			changed := filepath.Clean(event.Name) == filepath.Clean(p.path)
+			if current, _ := filepath.EvalSymlinks(p.path); current != realPath {
+				realPath = current
+				changed = true
+			}
+			if changed {
+				debounce.Reset(reloadDebounce)
+			}
+
+		case <-debounce.C:
+			if err := p.Reload(); err != nil {
+				logger.Error(ctx, "Rejected feature flags reload, keeping previous flags", err,
+					zap.String("path", p.path),
+				)
+				continue
+			}
+			logger.Info(ctx, "Feature flags reloaded",
+				zap.String("path", p.path),
+				zap.Int("flags", len(*p.flags.Load())),
+			)
+
+		case err := <-fsw.Errors:
+			logger.Error(ctx, "Feature flags file watcher error", err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Package features provides feature flags evaluated per request
+package features
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+)
+
+// TargetFromGin returns the target of an HTTP request from the user and
+// tenant IDs set by the correlation middleware and the request headers
+func TargetFromGin(c *gin.Context) Target {
+	ctx := c.Request.Context()
+	return Target{
+		UserID:   contextString(ctx, logger.UserIDKey),
+		TenantID: contextString(ctx, logger.TenantIDKey),
+		Headers:  c.Request.Header,
+	}
+}
+
+// Require gates routes behind a flag, responding 404 Not Found to requests
+// the flag is off for, as if the routes did not exist
+func Require(e *Evaluator, name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !e.EnabledFor(name, TargetFromGin(c)) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "The requested resource was not found",
+			})
+			return
+		}
+		c.Next()
+	}
+}
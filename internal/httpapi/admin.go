@@ -0,0 +1,155 @@
+// Package httpapi provides HTTP server setup and routing
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"go.uber.org/zap"
+)
+
+// Admin listeners bind to loopback unless an address is configured, and bound
+// the time a client may take to send a request
+const (
+	defaultAdminBindAddress = "127.0.0.1"
+	adminReadHeaderTimeout  = 5 * time.Second
+	adminReadTimeout        = 30 * time.Second
+)
+
+// AdminServer serves operational endpoints on an internal listener, without
+// the public middleware chain (rate limiting, CORS, access logs)
+type AdminServer struct {
+	name       string
+	router     *gin.Engine
+	httpServer *http.Server
+	isReady    atomic.Bool
+
+	mu       sync.RWMutex
+	listener net.Listener
+}
+
+// AdminServers returns the admin listeners for metrics on the metrics port
+// and pprof on the pprof port, sharing one listener when the ports match.
+// The probes are also served on the metrics listener when AdminHealth is set,
+// and api, if not nil, when an admin token is configured.
+func (s *Server) AdminServers(api *AdminAPI) []*AdminServer {
+	cfg := s.config
+	bindAddress := cfg.Server.AdminBindAddress
+	if bindAddress == "" {
+		bindAddress = defaultAdminBindAddress
+	}
+
+	var servers []*AdminServer
+	byPort := make(map[string]*AdminServer)
+	routerFor := func(port string) *gin.Engine {
+		if admin, ok := byPort[port]; ok {
+			return admin.router
+		}
+		admin := newAdminServer("admin:"+port, net.JoinHostPort(bindAddress, port))
+		byPort[port] = admin
+		servers = append(servers, admin)
+		return admin.router
+	}
+
+	if port := cfg.Observability.MetricsPort; port != "" {
+		if cfg.Observability.MetricsEnabled {
+			routerFor(port).GET(metricsPath(cfg), gin.WrapH(metrics.Handler()))
+		}
+		if cfg.Server.AdminHealth {
+			registerProbeRoutes(routerFor(port), s)
+		}
+		if api != nil && cfg.Server.AdminToken != "" {
+			api.RegisterRoutes(routerFor(port))
+		}
+	}
+
+	if port := cfg.Server.PProfPort; port != "" && pprofEnabled(cfg) {
+		registerPprofRoutes(routerFor(port))
+	}
+
+	return servers
+}
+
+// newAdminServer creates an admin server listening on addr
+func newAdminServer(name, addr string) *AdminServer {
+	router := gin.New()
+	router.Use(middleware.Recovery())
+
+	return &AdminServer{
+		name:   name,
+		router: router,
+		httpServer: &http.Server{
+			Addr:              addr,
+			Handler:           router,
+			ReadHeaderTimeout: adminReadHeaderTimeout,
+			ReadTimeout:       adminReadTimeout,
+		},
+	}
+}
+
+// Name identifies the admin server as an application component
+func (a *AdminServer) Name() string {
+	return a.name
+}
+
+// Start listens on the admin address and serves until shutdown
+func (a *AdminServer) Start(ctx context.Context) error {
+	lis, err := net.Listen("tcp", a.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to start admin server %s: %w", a.name, err)
+	}
+
+	logger.Info(ctx, "Starting admin server",
+		zap.String("name", a.name),
+		zap.String("address", lis.Addr().String()),
+	)
+
+	a.mu.Lock()
+	a.listener = lis
+	a.mu.Unlock()
+	a.isReady.Store(true)
+
+	if err := a.httpServer.Serve(lis); err != nil && err != http.ErrServerClosed {
+		a.isReady.Store(false)
+		return fmt.Errorf("failed to start admin server %s: %w", a.name, err)
+	}
+	return nil
+}
+
+// Stop gracefully shuts down the admin server
+func (a *AdminServer) Stop(ctx context.Context) error {
+	a.isReady.Store(false)
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown admin server %s: %w", a.name, err)
+	}
+	return nil
+}
+
+// Ready reports whether the admin server is listening
+func (a *AdminServer) Ready() bool {
+	return a.isReady.Load()
+}
+
+// Addr returns the address the admin server is listening on, or nil before Start
+func (a *AdminServer) Addr() net.Addr {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.listener == nil {
+		return nil
+	}
+	return a.listener.Addr()
+}
+
+// Router returns the Gin router of the admin server
+func (a *AdminServer) Router() *gin.Engine {
+	return a.router
+}
@@ -0,0 +1,243 @@
+// Package httpapi provides HTTP server setup and routing
+package httpapi
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"go.uber.org/zap"
+)
+
+// AdminAPI serves runtime operations under /admin on the admin listener:
+// log level, feature flags, rate limit buckets and configuration. Every
+// request must carry the admin token as a bearer token; changes are audited.
+type AdminAPI struct {
+	token    string
+	watcher  *config.Watcher
+	limiters map[string]middleware.InspectableRateLimiter
+}
+
+// NewAdminAPI creates an admin API authenticated by token that reads and
+// updates configuration through watcher
+func NewAdminAPI(token string, watcher *config.Watcher) *AdminAPI {
+	return &AdminAPI{
+		token:    token,
+		watcher:  watcher,
+		limiters: make(map[string]middleware.InspectableRateLimiter),
+	}
+}
+
+// AddRateLimiter exposes a rate limiter's buckets under name; register the
+// limiter serving requests so resets reach Redis and every policy. A nil
+// limiter, i.e. a disabled middleware, is ignored.
+func (a *AdminAPI) AddRateLimiter(name string, limiter middleware.InspectableRateLimiter) {
+	if limiter != nil {
+		a.limiters[name] = limiter
+	}
+}
+
+// RegisterRoutes registers the admin endpoints on router
+func (a *AdminAPI) RegisterRoutes(router gin.IRouter) {
+	admin := router.Group("/admin", a.authenticate)
+	{
+		admin.GET("/log-level", a.handleGetLogLevel)
+		admin.PUT("/log-level", a.handleSetLogLevel)
+
+		admin.GET("/features", a.handleGetFeatures)
+		admin.PUT("/features/:name", a.handleSetFeature)
+
+		admin.GET("/ratelimits", a.handleGetRateLimits)
+		admin.GET("/ratelimits/:limiter/:key", a.handleGetBucket)
+		admin.DELETE("/ratelimits/:limiter/:key", a.handleResetBucket)
+
+		admin.GET("/config", a.handleGetConfig)
+		admin.POST("/config/reload", a.handleReloadConfig)
+	}
+}
+
+// authenticate rejects requests without the admin bearer token
+func (a *AdminAPI) authenticate(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || a.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+		logger.Audit(c.Request.Context(), "authenticate", c.Request.URL.Path, "denied",
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "A valid admin bearer token is required",
+		})
+		return
+	}
+	c.Next()
+}
+
+// handleGetLogLevel returns the current log level
+func (a *AdminAPI) handleGetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": logger.GetLevel()})
+}
+
+// handleSetLogLevel changes the log level until the next restart or reload
+func (a *AdminAPI) handleSetLogLevel(c *gin.Context) {
+	var req struct {
+		Level string `json:"level" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	old := logger.GetLevel()
+	if err := logger.SetLevel(req.Level); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	logger.Audit(c.Request.Context(), "set_log_level", "log_level", "success",
+		zap.String("old", old),
+		zap.String("new", logger.GetLevel()),
+	)
+	c.JSON(http.StatusOK, gin.H{"level": logger.GetLevel()})
+}
+
+// handleGetFeatures returns the feature flags
+func (a *AdminAPI) handleGetFeatures(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"features": a.features()})
+}
+
+// handleSetFeature turns a feature flag on or off as a runtime override
+func (a *AdminAPI) handleSetFeature(c *gin.Context) {
+	var req struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	name := c.Param("name")
+	features := a.watcher.Config().Features
+	if _, ok := features.Flags()[name]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Unknown feature flag " + name,
+		})
+		return
+	}
+
+	err := a.watcher.Update(c.Request.Context(), func(cfg *config.Config) {
+		*cfg.Features.Flags()[name] = *req.Enabled
+	})
+	if err != nil {
+		badRequest(c, err)
+		return
+	}
+
+	logger.Audit(c.Request.Context(), "set_feature", "feature:"+name, "success",
+		zap.Bool("enabled", *req.Enabled),
+	)
+	c.JSON(http.StatusOK, gin.H{"features": a.features()})
+}
+
+// features returns the current feature flag values
+func (a *AdminAPI) features() map[string]bool {
+	features := a.watcher.Config().Features
+	values := make(map[string]bool)
+	for name, enabled := range features.Flags() {
+		values[name] = *enabled
+	}
+	return values
+}
+
+// handleGetRateLimits returns the buckets of every rate limiter
+func (a *AdminAPI) handleGetRateLimits(c *gin.Context) {
+	limiters := make(map[string][]middleware.BucketState, len(a.limiters))
+	for name, limiter := range a.limiters {
+		limiters[name] = limiter.Snapshot()
+	}
+	c.JSON(http.StatusOK, gin.H{"limiters": limiters})
+}
+
+// handleGetBucket returns the rate limit buckets of one key, one per policy
+func (a *AdminAPI) handleGetBucket(c *gin.Context) {
+	limiter, ok := a.limiter(c)
+	if !ok {
+		return
+	}
+
+	key := c.Param("key")
+	buckets := []middleware.BucketState{}
+	for _, bucket := range limiter.Snapshot() {
+		if bucket.Key == key {
+			buckets = append(buckets, bucket)
+		}
+	}
+	if len(buckets) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "No rate limit bucket for key " + key,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"key": key, "buckets": buckets})
+}
+
+// handleResetBucket resets a client's rate limit
+func (a *AdminAPI) handleResetBucket(c *gin.Context) {
+	limiter, ok := a.limiter(c)
+	if !ok {
+		return
+	}
+
+	limiter.Reset(c.Param("key"))
+	logger.Audit(c.Request.Context(), "reset_rate_limit", "ratelimit:"+c.Param("limiter"), "success",
+		zap.String("key", c.Param("key")),
+	)
+	c.Status(http.StatusNoContent)
+}
+
+// limiter returns the rate limiter named in the path, responding 404 if
+// there is none
+func (a *AdminAPI) limiter(c *gin.Context) (middleware.InspectableRateLimiter, bool) {
+	limiter, ok := a.limiters[c.Param("limiter")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Unknown or disabled rate limiter " + c.Param("limiter"),
+		})
+	}
+	return limiter, ok
+}
+
+// handleGetConfig returns the effective configuration with secrets redacted
+func (a *AdminAPI) handleGetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, a.watcher.Config().Redacted())
+}
+
+// handleReloadConfig reloads the configuration from its files, as SIGHUP does
+func (a *AdminAPI) handleReloadConfig(c *gin.Context) {
+	if err := a.watcher.Reload(c.Request.Context()); err != nil {
+		logger.Audit(c.Request.Context(), "reload_config", "config", "failure")
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "reload_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	logger.Audit(c.Request.Context(), "reload_config", "config", "success")
+	c.JSON(http.StatusOK, gin.H{"status": "reloaded"})
+}
+
+// badRequest responds 400 with err as the message
+func badRequest(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "bad_request",
+		"message": err.Error(),
+	})
+}
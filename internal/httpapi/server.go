@@ -4,15 +4,21 @@ package httpapi
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/pprof"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/features"
 	"github.com/lumitut/lumi-go/internal/middleware"
 	"github.com/lumitut/lumi-go/internal/observability/logger"
 	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"github.com/lumitut/lumi-go/internal/rpcapi"
+	"github.com/lumitut/lumi-go/internal/service"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
@@ -21,38 +27,88 @@ type Server struct {
 	config     *config.Config
 	router     *gin.Engine
 	httpServer *http.Server
-	isReady    bool
+	health     *service.HealthService
+	isReady    atomic.Bool
+
+	// Connection draining: requests being served, and the context every
+	// request derives from, cancelled to close long-lived connections
+	inFlight       atomic.Int64
+	connCtx        context.Context
+	closeLongLived context.CancelFunc
+
+	// Runtime-reloadable middleware state, nil when the middleware is disabled
+	rateLimiters *middleware.RateLimiters
+	corsOrigins  *middleware.OriginList
+
+	// Keeps the rate limit counters shared by every replica, nil unless the
+	// store is Redis; owned by the caller
+	redis *redis.Client
+
+	// Maintenance switch for the API routes, toggled by feature flag changes
+	maintenance *middleware.MaintenanceMode
+}
+
+// NewServer creates a new HTTP server. The ExampleService backs the REST
+// routes served through the gRPC gateway and the HealthService the readiness
+// endpoints; both are shared with the RPC server. Rate limits are kept in
+// memory and beta features are off; use NewServerWithOptions to change that.
+func NewServer(cfg *config.Config, examples *service.ExampleService, health *service.HealthService) *Server {
+	return NewServerWithOptions(cfg, examples, health, Options{})
+}
+
+// Options are the optional dependencies of a server
+type Options struct {
+	// Redis keeps the rate limit counters shared by every replica when not
+	// nil, as returned by middleware.NewRateLimitRedis; the caller closes it
+	Redis *redis.Client
+	// Features turns beta features on per request when not nil
+	Features *features.Evaluator
 }
 
-// NewServer creates a new HTTP server
-func NewServer(cfg *config.Config) *Server {
+// NewServerWithOptions creates a new HTTP server with the given dependencies
+func NewServerWithOptions(cfg *config.Config, examples *service.ExampleService, health *service.HealthService, opts Options) *Server {
 	// Set Gin mode based on environment
 	if cfg.Service.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	s := &Server{
+		config: cfg,
+		health: health,
+		redis:  opts.Redis,
+		maintenance: middleware.NewMaintenanceMode(middleware.MaintenanceConfig{
+			RetryAfter:   cfg.Middleware.MaintenanceRetryAfter,
+			AllowIPs:     cfg.Middleware.MaintenanceAllowIPs,
+			AllowAPIKeys: cfg.Middleware.MaintenanceAllowAPIKeys,
+		}, cfg.Features.MaintenanceMode),
+	}
+
+	// Startup completes once the server is listening
+	health.Lifecycle().Register("http")
+
 	// Create router
-	router := setupRouter(cfg)
+	s.router = s.setupRouter(examples, opts.Features)
 
 	// Create HTTP server
-	httpServer := &http.Server{
+	s.connCtx, s.closeLongLived = context.WithCancel(context.Background())
+	s.httpServer = &http.Server{
 		Addr:         ":" + cfg.Server.HTTPPort,
-		Handler:      router,
+		Handler:      s.trackInFlight(s.router),
 		ReadTimeout:  cfg.Server.HTTPReadTimeout,
 		WriteTimeout: cfg.Server.HTTPWriteTimeout,
 		IdleTimeout:  cfg.Server.HTTPIdleTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return s.connCtx
+		},
 	}
 
-	return &Server{
-		config:     cfg,
-		router:     router,
-		httpServer: httpServer,
-		isReady:    false,
-	}
+	return s
 }
 
 // setupRouter configures the Gin router with all middleware and routes
-func setupRouter(cfg *config.Config) *gin.Engine {
+func (s *Server) setupRouter(examples *service.ExampleService, flags *features.Evaluator) *gin.Engine {
+	cfg := s.config
+
 	// Create router without default middleware
 	router := gin.New()
 
@@ -107,18 +163,15 @@ func setupRouter(cfg *config.Config) *gin.Engine {
 		}))
 	}
 
-	// 7. Rate limiting
+	// 7. Rate limiting: the global limit, policies and API key plans
 	if cfg.Middleware.RateLimitEnabled {
-		var rateLimitMiddleware gin.HandlerFunc
-		switch cfg.Middleware.RateLimitType {
-		case "user":
-			rateLimitMiddleware = middleware.UserRateLimit(cfg.Middleware.RateLimitRate)
-		case "api_key":
-			rateLimitMiddleware = middleware.APIKeyRateLimit(cfg.Middleware.RateLimitRate)
-		default: // "ip"
-			rateLimitMiddleware = middleware.IPRateLimit(cfg.Middleware.RateLimitRate)
-		}
-		router.Use(rateLimitMiddleware)
+		rateLimitConfig := middleware.RateLimitConfigForType(cfg.Middleware.RateLimitType, cfg.Middleware.RateLimitRate)
+		rateLimitConfig.Burst = cfg.Middleware.RateLimitBurst
+		rateLimitConfig.Headers = cfg.Middleware.RateLimitHeaders
+		s.rateLimiters = middleware.NewRateLimiters(cfg.Middleware, s.redis, cfg.Service.Name+":ratelimit:http:")
+		rateLimitConfig.Limiter = s.rateLimiters.Limiter()
+		rateLimitConfig.Policies = s.rateLimiters.Policies()
+		router.Use(middleware.RateLimit(rateLimitConfig))
 	}
 
 	// 8. CORS (if enabled)
@@ -139,58 +192,45 @@ func setupRouter(cfg *config.Config) *gin.Engine {
 			corsConfig = middleware.DevelopmentCORSConfig()
 		}
 
+		s.corsOrigins = middleware.NewOriginList(corsConfig.AllowOrigins)
+		corsConfig.AllowOriginList = s.corsOrigins
+
 		router.Use(middleware.CORS(corsConfig))
 	}
 
+	// 9. Response compression and request decompression (if enabled)
+	if cfg.Middleware.CompressionEnabled {
+		compressionConfig := middleware.DefaultCompressionConfig()
+		compressionConfig.MinSize = cfg.Middleware.CompressionMinSize
+		compressionConfig.ContentTypes = cfg.Middleware.CompressionContentTypes
+		compressionConfig.MaxDecompressedSize = cfg.Middleware.DecompressionMaxSize
+		// Metrics are compressed by the Prometheus handler; profiles already are
+		compressionConfig.SkipPaths = []string{metricsPath(cfg), "/debug/pprof/"}
+		router.Use(middleware.CompressionWithConfig(compressionConfig))
+	}
+
 	// Register routes
-	registerOpsRoutes(router, cfg)
-	registerAPIRoutes(router, cfg)
+	registerOpsRoutes(router, cfg, s)
+	registerAPIRoutes(router, examples, flags,
+		// API routes only: maintenance mode, then request timeouts
+		middleware.Maintenance(s.maintenance),
+		middleware.Timeout(timeoutConfig(cfg)),
+	)
 
 	return router
 }
 
 // registerOpsRoutes registers operational endpoints
-func registerOpsRoutes(router *gin.Engine, cfg *config.Config) {
-	// Health check - always returns 200 if service is running
-	router.GET("/healthz", handleHealth)
-	router.GET("/health", handleHealth)
-
-	// Readiness check - returns 200 when service is ready to handle requests
-	router.GET("/readyz", func(c *gin.Context) {
-		// Access the server instance through context if needed
-		// For now, just check basic readiness
-		c.JSON(http.StatusOK, gin.H{
-			"status": "ready",
-			"time":   time.Now().Unix(),
-		})
-	})
-	router.GET("/ready", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "ready",
-			"time":   time.Now().Unix(),
-		})
-	})
-
-	// Metrics endpoint
-	if cfg.Observability.MetricsEnabled {
-		router.GET("/metrics", gin.WrapH(metrics.Handler()))
-	}
+func registerOpsRoutes(router *gin.Engine, cfg *config.Config, s *Server) {
+	registerProbeRoutes(router, s)
 
-	// pprof endpoints (only in non-production or if explicitly enabled)
-	if cfg.Service.Environment != "production" || cfg.Server.EnablePProf {
-		pprofGroup := router.Group("/debug/pprof")
-		{
-			pprofGroup.GET("/", gin.WrapF(pprof.Index))
-			pprofGroup.GET("/cmdline", gin.WrapF(pprof.Cmdline))
-			pprofGroup.GET("/profile", gin.WrapF(pprof.Profile))
-			pprofGroup.GET("/symbol", gin.WrapF(pprof.Symbol))
-			pprofGroup.GET("/trace", gin.WrapF(pprof.Trace))
-			pprofGroup.GET("/allocs", gin.WrapH(pprof.Handler("allocs")))
-			pprofGroup.GET("/block", gin.WrapH(pprof.Handler("block")))
-			pprofGroup.GET("/goroutine", gin.WrapH(pprof.Handler("goroutine")))
-			pprofGroup.GET("/heap", gin.WrapH(pprof.Handler("heap")))
-			pprofGroup.GET("/mutex", gin.WrapH(pprof.Handler("mutex")))
-			pprofGroup.GET("/threadcreate", gin.WrapH(pprof.Handler("threadcreate")))
+	// Metrics and pprof, unless only served on the admin listeners
+	if !cfg.Server.AdminOnly {
+		if cfg.Observability.MetricsEnabled {
+			router.GET(metricsPath(cfg), gin.WrapH(metrics.Handler()))
+		}
+		if pprofEnabled(cfg) {
+			registerPprofRoutes(router)
 		}
 	}
 
@@ -204,66 +244,231 @@ func registerOpsRoutes(router *gin.Engine, cfg *config.Config) {
 	})
 }
 
-// registerAPIRoutes registers application API routes
-func registerAPIRoutes(router *gin.Engine, cfg *config.Config) {
-	// API v1 routes
-	v1 := router.Group("/api/v1")
+// registerProbeRoutes registers the liveness, startup and readiness probes
+func registerProbeRoutes(router gin.IRoutes, s *Server) {
+	// Liveness check - returns 200 unless an event loop is wedged
+	router.GET("/healthz", s.handleHealth)
+	router.GET("/health", s.handleHealth)
+
+	// Startup check - returns 200 once every component is initialized
+	router.GET("/startupz", s.handleStartup)
+
+	// Readiness check - returns 200 when service is ready to handle requests, 503 otherwise
+	router.GET("/readyz", s.handleReadiness)
+	router.GET("/ready", s.handleReadiness)
+}
+
+// registerPprofRoutes registers the pprof endpoints under /debug/pprof
+func registerPprofRoutes(router gin.IRoutes) {
+	router.GET("/debug/pprof/", gin.WrapF(pprof.Index))
+	router.GET("/debug/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	router.GET("/debug/pprof/profile", gin.WrapF(pprof.Profile))
+	router.GET("/debug/pprof/symbol", gin.WrapF(pprof.Symbol))
+	router.GET("/debug/pprof/trace", gin.WrapF(pprof.Trace))
+	router.GET("/debug/pprof/allocs", gin.WrapH(pprof.Handler("allocs")))
+	router.GET("/debug/pprof/block", gin.WrapH(pprof.Handler("block")))
+	router.GET("/debug/pprof/goroutine", gin.WrapH(pprof.Handler("goroutine")))
+	router.GET("/debug/pprof/heap", gin.WrapH(pprof.Handler("heap")))
+	router.GET("/debug/pprof/mutex", gin.WrapH(pprof.Handler("mutex")))
+	router.GET("/debug/pprof/threadcreate", gin.WrapH(pprof.Handler("threadcreate")))
+}
+
+// timeoutConfig maps the request timeout settings onto the timeout middleware
+func timeoutConfig(cfg *config.Config) middleware.TimeoutConfig {
+	// Routes are checked by Validate
+	routes, _ := cfg.Middleware.RequestTimeoutRouteMap()
+	return middleware.TimeoutConfig{
+		Default: cfg.Middleware.RequestTimeout,
+		Routes:  routes,
+	}
+}
+
+// pprofEnabled reports whether pprof is served: outside production, or in
+// production only when explicitly enabled
+func pprofEnabled(cfg *config.Config) bool {
+	return cfg.Service.Environment != "production" || cfg.Server.EnablePProf
+}
+
+// metricsPath returns the configured metrics path, defaulting to /metrics
+func metricsPath(cfg *config.Config) string {
+	if cfg.Observability.MetricsPath == "" {
+		return "/metrics"
+	}
+	return cfg.Observability.MetricsPath
+}
+
+// registerAPIRoutes registers application API routes behind the API-only middleware
+func registerAPIRoutes(router *gin.Engine, examples *service.ExampleService, flags *features.Evaluator, handlers ...gin.HandlerFunc) {
+	// API v1 routes are generated from the google.api.http annotations in
+	// api/proto/service.proto and served by the gRPC gateway
+	v1 := router.Group("/api/v1", handlers...)
 	{
-		// Example user routes
-		users := v1.Group("/users")
-		{
-			users.GET("/:id", handleGetUser)
-			users.POST("", handleCreateUser)
-			users.PUT("/:id", handleUpdateUser)
-			users.DELETE("/:id", handleDeleteUser)
-			users.GET("", handleListUsers)
-		}
+		v1.Any("/*path", gin.WrapH(rpcapi.NewGateway(examples, flags)))
+	}
+}
 
-		// Add more API groups here
+// OnConfigChange applies a configuration change that takes effect without a
+// restart. Enabling or disabling a middleware still requires a restart.
+func (s *Server) OnConfigChange(event config.Event) {
+	switch e := event.(type) {
+	case config.RateLimitChanged:
+		if s.rateLimiters != nil {
+			s.rateLimiters.SetLimits(e.Rate, e.Burst)
+		}
+	case config.CORSOriginsChanged:
+		if s.corsOrigins != nil {
+			origins := e.Origins
+			if s.config.Service.Environment == "development" && len(origins) == 0 {
+				origins = middleware.DevelopmentCORSConfig().AllowOrigins
+			}
+			s.corsOrigins.Set(origins)
+		}
+	case config.FeaturesChanged:
+		s.maintenance.SetEnabled(e.New.MaintenanceMode)
 	}
 }
 
 // Start starts the HTTP server
 func (s *Server) Start(ctx context.Context) error {
+	lis, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to start HTTP server: %w", err)
+	}
+
+	return s.Serve(ctx, lis)
+}
+
+// Serve serves HTTP requests on the given listener until shutdown
+func (s *Server) Serve(ctx context.Context, lis net.Listener) error {
 	logger.Info(ctx, "Starting HTTP server",
-		zap.String("address", s.httpServer.Addr),
+		zap.String("address", lis.Addr().String()),
 		zap.String("environment", s.config.Service.Environment),
 	)
 
-	// Mark server as ready after a brief initialization
-	go func() {
-		time.Sleep(100 * time.Millisecond)
-		s.setReady(true)
-		logger.Info(ctx, "HTTP server ready to accept requests")
-	}()
+	// The listener accepts connections from here on
+	s.setReady(true)
+	s.health.Lifecycle().MarkInitialized("http")
+	logger.Info(ctx, "HTTP server ready to accept requests")
 
-	// Start server
-	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := s.httpServer.Serve(lis); err != nil && err != http.ErrServerClosed {
+		s.setReady(false)
 		return fmt.Errorf("failed to start HTTP server: %w", err)
 	}
 
 	return nil
 }
 
-// Shutdown gracefully shuts down the HTTP server
+// Shutdown gracefully shuts down the HTTP server:
+//  1. readiness fails and the server keeps serving for the pre-stop delay,
+//     so load balancers stop routing to it before its listener closes
+//  2. the listener closes and in-flight requests get the drain timeout to finish
+//  3. requests still running after that, such as streams, SSE and WebSockets,
+//     have their context cancelled to tell them to close
+//  4. connections still open when ctx expires are closed forcibly
 func (s *Server) Shutdown(ctx context.Context) error {
 	logger.Info(ctx, "Shutting down HTTP server")
 
 	// Mark as not ready
+	wasReady := s.IsReady()
 	s.setReady(false)
 
-	// Wait a bit for load balancers to detect
-	time.Sleep(5 * time.Second)
+	if delay := s.config.Server.PreStopDelay; wasReady && delay > 0 {
+		logger.Info(ctx, "Waiting for load balancers to stop routing requests",
+			zap.Duration("pre_stop_delay", delay),
+		)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	start := time.Now()
+	inFlight := s.inFlight.Load()
 
-	// Shutdown server
-	if err := s.httpServer.Shutdown(ctx); err != nil {
-		return fmt.Errorf("failed to shutdown HTTP server: %w", err)
+	drainCtx := ctx
+	if timeout := s.config.Server.DrainTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		drainCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var cut int64
+	err := s.httpServer.Shutdown(drainCtx)
+	if err != nil {
+		cut = s.inFlight.Load()
+		logger.Warn(ctx, "Drain timeout exceeded, closing long-lived connections",
+			zap.Int64("requests", cut),
+		)
+		s.closeLongLived()
+
+		if err = s.httpServer.Shutdown(ctx); err != nil {
+			logger.Warn(ctx, "Shutdown timeout exceeded, forcing connections closed",
+				zap.Int64("requests", s.inFlight.Load()),
+			)
+			_ = s.httpServer.Close()
+		}
 	}
+	s.closeLongLived()
+
+	_ = s.Close()
 
-	logger.Info(ctx, "HTTP server shutdown complete")
+	drained := max(inFlight-cut, 0)
+	metrics.RecordShutdownRequests(drained, cut)
+	logger.Info(ctx, "HTTP server shutdown complete",
+		zap.Int64("drained", drained),
+		zap.Int64("cut", cut),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to shutdown HTTP server: %w", err)
+	}
 	return nil
 }
 
+// trackInFlight counts the requests being served, so shutdown can wait for
+// them and report how many it drained
+func (s *Server) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Name identifies the server as an application component
+func (s *Server) Name() string {
+	return "http"
+}
+
+// Stop gracefully shuts down the HTTP server as an application component
+func (s *Server) Stop(ctx context.Context) error {
+	return s.Shutdown(ctx)
+}
+
+// Close releases the server's rate limiters. Shutdown calls it; close a
+// server that is never started directly. It is safe to call more than once.
+func (s *Server) Close() error {
+	if s.rateLimiters == nil {
+		return nil
+	}
+	return s.rateLimiters.Close()
+}
+
+// Ready reports whether the server accepts requests
+func (s *Server) Ready() bool {
+	return s.IsReady()
+}
+
+// RateLimiter returns the limiters serving requests, or nil when rate
+// limiting is disabled
+func (s *Server) RateLimiter() middleware.InspectableRateLimiter {
+	if s.rateLimiters == nil {
+		return nil
+	}
+	return s.rateLimiters
+}
+
 // Router returns the Gin router
 func (s *Server) Router() *gin.Engine {
 	return s.router
@@ -271,110 +476,99 @@ func (s *Server) Router() *gin.Engine {
 
 // setReady sets the readiness state
 func (s *Server) setReady(ready bool) {
-	s.isReady = ready
+	s.isReady.Store(ready)
 }
 
 // IsReady returns the readiness state
 func (s *Server) IsReady() bool {
-	return s.isReady
-}
-
-// Health check handler
-func handleHealth(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "healthy",
-		"time":   time.Now().Unix(),
-	})
+	return s.isReady.Load()
 }
 
-// Example API handlers (to be moved to separate handlers package)
-func handleGetUser(c *gin.Context) {
-	ctx := c.Request.Context()
-	userID := c.Param("id")
-
-	logger.Info(ctx, "Fetching user",
-		zap.String("user_id", userID),
-	)
-
-	// Example response
-	c.JSON(http.StatusOK, gin.H{
-		"id":         userID,
-		"username":   "john_doe",
-		"email":      "john@example.com",
-		"created_at": time.Now().Unix(),
-	})
+// readinessResponse is the body of the readiness endpoints
+type readinessResponse struct {
+	Status string `json:"status"`
+	Time   int64  `json:"time"`
+	*service.ReadinessStatus
 }
 
-func handleCreateUser(c *gin.Context) {
-	ctx := c.Request.Context()
-
-	var user struct {
-		Username string `json:"username" binding:"required"`
-		Email    string `json:"email" binding:"required,email"`
+// handleReadiness serves the readiness status, with 503 until the server
+// accepts requests and every critical check passes
+func (s *Server) handleReadiness(c *gin.Context) {
+	readiness, err := s.health.GetReadiness(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "not_ready",
+			"time":   time.Now().Unix(),
+			"error":  err.Error(),
+		})
+		return
 	}
 
-	if err := c.ShouldBindJSON(&user); err != nil {
-		logger.Warn(ctx, "Invalid user data",
-			zap.Error(err),
-		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request"})
-		return
+	if !s.IsReady() {
+		readiness.Ready = false
+		readiness.Checks["http"] = service.Check{
+			Status:   "not_ready",
+			Message:  "HTTP server is not accepting requests",
+			Critical: true,
+		}
 	}
 
-	logger.Info(ctx, "Creating user",
-		zap.String("username", user.Username),
-	)
+	status, code := "ready", http.StatusOK
+	if !readiness.Ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"id":       "user_123",
-		"username": user.Username,
-		"email":    user.Email,
+	c.JSON(code, readinessResponse{
+		Status:          status,
+		Time:            readiness.Timestamp,
+		ReadinessStatus: readiness,
 	})
 }
 
-func handleUpdateUser(c *gin.Context) {
-	ctx := c.Request.Context()
-	userID := c.Param("id")
+// handleHealth serves the liveness status, with 503 while an event loop is wedged
+func (s *Server) handleHealth(c *gin.Context) {
+	health, err := s.health.GetHealth(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "unhealthy",
+			"time":   time.Now().Unix(),
+			"error":  err.Error(),
+		})
+		return
+	}
 
-	logger.Info(ctx, "Updating user",
-		zap.String("user_id", userID),
-	)
+	code := http.StatusOK
+	if health.Status != "healthy" {
+		code = http.StatusServiceUnavailable
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":         userID,
-		"updated_at": time.Now().Unix(),
+	c.JSON(code, gin.H{
+		"status":  health.Status,
+		"time":    health.Timestamp,
+		"details": health.Details,
 	})
 }
 
-func handleDeleteUser(c *gin.Context) {
-	ctx := c.Request.Context()
-	userID := c.Param("id")
-
-	logger.Info(ctx, "Deleting user",
-		zap.String("user_id", userID),
-	)
+// handleStartup serves the startup status, with 503 until every component is initialized
+func (s *Server) handleStartup(c *gin.Context) {
+	startup, err := s.health.GetStartup(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "starting",
+			"time":   time.Now().Unix(),
+			"error":  err.Error(),
+		})
+		return
+	}
 
-	c.Status(http.StatusNoContent)
-}
+	code := http.StatusOK
+	if !startup.Started {
+		code = http.StatusServiceUnavailable
+	}
 
-func handleListUsers(c *gin.Context) {
-	ctx := c.Request.Context()
-
-	logger.Info(ctx, "Listing users")
-
-	c.JSON(http.StatusOK, gin.H{
-		"users": []gin.H{
-			{
-				"id":       "user_1",
-				"username": "john_doe",
-				"email":    "john@example.com",
-			},
-			{
-				"id":       "user_2",
-				"username": "jane_doe",
-				"email":    "jane@example.com",
-			},
-		},
-		"total": 2,
+	c.JSON(code, gin.H{
+		"status":     startup.State,
+		"time":       startup.Timestamp,
+		"components": startup.Components,
 	})
 }
@@ -0,0 +1,409 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings supported for responses and request bodies
+const (
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+	EncodingGzip   = "gzip"
+)
+
+// CompressionConfig provides configuration for the compression middleware
+type CompressionConfig struct {
+	// Encodings lists the response encodings in order of preference
+	Encodings []string
+	// MinSize is the smallest response body, in bytes, that is compressed
+	MinSize int
+	// ContentTypes lists the compressible media types; entries ending in "/"
+	// match a whole type, e.g. "text/"
+	ContentTypes []string
+	// SkipPaths lists path prefixes whose responses are never compressed
+	SkipPaths []string
+	// MaxDecompressedSize limits compressed request bodies once decompressed;
+	// larger bodies are rejected with 413. 0 rejects compressed request bodies.
+	MaxDecompressedSize int64
+}
+
+// DefaultCompressionConfig returns the default compression configuration
+func DefaultCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		Encodings: []string{EncodingBrotli, EncodingZstd, EncodingGzip},
+		MinSize:   1024,
+		ContentTypes: []string{
+			"application/json",
+			"application/problem+json",
+			"application/javascript",
+			"application/xml",
+			"image/svg+xml",
+			"text/",
+		},
+		MaxDecompressedSize: 10 << 20, // 10 MiB
+	}
+}
+
+// encoder is a pooled response compressor
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoderPools holds reusable encoders by content coding
+var encoderPools = map[string]*sync.Pool{
+	EncodingGzip: {New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	}},
+	EncodingBrotli: {New: func() interface{} {
+		// Level 4 compresses better than gzip at a similar speed
+		return brotli.NewWriterLevel(io.Discard, 4)
+	}},
+	EncodingZstd: {New: func() interface{} {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return enc
+	}},
+}
+
+// Compression compresses responses for clients that accept it and
+// decompresses compressed request bodies
+func Compression() gin.HandlerFunc {
+	return CompressionWithConfig(DefaultCompressionConfig())
+}
+
+// CompressionWithConfig creates a compression middleware with custom
+// configuration. Responses are compressed when their media type is allowed,
+// their body is at least MinSize and they are not already encoded; streaming
+// responses (text/event-stream, or flushed before MinSize is reached) are
+// sent uncompressed.
+func CompressionWithConfig(config CompressionConfig) gin.HandlerFunc {
+	var encodings []string
+	for _, encoding := range config.Encodings {
+		if _, ok := encoderPools[encoding]; ok {
+			encodings = append(encodings, encoding)
+		}
+	}
+
+	return func(c *gin.Context) {
+		for _, prefix := range config.SkipPaths {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		if !decompressRequest(c, config.MaxDecompressedSize) {
+			return
+		}
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), encodings)
+		if c.Request.Method == http.MethodHead {
+			encoding = ""
+		}
+
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			config:         &config,
+			encoding:       encoding,
+		}
+		c.Writer = w
+		completed := false
+		defer func() {
+			// On panic, drop an uncommitted response so recovery can write its own
+			if !completed && !w.decided {
+				w.buf, w.status, w.started = nil, 0, false
+			}
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+
+		c.Next()
+		completed = true
+	}
+}
+
+// negotiateEncoding picks the most preferred of encodings the client accepts,
+// or "" for none
+func negotiateEncoding(acceptEncoding string, encodings []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]bool)
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name == "*" {
+			wildcard = q > 0
+			continue
+		}
+		accepted[name] = q > 0
+	}
+
+	for _, encoding := range encodings {
+		if ok, listed := accepted[encoding]; ok || (!listed && wildcard) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// decompressRequest replaces a compressed request body with its decompressed
+// content, responding with an error and returning false if it cannot
+func decompressRequest(c *gin.Context, maxSize int64) bool {
+	encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+	if encoding == "" || encoding == "identity" || c.Request.Body == nil {
+		return true
+	}
+
+	abort := func(status int, code, message string) bool {
+		c.AbortWithStatusJSON(status, gin.H{
+			"error":   code,
+			"message": message,
+		})
+		return false
+	}
+
+	if maxSize <= 0 {
+		return abort(http.StatusUnsupportedMediaType, "unsupported_encoding", "Compressed request bodies are not accepted")
+	}
+
+	var reader io.Reader
+	switch encoding {
+	case EncodingGzip:
+		gz, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			return abort(http.StatusBadRequest, "invalid_request", "Malformed gzip request body")
+		}
+		defer gz.Close()
+		reader = gz
+	case EncodingBrotli:
+		reader = brotli.NewReader(c.Request.Body)
+	case EncodingZstd:
+		zr, err := zstd.NewReader(c.Request.Body,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxMemory(uint64(maxSize)),
+		)
+		if err != nil {
+			return abort(http.StatusBadRequest, "invalid_request", "Malformed zstd request body")
+		}
+		defer zr.Close()
+		reader = zr
+	default:
+		return abort(http.StatusUnsupportedMediaType, "unsupported_encoding",
+			fmt.Sprintf("Unsupported request content encoding %q", encoding))
+	}
+
+	// Read at most one byte past the limit to detect oversized bodies
+	body, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if int64(len(body)) > maxSize || tooLarge(err) {
+		return abort(http.StatusRequestEntityTooLarge, "request_too_large",
+			fmt.Sprintf("Decompressed request body exceeds %d bytes", maxSize))
+	}
+	if err != nil {
+		return abort(http.StatusBadRequest, "invalid_request", "Malformed "+encoding+" request body")
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Request.ContentLength = int64(len(body))
+	c.Request.Header.Del("Content-Encoding")
+	c.Request.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return true
+}
+
+// tooLarge reports whether a decoder refused to decompress a frame larger
+// than its memory limit
+func tooLarge(err error) bool {
+	return errors.Is(err, zstd.ErrDecoderSizeExceeded) ||
+		errors.Is(err, zstd.ErrFrameSizeExceeded) ||
+		errors.Is(err, zstd.ErrWindowSizeExceeded)
+}
+
+// compressWriter buffers the start of a response until it can decide whether
+// to compress it, then either streams it through an encoder or passes it on
+type compressWriter struct {
+	gin.ResponseWriter
+	config   *CompressionConfig
+	encoding string // negotiated encoding, "" if the client accepts none
+
+	status  int
+	started bool // the header was written, though it may still be buffered
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+// WriteHeader records the status until the response is committed
+func (w *compressWriter) WriteHeader(code int) {
+	if !w.decided && code > 0 {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow marks the header as written; it is committed with the body
+func (w *compressWriter) WriteHeaderNow() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.started = true
+}
+
+// Status returns the response status
+func (w *compressWriter) Status() int {
+	if !w.decided && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+// Written reports whether the handler has started the response. Like gin's
+// writer, a status set with WriteHeader alone does not count.
+func (w *compressWriter) Written() bool {
+	return w.started || w.ResponseWriter.Written()
+}
+
+// Write buffers the body until MinSize is reached, then commits the response
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.config.MinSize {
+		if err := w.commit(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// WriteString writes s as the body
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush commits the response, uncompressed if it is still below MinSize,
+// and flushes it to the client
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.WriteHeaderNow()
+		_ = w.commit()
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack takes over the connection, e.g. for WebSockets
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// commit decides whether to compress, writes the header and the buffered body
+func (w *compressWriter) commit() error {
+	w.decided = true
+	header := w.Header()
+
+	if w.compressible() {
+		header.Add("Vary", "Accept-Encoding")
+		if w.encoding != "" {
+			header.Set("Content-Encoding", w.encoding)
+			header.Del("Content-Length")
+			w.enc = encoderPools[w.encoding].Get().(encoder)
+			w.enc.Reset(w.ResponseWriter)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return nil
+	}
+
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// compressible reports whether the response could be compressed for a
+// client accepting it: large enough, not streaming, not encoded and of an
+// allowed media type
+func (w *compressWriter) compressible() bool {
+	if len(w.buf) < w.config.MinSize || len(w.buf) == 0 {
+		return false
+	}
+	switch w.status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buf)
+		header.Set("Content-Type", contentType)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "text/event-stream" {
+		return false
+	}
+	for _, allowed := range w.config.ContentTypes {
+		if mediaType == allowed || (strings.HasSuffix(allowed, "/") && strings.HasPrefix(mediaType, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// finish commits a response still being buffered and completes the encoding
+func (w *compressWriter) finish() {
+	if !w.decided && w.status != 0 {
+		_ = w.commit()
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.enc.Reset(io.Discard)
+		encoderPools[w.encoding].Put(w.enc)
+		w.enc = nil
+	}
+}
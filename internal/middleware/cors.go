@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,10 @@ type CORSConfig struct {
 	// Default value is []
 	AllowOrigins []string
 
+	// AllowOriginList replaces AllowOrigins when set, allowing the origins to
+	// be changed at runtime
+	AllowOriginList *OriginList
+
 	// AllowOriginFunc is a function to determine if origin is allowed.
 	// This allows for dynamic origin validation
 	AllowOriginFunc func(origin string) bool
@@ -59,6 +64,29 @@ type CORSConfig struct {
 	AllowFiles bool
 }
 
+// OriginList is a list of allowed origins that can be replaced at runtime
+type OriginList struct {
+	origins atomic.Pointer[[]string]
+}
+
+// NewOriginList creates an origin list
+func NewOriginList(origins []string) *OriginList {
+	l := &OriginList{}
+	l.Set(origins)
+	return l
+}
+
+// Set replaces the allowed origins
+func (l *OriginList) Set(origins []string) {
+	origins = append([]string(nil), origins...)
+	l.origins.Store(&origins)
+}
+
+// Origins returns the allowed origins
+func (l *OriginList) Origins() []string {
+	return *l.origins.Load()
+}
+
 // DefaultCORSConfig returns a secure default CORS configuration (CORS disabled)
 func DefaultCORSConfig() CORSConfig {
 	return CORSConfig{
@@ -178,7 +206,11 @@ func isOriginAllowed(origin string, config CORSConfig) bool {
 	}
 
 	// Check if origin is in allowed list
-	for _, allowedOrigin := range config.AllowOrigins {
+	allowOrigins := config.AllowOrigins
+	if config.AllowOriginList != nil {
+		allowOrigins = config.AllowOriginList.Origins()
+	}
+	for _, allowedOrigin := range allowOrigins {
 		if config.AllowWildcard && strings.Contains(allowedOrigin, "*") {
 			if matchWildcard(origin, allowedOrigin) {
 				return true
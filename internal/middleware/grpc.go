@@ -0,0 +1,109 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// wrappedServerStream overrides the context of a grpc.ServerStream so
+// stream interceptors can pass enriched contexts to handlers
+type wrappedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedServerStream) Context() context.Context {
+	return w.ctx
+}
+
+// wrapServerStream returns ss with its context replaced by ctx
+func wrapServerStream(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	if w, ok := ss.(*wrappedServerStream); ok {
+		return &wrappedServerStream{ServerStream: w.ServerStream, ctx: ctx}
+	}
+	return &wrappedServerStream{ServerStream: ss, ctx: ctx}
+}
+
+// StreamDrainInterceptor cancels the context of open streams once drain is
+// done, telling long-lived streams to end while the server shuts down
+func StreamDrainInterceptor(drain context.Context) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := context.WithCancel(ss.Context())
+		defer cancel()
+		stop := context.AfterFunc(drain, cancel)
+		defer stop()
+		return handler(srv, wrapServerStream(ss, ctx))
+	}
+}
+
+// SplitMethodName splits a full gRPC method name ("/pkg.Service/Method")
+// into its service and method parts
+func SplitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+
+// metadataValue returns the first value for key in the incoming metadata
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// PeerIP returns the IP address of the gRPC client
+func PeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// skipMethodMap builds a lookup set of full method names
+func skipMethodMap(methods []string) map[string]bool {
+	skipMap := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		skipMap[method] = true
+	}
+	return skipMap
+}
@@ -0,0 +1,79 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryCorrelationInterceptor adds correlation IDs from incoming metadata to
+// the handler context and echoes them back in the response headers
+func UnaryCorrelationInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(correlateGRPC(ctx), req)
+	}
+}
+
+// StreamCorrelationInterceptor adds correlation IDs from incoming metadata to
+// the stream context and echoes them back in the response headers
+func StreamCorrelationInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, wrapServerStream(ss, correlateGRPC(ss.Context())))
+	}
+}
+
+// correlateGRPC mirrors Correlation for gRPC metadata
+func correlateGRPC(ctx context.Context) context.Context {
+	// Get or generate request ID
+	requestID := metadataValue(ctx, strings.ToLower(HeaderRequestID))
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	// Get or generate correlation ID
+	correlationID := metadataValue(ctx, strings.ToLower(HeaderCorrelationID))
+	if correlationID == "" {
+		correlationID = requestID // Use request ID as correlation ID if not provided
+	}
+
+	// Echo IDs back to the client; this fails only if headers were already sent
+	_ = grpc.SetHeader(ctx, metadata.Pairs(
+		strings.ToLower(HeaderRequestID), requestID,
+		strings.ToLower(HeaderCorrelationID), correlationID,
+	))
+
+	// Create context with correlation values
+	ctx = context.WithValue(ctx, logger.RequestIDKey, requestID)
+	ctx = context.WithValue(ctx, logger.CorrelationIDKey, correlationID)
+
+	// Extract user context if available
+	if userID := metadataValue(ctx, strings.ToLower(HeaderUserID)); userID != "" {
+		ctx = context.WithValue(ctx, logger.UserIDKey, userID)
+	}
+	if tenantID := metadataValue(ctx, strings.ToLower(HeaderTenantID)); tenantID != "" {
+		ctx = context.WithValue(ctx, logger.TenantIDKey, tenantID)
+	}
+
+	return ctx
+}
+
+// RequestIDFromContext returns the request ID stored by the correlation interceptor
+func RequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(logger.RequestIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// CorrelationIDFromContext returns the correlation ID stored by the correlation interceptor
+func CorrelationIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(logger.CorrelationIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
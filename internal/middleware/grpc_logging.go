@@ -0,0 +1,145 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryLoggingInterceptor writes an access log entry for every unary RPC.
+func UnaryLoggingInterceptor(config LoggingConfig) grpc.UnaryServerInterceptor {
+	config = grpcLoggingDefaults(config)
+	skipMap := skipMethodMap(config.SkipPaths)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if skipMap[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		fields := grpcLogFields(ctx, info.FullMethod, "unary", time.Since(start), err)
+		if config.LogRequestBody {
+			fields = appendMessageField(fields, "request_body", req, config.MaxBodySize)
+		}
+		if config.LogResponseBody && err == nil {
+			fields = appendMessageField(fields, "response_body", resp, config.MaxBodySize)
+		}
+		logGRPCRequest(ctx, config, info.FullMethod, time.Since(start), err, fields)
+
+		return resp, err
+	}
+}
+
+// StreamLoggingInterceptor writes an access log entry when a streaming RPC ends.
+func StreamLoggingInterceptor(config LoggingConfig) grpc.StreamServerInterceptor {
+	config = grpcLoggingDefaults(config)
+	skipMap := skipMethodMap(config.SkipPaths)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if skipMap[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		start := time.Now()
+		err := handler(srv, ss)
+
+		ctx := ss.Context()
+		fields := grpcLogFields(ctx, info.FullMethod, "stream", time.Since(start), err)
+		logGRPCRequest(ctx, config, info.FullMethod, time.Since(start), err, fields)
+
+		return err
+	}
+}
+
+// grpcLoggingDefaults fills in logging defaults
+func grpcLoggingDefaults(config LoggingConfig) LoggingConfig {
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = 10 * 1024 // 10KB default
+	}
+	if config.SlowThreshold == 0 {
+		config.SlowThreshold = 1 * time.Second
+	}
+	return config
+}
+
+// grpcLogFields builds the common access log fields for an RPC
+func grpcLogFields(ctx context.Context, fullMethod, kind string, latency time.Duration, err error) []zap.Field {
+	service, method := SplitMethodName(fullMethod)
+	code := status.Code(err)
+
+	fields := []zap.Field{
+		zap.String("grpc_service", service),
+		zap.String("grpc_method", method),
+		zap.String("grpc_type", kind),
+		zap.String("grpc_code", code.String()),
+		zap.String("ip", PeerIP(ctx)),
+		zap.Duration("latency", latency),
+		zap.Float64("latency_ms", float64(latency.Nanoseconds())/1e6),
+		zap.String("user_agent", metadataValue(ctx, "user-agent")),
+	}
+
+	if err != nil {
+		fields = append(fields, zap.String("error", status.Convert(err).Message()))
+	}
+
+	return fields
+}
+
+// appendMessageField adds a redacted JSON rendering of a proto message
+func appendMessageField(fields []zap.Field, key string, msg interface{}, maxSize int64) []zap.Field {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return fields
+	}
+	body, err := protojson.Marshal(m)
+	if err != nil || len(body) == 0 || int64(len(body)) >= maxSize {
+		return fields
+	}
+	return append(fields, zap.String(key, logger.RedactJSON(string(body), logger.DefaultRedactOptions())))
+}
+
+// logGRPCRequest logs an RPC at a level matching its status code
+func logGRPCRequest(ctx context.Context, config LoggingConfig, fullMethod string, latency time.Duration, err error, fields []zap.Field) {
+	log := logger.WithContext(ctx)
+
+	switch grpcCodeClass(status.Code(err)) {
+	case "server_error":
+		log.Error("gRPC request failed", fields...)
+	case "client_error":
+		log.Warn("gRPC request client error", fields...)
+	default:
+		log.Info("gRPC request completed", fields...)
+	}
+
+	// Log slow requests
+	if latency > config.SlowThreshold {
+		log.Warn("Slow gRPC request detected",
+			zap.String("grpc_full_method", fullMethod),
+			zap.Duration("latency", latency),
+			zap.Duration("threshold", config.SlowThreshold),
+		)
+	}
+}
+
+// grpcCodeClass classifies a gRPC code the way HTTP statuses are bucketed
+func grpcCodeClass(code codes.Code) string {
+	switch code {
+	case codes.OK:
+		return "ok"
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented,
+		codes.Internal, codes.Unavailable, codes.DataLoss:
+		return "server_error"
+	default:
+		return "client_error"
+	}
+}
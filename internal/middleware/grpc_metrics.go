@@ -0,0 +1,78 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryMetricsInterceptor records gRPC request count and latency for unary RPCs.
+func UnaryMetricsInterceptor(config MetricsConfig) grpc.UnaryServerInterceptor {
+	skipMap := skipMethodMap(config.SkipPaths)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if skipMap[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		service, method := SplitMethodName(info.FullMethod)
+		metrics.RecordGRPCRequest(service, method, status.Code(err).String(), time.Since(start))
+
+		return resp, err
+	}
+}
+
+// StreamMetricsInterceptor records gRPC request count and latency for
+// streaming RPCs, plus a per-message count in each direction.
+func StreamMetricsInterceptor(config MetricsConfig) grpc.StreamServerInterceptor {
+	skipMap := skipMethodMap(config.SkipPaths)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if skipMap[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		service, method := SplitMethodName(info.FullMethod)
+		start := time.Now()
+
+		err := handler(srv, &metricsServerStream{
+			ServerStream: ss,
+			service:      service,
+			method:       method,
+		})
+
+		metrics.RecordGRPCRequest(service, method, status.Code(err).String(), time.Since(start))
+
+		return err
+	}
+}
+
+// metricsServerStream counts messages sent and received on a stream
+type metricsServerStream struct {
+	grpc.ServerStream
+	service string
+	method  string
+}
+
+func (s *metricsServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		metrics.RecordGRPCStreamMsg(s.service, s.method, "sent")
+	}
+	return err
+}
+
+func (s *metricsServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		metrics.RecordGRPCStreamMsg(s.service, s.method, "received")
+	}
+	return err
+}
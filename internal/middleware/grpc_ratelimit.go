@@ -0,0 +1,219 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCRateLimitConfig provides configuration for the gRPC rate limiting interceptors
+type GRPCRateLimitConfig struct {
+	// Enabled enables rate limiting
+	Enabled bool
+	// Rate is the number of requests per minute
+	Rate int
+	// Burst is the maximum burst size
+	Burst int
+	// Limiter is the limiter to use, left to its owner to close. When nil a
+	// token bucket limiter is created from Rate and Burst and stopped by
+	// GRPCRateLimit.Close.
+	Limiter RateLimiter
+	// KeyFunc generates the rate limit key from the request context
+	KeyFunc func(ctx context.Context, fullMethod string) string
+	// Policies, when set, limit calls instead of Limiter and KeyFunc;
+	// calls matching no policy are not limited
+	Policies *RateLimitPolicies
+	// Headers is the rate limit metadata format: RateLimitHeadersLegacy
+	// (default), RateLimitHeadersIETF or RateLimitHeadersBoth
+	Headers string
+	// SkipPaths skips rate limiting for these full method names, such as
+	// "/grpc.health.v1.Health/Check"
+	SkipPaths []string
+}
+
+// GRPCRateLimitKeyFunc returns the key function for a rate limit type
+// ("ip", "user" or "api_key"), mirroring IPRateLimit, UserRateLimit and APIKeyRateLimit
+func GRPCRateLimitKeyFunc(rateLimitType string) func(ctx context.Context, fullMethod string) string {
+	switch rateLimitType {
+	case "user":
+		return func(ctx context.Context, fullMethod string) string {
+			if userID := metadataValue(ctx, strings.ToLower(HeaderUserID)); userID != "" {
+				return fmt.Sprintf("user:%s", userID)
+			}
+			return PeerIP(ctx)
+		}
+	case "api_key":
+		return func(ctx context.Context, fullMethod string) string {
+			if apiKey := metadataValue(ctx, "x-api-key"); apiKey != "" {
+				return fmt.Sprintf("api:%s", apiKey)
+			}
+			if auth := metadataValue(ctx, "authorization"); len(auth) > 7 && auth[:7] == "Bearer " {
+				return fmt.Sprintf("bearer:%s", auth[7:])
+			}
+			return PeerIP(ctx)
+		}
+	default: // "ip"
+		return func(ctx context.Context, fullMethod string) string {
+			return PeerIP(ctx)
+		}
+	}
+}
+
+// GRPCRateLimit holds the unary and stream rate limiting interceptors, which
+// share one limiter so both count against the same budget
+type GRPCRateLimit struct {
+	check func(ctx context.Context, fullMethod string) error // nil when disabled
+	owned []io.Closer
+}
+
+// NewGRPCRateLimit creates the gRPC rate limiting interceptors; Close them
+// when the server is discarded
+func NewGRPCRateLimit(config GRPCRateLimitConfig) *GRPCRateLimit {
+	m := &GRPCRateLimit{}
+	if !config.Enabled {
+		return m
+	}
+
+	limiter := config.Limiter
+	if limiter == nil && config.Policies == nil {
+		bucketLimiter := NewTokenBucketLimiter(
+			config.Rate,
+			config.Burst,
+			time.Minute,
+			5*time.Minute,
+		)
+		limiter = bucketLimiter
+		m.owned = append(m.owned, bucketLimiter)
+	}
+	m.check = grpcRateLimitCheck(config, limiter)
+	return m
+}
+
+// UnaryInterceptor rejects unary RPCs over the rate limit with codes.ResourceExhausted
+func (m *GRPCRateLimit) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	check := m.check
+	if check == nil {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(ctx, req)
+		}
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor rejects streaming RPCs over the rate limit with codes.ResourceExhausted
+func (m *GRPCRateLimit) StreamInterceptor() grpc.StreamServerInterceptor {
+	check := m.check
+	if check == nil {
+		return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(srv, ss)
+		}
+	}
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := check(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// Close stops the limiter the interceptors created; a limiter passed in the
+// configuration is left to its owner
+func (m *GRPCRateLimit) Close() error {
+	var errs []error
+	for _, c := range m.owned {
+		errs = append(errs, c.Close())
+	}
+	m.owned = nil
+	return errors.Join(errs...)
+}
+
+// UnaryRateLimitInterceptor rejects unary RPCs over the rate limit with
+// codes.ResourceExhausted. A limiter it creates, when config.Limiter is nil,
+// lives as long as the process; use NewGRPCRateLimit to release it.
+func UnaryRateLimitInterceptor(config GRPCRateLimitConfig) grpc.UnaryServerInterceptor {
+	return NewGRPCRateLimit(config).UnaryInterceptor()
+}
+
+// StreamRateLimitInterceptor rejects streaming RPCs over the rate limit with
+// codes.ResourceExhausted. A limiter it creates, when config.Limiter is nil,
+// lives as long as the process; use NewGRPCRateLimit to release it.
+func StreamRateLimitInterceptor(config GRPCRateLimitConfig) grpc.StreamServerInterceptor {
+	return NewGRPCRateLimit(config).StreamInterceptor()
+}
+
+// grpcRateLimitCheck builds the rate limit check shared by both interceptor
+// kinds, counting calls against limiter unless config.Policies is set
+func grpcRateLimitCheck(config GRPCRateLimitConfig, limiter RateLimiter) func(ctx context.Context, fullMethod string) error {
+	if config.KeyFunc == nil {
+		config.KeyFunc = GRPCRateLimitKeyFunc("ip")
+	}
+	skipMap := skipMethodMap(config.SkipPaths)
+
+	return func(ctx context.Context, fullMethod string) error {
+		if skipMap[fullMethod] {
+			return nil
+		}
+
+		// Check rate limit
+		var allowed bool
+		var info RateLimitInfo
+		var key, policy string
+		if config.Policies != nil {
+			decision := config.Policies.Check(RateLimitRequestFromGRPC(ctx, fullMethod))
+			allowed, info, key, policy = decision.Allowed, decision.Info, decision.Key, decision.Policy
+		} else if key = config.KeyFunc(ctx, fullMethod); key != "" {
+			allowed, info = limiter.Allow(key)
+		}
+		if key == "" {
+			return nil
+		}
+
+		if policy == "" {
+			policy = defaultRateLimitPolicy
+		}
+		recordRateLimitDecision("grpc", policy, key, allowed)
+
+		// Send rate limit headers, and the same as trailers since clients
+		// reading the status of a rejected call only see trailers
+		md := metadata.MD{}
+		for _, h := range rateLimitHeaders(config.Headers, policy, info) {
+			md.Set(h[0], h[1])
+		}
+		if !allowed {
+			md.Set("retry-after", strconv.Itoa(retryAfterSeconds(info)))
+		}
+		_ = grpc.SetHeader(ctx, md)
+		_ = grpc.SetTrailer(ctx, md)
+
+		if !allowed {
+			logger.Warn(ctx, "Rate limit exceeded",
+				zap.String("key", key),
+				zap.String("policy", policy),
+				zap.String("grpc_full_method", fullMethod),
+				zap.String("ip", PeerIP(ctx)),
+			)
+			return status.Error(codes.ResourceExhausted, "Too many requests. Please try again later.")
+		}
+
+		return nil
+	}
+}
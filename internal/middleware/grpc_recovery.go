@@ -0,0 +1,102 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryRecoveryInterceptor recovers from panics in unary handlers and
+// returns codes.Internal to the client
+func UnaryRecoveryInterceptor(config RecoveryConfig) grpc.UnaryServerInterceptor {
+	if config.StackTraceSize == 0 {
+		config.StackTraceSize = 4096
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = handleGRPCPanic(ctx, info.FullMethod, r, config)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecoveryInterceptor recovers from panics in stream handlers and
+// returns codes.Internal to the client
+func StreamRecoveryInterceptor(config RecoveryConfig) grpc.StreamServerInterceptor {
+	if config.StackTraceSize == 0 {
+		config.StackTraceSize = 4096
+	}
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = handleGRPCPanic(ss.Context(), info.FullMethod, r, config)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// handleGRPCPanic logs a recovered gRPC panic and converts it into a status error
+func handleGRPCPanic(ctx context.Context, fullMethod string, err interface{}, config RecoveryConfig) error {
+	// Capture stack trace
+	var stack []byte
+	if config.EnableStackTrace {
+		stack = make([]byte, config.StackTraceSize)
+		length := runtime.Stack(stack, false)
+		stack = stack[:length]
+	}
+
+	service, method := SplitMethodName(fullMethod)
+
+	// Prepare log fields
+	fields := []zap.Field{
+		zap.Any("error", err),
+		zap.String("grpc_service", service),
+		zap.String("grpc_method", method),
+		zap.String("ip", PeerIP(ctx)),
+	}
+
+	// Add stack trace if enabled
+	if config.EnableStackTrace && len(stack) > 0 {
+		fields = append(fields, zap.ByteString("stack", stack))
+	}
+
+	// Log the panic
+	switch config.LogLevel {
+	case "debug":
+		logger.Debug(ctx, "Panic recovered", fields...)
+	case "info":
+		logger.Info(ctx, "Panic recovered", fields...)
+	case "warn":
+		logger.Warn(ctx, "Panic recovered", fields...)
+	case "fatal":
+		logger.Fatal(ctx, "Panic recovered", fields...)
+	default:
+		logger.Error(ctx, "Panic recovered", nil, fields...)
+	}
+
+	// Print stack to stderr if configured (useful for development)
+	if config.PrintStack && len(stack) > 0 {
+		fmt.Fprintf(os.Stderr, "[Recovery] panic recovered:\n%s\n%s\n", err, stack)
+	}
+
+	// Record panic metric
+	if m := metrics.Get(); m != nil {
+		m.PanicsTotal.Inc()
+	}
+
+	return status.Error(codes.Internal, "An internal server error occurred")
+}
@@ -0,0 +1,118 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryTracingInterceptor creates OpenTelemetry server spans for unary RPCs.
+func UnaryTracingInterceptor(config TracingConfig) grpc.UnaryServerInterceptor {
+	tracer, config := grpcTracer(config)
+	skipMap := skipMethodMap(config.SkipPaths)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if skipMap[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, span := startGRPCSpan(ctx, tracer, config, info.FullMethod)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		finishGRPCSpan(span, config, err)
+		return resp, err
+	}
+}
+
+// StreamTracingInterceptor creates OpenTelemetry server spans for streaming RPCs.
+func StreamTracingInterceptor(config TracingConfig) grpc.StreamServerInterceptor {
+	tracer, config := grpcTracer(config)
+	skipMap := skipMethodMap(config.SkipPaths)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if skipMap[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		ctx, span := startGRPCSpan(ss.Context(), tracer, config, info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, wrapServerStream(ss, ctx))
+		finishGRPCSpan(span, config, err)
+		return err
+	}
+}
+
+// grpcTracer applies tracing defaults and returns the tracer to use
+func grpcTracer(config TracingConfig) (trace.Tracer, TracingConfig) {
+	if config.ServiceName == "" {
+		config.ServiceName = "lumi-go"
+	}
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	if config.Propagator == nil {
+		config.Propagator = otel.GetTextMapPropagator()
+	}
+
+	tracer := config.TracerProvider.Tracer(
+		config.ServiceName,
+		trace.WithInstrumentationVersion("1.0.0"),
+	)
+	return tracer, config
+}
+
+// startGRPCSpan extracts the remote trace context and starts a server span
+func startGRPCSpan(ctx context.Context, tracer trace.Tracer, config TracingConfig, fullMethod string) (context.Context, trace.Span) {
+	// Extract trace context from incoming metadata
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = config.Propagator.Extract(ctx, metadataCarrier(md.Copy()))
+	}
+
+	service, method := SplitMethodName(fullMethod)
+	ctx, span := tracer.Start(ctx, strings.TrimPrefix(fullMethod, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(service),
+			semconv.RPCMethod(method),
+			attribute.String("net.peer.ip", PeerIP(ctx)),
+			attribute.String("rpc.request_id", RequestIDFromContext(ctx)),
+			attribute.String("rpc.correlation_id", CorrelationIDFromContext(ctx)),
+		),
+	)
+
+	// Expose trace and span IDs to the logger
+	if spanCtx := span.SpanContext(); spanCtx.IsValid() {
+		ctx = context.WithValue(ctx, logger.TraceIDKey, spanCtx.TraceID().String())
+		ctx = context.WithValue(ctx, logger.SpanIDKey, spanCtx.SpanID().String())
+	}
+
+	return ctx, span
+}
+
+// finishGRPCSpan records the RPC outcome on the span
+func finishGRPCSpan(span trace.Span, config TracingConfig, err error) {
+	st, _ := status.FromError(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+
+	if err != nil {
+		span.SetStatus(codes.Error, st.Message())
+		if config.RecordError {
+			span.RecordError(err)
+		}
+		return
+	}
+	span.SetStatus(codes.Ok, "")
+}
@@ -138,6 +138,8 @@ func Logging(skipPaths ...string) gin.HandlerFunc {
 
 // LoggingConfig provides configuration for the logging middleware
 type LoggingConfig struct {
+	// SkipPaths skips logging for these paths, or for these full method names
+	// such as "/grpc.health.v1.Health/Check" in the gRPC interceptors
 	SkipPaths      []string
 	LogRequestBody bool
 	LogResponseBody bool
@@ -0,0 +1,201 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MaintenanceConfig provides configuration for the maintenance mode middleware
+type MaintenanceConfig struct {
+	// RetryAfter is sent to rejected clients as the Retry-After header
+	RetryAfter time.Duration
+	// Message is the message of the rejection
+	Message string
+	// AllowIPs lists client IP addresses or CIDR ranges that bypass maintenance mode
+	AllowIPs []string
+	// AllowAPIKeys lists API keys (X-API-Key) that bypass maintenance mode
+	AllowAPIKeys []string
+	// SkipMethods lists gRPC full method names that are always served
+	SkipMethods []string
+}
+
+// DefaultMaintenanceConfig returns the default maintenance mode configuration
+func DefaultMaintenanceConfig() MaintenanceConfig {
+	return MaintenanceConfig{
+		RetryAfter: 5 * time.Minute,
+		Message:    "The service is down for maintenance. Please try again later.",
+	}
+}
+
+// MaintenanceMode is a maintenance switch shared by the HTTP middleware and
+// gRPC interceptors that can be flipped at runtime
+type MaintenanceMode struct {
+	config  MaintenanceConfig
+	enabled atomic.Bool
+	nets    []*net.IPNet
+}
+
+// NewMaintenanceMode creates a maintenance switch, initially enabled or not.
+// Invalid entries in AllowIPs are ignored.
+func NewMaintenanceMode(config MaintenanceConfig, enabled bool) *MaintenanceMode {
+	if config.Message == "" {
+		config.Message = DefaultMaintenanceConfig().Message
+	}
+
+	m := &MaintenanceMode{config: config}
+	for _, entry := range config.AllowIPs {
+		if ipNet := parseIPNet(entry); ipNet != nil {
+			m.nets = append(m.nets, ipNet)
+		}
+	}
+	m.enabled.Store(enabled)
+	return m
+}
+
+// SetEnabled turns maintenance mode on or off
+func (m *MaintenanceMode) SetEnabled(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// Enabled reports whether maintenance mode is on
+func (m *MaintenanceMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Allowed reports whether a client may bypass maintenance mode, by IP address
+// or API key
+func (m *MaintenanceMode) Allowed(ip, apiKey string) bool {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		for _, ipNet := range m.nets {
+			if ipNet.Contains(parsed) {
+				return true
+			}
+		}
+	}
+	if apiKey != "" {
+		for _, key := range m.config.AllowAPIKeys {
+			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rejects reports whether a request must be rejected
+func (m *MaintenanceMode) rejects(ip, apiKey string) bool {
+	return m.Enabled() && !m.Allowed(ip, apiKey)
+}
+
+// retryAfterSeconds returns RetryAfter in whole seconds, rounded up
+func (m *MaintenanceMode) retryAfterSeconds() int {
+	return int(math.Ceil(m.config.RetryAfter.Seconds()))
+}
+
+// Maintenance rejects requests with 503 Service Unavailable while maintenance
+// mode is on. Apply it to API routes only so probes and ops routes keep working.
+func Maintenance(m *MaintenanceMode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.rejects(realClientIP(c), c.GetHeader("X-API-Key")) {
+			c.Next()
+			return
+		}
+
+		retryAfter := m.retryAfterSeconds()
+		if retryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+		}
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":       "maintenance",
+			"message":     m.config.Message,
+			"retry_after": retryAfter,
+		})
+	}
+}
+
+// realClientIP returns the client IP resolved by the RealIP middleware, which
+// only honours forwarding headers from trusted proxies, falling back to Gin's
+func realClientIP(c *gin.Context) string {
+	if ip := c.GetString("client_ip"); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
+
+// UnaryMaintenanceInterceptor rejects unary RPCs with codes.Unavailable while
+// maintenance mode is on
+func UnaryMaintenanceInterceptor(m *MaintenanceMode) grpc.UnaryServerInterceptor {
+	check := grpcMaintenanceCheck(m)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamMaintenanceInterceptor rejects streaming RPCs with codes.Unavailable
+// while maintenance mode is on
+func StreamMaintenanceInterceptor(m *MaintenanceMode) grpc.StreamServerInterceptor {
+	check := grpcMaintenanceCheck(m)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := check(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// grpcMaintenanceCheck builds the shared maintenance check for both interceptor kinds
+func grpcMaintenanceCheck(m *MaintenanceMode) func(ctx context.Context, fullMethod string) error {
+	skipMap := skipMethodMap(m.config.SkipMethods)
+
+	return func(ctx context.Context, fullMethod string) error {
+		if skipMap[fullMethod] || !m.rejects(PeerIP(ctx), metadataValue(ctx, "x-api-key")) {
+			return nil
+		}
+
+		if retryAfter := m.retryAfterSeconds(); retryAfter > 0 {
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+		}
+		return status.Error(codes.Unavailable, m.config.Message)
+	}
+}
+
+// parseIPNet parses an IP address or CIDR range, returning nil if invalid
+func parseIPNet(entry string) *net.IPNet {
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil
+		}
+		return ipNet
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
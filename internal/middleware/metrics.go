@@ -41,7 +41,8 @@ func Metrics() gin.HandlerFunc {
 
 // MetricsWithConfig allows custom configuration for metrics middleware
 type MetricsConfig struct {
-	// SkipPaths specifies paths to skip from metrics
+	// SkipPaths specifies paths to skip from metrics, or full method names
+	// such as "/grpc.health.v1.Health/Check" in the gRPC interceptors
 	SkipPaths []string
 	// GroupedPaths groups similar paths together (e.g., /users/:id -> /users/{id})
 	GroupedPaths map[string]string
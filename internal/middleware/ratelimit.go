@@ -3,10 +3,14 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -21,38 +25,75 @@ type RateLimiter interface {
 	Reset(key string)
 }
 
+// InspectableRateLimiter is a rate limiter whose buckets can be listed, as
+// the admin API does
+type InspectableRateLimiter interface {
+	Snapshot() []BucketState
+	Reset(key string)
+}
+
 // RateLimitInfo contains rate limit information
 type RateLimitInfo struct {
 	Limit     int
 	Remaining int
 	ResetTime time.Time
+	// Window is the period Limit applies to
+	Window time.Duration
 }
 
-// TokenBucketLimiter implements token bucket algorithm
+// TokenBucketLimiter implements the token bucket algorithm as a generic cell
+// rate algorithm (GCRA): each key stores only the theoretical arrival time of
+// its next request, so tokens refill continuously rather than per second.
+// Keys are spread over shards, each with its own lock.
 type TokenBucketLimiter struct {
-	mu      sync.RWMutex
+	shards   [tokenBucketShards]bucketShard
+	limits   atomic.Pointer[bucketLimits]
+	interval time.Duration // period rate applies to
+	ttl      time.Duration // TTL for inactive buckets
+	cleanup  time.Duration // cleanup interval
+
+	closeOnce sync.Once
+	stop      chan struct{} // closed by Close
+	done      chan struct{} // closed when the cleanup goroutine exits
+}
+
+// tokenBucketShards is the number of shards; a power of two
+const tokenBucketShards = 64
+
+// bucketShard holds the buckets of the keys hashing to it
+type bucketShard struct {
+	mu      sync.Mutex
 	buckets map[string]*bucket
-	rate    int           // tokens per interval
-	burst   int           // max tokens in bucket
-	ttl     time.Duration // TTL for inactive buckets
-	cleanup time.Duration // cleanup interval
+}
+
+// bucketLimits are the limits applied to every bucket
+type bucketLimits struct {
+	rate      int           // tokens per interval
+	burst     int           // max tokens in bucket
+	emission  time.Duration // time to refill one token
+	tolerance time.Duration // time to refill a full bucket
 }
 
 type bucket struct {
-	tokens    int
-	lastFill  time.Time
-	resetTime time.Time
+	tat      time.Time // theoretical arrival time: when the bucket is full again
+	lastSeen time.Time
 }
 
-// NewTokenBucketLimiter creates a new token bucket rate limiter
+// NewTokenBucketLimiter creates a new token bucket rate limiter allowing rate
+// requests per interval, with bursts of up to burst requests. Close stops
+// its cleanup goroutine.
 func NewTokenBucketLimiter(rate, burst int, interval, ttl time.Duration) *TokenBucketLimiter {
 	limiter := &TokenBucketLimiter{
-		buckets: make(map[string]*bucket),
-		rate:    rate,
-		burst:   burst,
-		ttl:     ttl,
-		cleanup: ttl / 2,
+		interval: interval,
+		ttl:      ttl,
+		cleanup:  ttl / 2,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
 	}
+	for i := range limiter.shards {
+		limiter.shards[i].buckets = make(map[string]*bucket)
+	}
+	limiter.SetLimits(rate, burst)
 
 	// Start cleanup goroutine
 	go limiter.cleanupRoutine()
@@ -60,85 +101,189 @@ func NewTokenBucketLimiter(rate, burst int, interval, ttl time.Duration) *TokenB
 	return limiter
 }
 
-// Allow checks if request is allowed
-func (l *TokenBucketLimiter) Allow(key string) (bool, RateLimitInfo) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+// shard returns the shard holding key
+func (l *TokenBucketLimiter) shard(key string) *bucketShard {
+	// FNV-1a, inlined to avoid allocating a hasher per call
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return &l.shards[h&(tokenBucketShards-1)]
+}
 
+// Allow checks if request is allowed. ResetTime is when the bucket is full
+// again, or for a denied request when the next token is available.
+func (l *TokenBucketLimiter) Allow(key string) (bool, RateLimitInfo) {
+	limits := l.limits.Load()
 	now := time.Now()
-	b, exists := l.buckets[key]
 
+	s := l.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, exists := s.buckets[key]
 	if !exists {
-		// Create new bucket
-		b = &bucket{
-			tokens:    l.burst - 1,
-			lastFill:  now,
-			resetTime: now.Add(time.Minute),
-		}
-		l.buckets[key] = b
+		b = &bucket{tat: now}
+		s.buckets[key] = b
+	}
+	b.lastSeen = now
 
-		return true, RateLimitInfo{
-			Limit:     l.rate,
-			Remaining: b.tokens,
-			ResetTime: b.resetTime,
+	tat := b.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(limits.emission)
+
+	// The request fits if the bucket, refilled up to now, has a token left
+	if wait := next.Sub(now) - limits.tolerance; wait > 0 {
+		return false, RateLimitInfo{
+			Limit:     limits.rate,
+			Remaining: 0,
+			ResetTime: now.Add(wait),
+			Window:    l.interval,
 		}
 	}
 
-	// Refill tokens based on time elapsed
-	elapsed := now.Sub(b.lastFill)
-	tokensToAdd := int(elapsed.Seconds()) * l.rate / 60 // tokens per second
-	b.tokens = min(b.tokens+tokensToAdd, l.burst)
-	b.lastFill = now
+	b.tat = next
+	return true, RateLimitInfo{
+		Limit:     limits.rate,
+		Remaining: limits.remaining(next, now),
+		ResetTime: next,
+		Window:    l.interval,
+	}
+}
 
-	// Check if we have tokens
-	if b.tokens > 0 {
-		b.tokens--
-		return true, RateLimitInfo{
-			Limit:     l.rate,
-			Remaining: b.tokens,
-			ResetTime: b.resetTime,
-		}
+// remaining returns the whole tokens left in a bucket full again at tat
+func (limits *bucketLimits) remaining(tat, now time.Time) int {
+	if !tat.After(now) {
+		return limits.burst
 	}
+	return int((limits.tolerance - tat.Sub(now)) / limits.emission)
+}
 
-	// Update reset time if needed
-	if now.After(b.resetTime) {
-		b.resetTime = now.Add(time.Minute)
-		b.tokens = l.burst - 1
-		return true, RateLimitInfo{
-			Limit:     l.rate,
-			Remaining: b.tokens,
-			ResetTime: b.resetTime,
+// SetLimits changes the rate and burst applied to all buckets, including existing ones
+func (l *TokenBucketLimiter) SetLimits(rate, burst int) {
+	rate = max(rate, 1)
+	burst = max(burst, 1)
+	emission := l.interval / time.Duration(rate)
+	l.limits.Store(&bucketLimits{
+		rate:      rate,
+		burst:     burst,
+		emission:  emission,
+		tolerance: emission * time.Duration(burst),
+	})
+}
+
+// Reset resets the rate limit for a key
+func (l *TokenBucketLimiter) Reset(key string) {
+	s := l.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.buckets, key)
+}
+
+// BucketState is a snapshot of the rate limit of one key: the requests it
+// has left, when it last made one and when its budget is full again
+type BucketState struct {
+	Key       string    `json:"key"`
+	Policy    string    `json:"policy,omitempty"`
+	Tokens    int       `json:"tokens"`
+	LastFill  time.Time `json:"last_fill"`
+	ResetTime time.Time `json:"reset_time"`
+}
+
+// sortBucketStates sorts bucket states by key, then policy
+func sortBucketStates(states []BucketState) {
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Key != states[j].Key {
+			return states[i].Key < states[j].Key
 		}
+		return states[i].Policy < states[j].Policy
+	})
+}
+
+// Snapshot returns a snapshot of every bucket, sorted by key
+func (l *TokenBucketLimiter) Snapshot() []BucketState {
+	limits := l.limits.Load()
+	now := time.Now()
+
+	var states []BucketState
+	for i := range l.shards {
+		s := &l.shards[i]
+		s.mu.Lock()
+		for key, b := range s.buckets {
+			states = append(states, b.state(key, limits, now))
+		}
+		s.mu.Unlock()
+	}
+	if states == nil {
+		states = []BucketState{}
 	}
+	sortBucketStates(states)
+	return states
+}
 
-	return false, RateLimitInfo{
-		Limit:     l.rate,
-		Remaining: 0,
-		ResetTime: b.resetTime,
+// Bucket returns a snapshot of the bucket for key, if it exists
+func (l *TokenBucketLimiter) Bucket(key string) (BucketState, bool) {
+	s := l.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		return BucketState{}, false
 	}
+	return b.state(key, l.limits.Load(), time.Now()), true
 }
 
-// Reset resets the rate limit for a key
-func (l *TokenBucketLimiter) Reset(key string) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	delete(l.buckets, key)
+// state returns a snapshot of the bucket
+func (b *bucket) state(key string, limits *bucketLimits, now time.Time) BucketState {
+	resetTime := b.tat
+	if resetTime.Before(now) {
+		resetTime = now
+	}
+	return BucketState{
+		Key:       key,
+		Tokens:    limits.remaining(b.tat, now),
+		LastFill:  b.lastSeen,
+		ResetTime: resetTime,
+	}
+}
+
+// Close stops the cleanup goroutine; the limiter keeps working, but no
+// longer forgets inactive keys
+func (l *TokenBucketLimiter) Close() error {
+	l.closeOnce.Do(func() { close(l.stop) })
+	<-l.done
+	return nil
 }
 
-// cleanupRoutine periodically cleans up old buckets
+// cleanupRoutine periodically cleans up old buckets until Close
 func (l *TokenBucketLimiter) cleanupRoutine() {
+	defer close(l.done)
 	ticker := time.NewTicker(l.cleanup)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		l.mu.Lock()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.stop:
+			return
+		}
+
 		now := time.Now()
-		for key, b := range l.buckets {
-			if now.Sub(b.lastFill) > l.ttl {
-				delete(l.buckets, key)
+		for i := range l.shards {
+			s := &l.shards[i]
+			s.mu.Lock()
+			for key, b := range s.buckets {
+				// Buckets still refilling keep their state until full
+				if now.Sub(b.lastSeen) > l.ttl && !b.tat.After(now) {
+					delete(s.buckets, key)
+				}
 			}
+			s.mu.Unlock()
 		}
-		l.mu.Unlock()
 	}
 }
 
@@ -158,9 +303,19 @@ type RateLimitConfig struct {
 	Rate int
 	// Burst is the maximum burst size
 	Burst int
+	// Limiter is the limiter to use; when nil a token bucket limiter is
+	// created from Rate and Burst
+	Limiter RateLimiter
 	// KeyFunc generates the rate limit key from the request
 	KeyFunc func(*gin.Context) string
-	// ErrorHandler handles rate limit errors
+	// Policies, when set, limit requests instead of Limiter and KeyFunc;
+	// requests matching no policy are not limited
+	Policies *RateLimitPolicies
+	// Headers is the rate limit header format: RateLimitHeadersLegacy
+	// (default), RateLimitHeadersIETF or RateLimitHeadersBoth
+	Headers string
+	// ErrorHandler handles rate limit errors; the rate limit headers are
+	// already set
 	ErrorHandler func(*gin.Context, RateLimitInfo)
 	// SkipPaths skips rate limiting for these paths
 	SkipPaths []string
@@ -179,40 +334,78 @@ func DefaultRateLimitConfig() RateLimitConfig {
 			return c.ClientIP()
 		},
 		ErrorHandler: defaultRateLimitErrorHandler,
-		SkipPaths:    []string{"/health", "/ready", "/metrics"},
+		// Probes come from one kubelet per node; limiting them would fail
+		// liveness under load and restart healthy pods
+		SkipPaths: []string{"/health", "/healthz", "/ready", "/readyz", "/startupz", "/metrics"},
 	}
 }
 
 // defaultRateLimitErrorHandler is the default error handler
 func defaultRateLimitErrorHandler(c *gin.Context, info RateLimitInfo) {
-	c.Header("X-RateLimit-Limit", strconv.Itoa(info.Limit))
-	c.Header("X-RateLimit-Remaining", strconv.Itoa(info.Remaining))
-	c.Header("X-RateLimit-Reset", strconv.FormatInt(info.ResetTime.Unix(), 10))
-	c.Header("Retry-After", strconv.Itoa(int(time.Until(info.ResetTime).Seconds())))
+	retryAfter := retryAfterSeconds(info)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
 
 	c.JSON(http.StatusTooManyRequests, gin.H{
 		"error":       "rate_limit_exceeded",
 		"message":     "Too many requests. Please try again later.",
-		"retry_after": int(time.Until(info.ResetTime).Seconds()),
+		"retry_after": retryAfter,
 	})
 	c.Abort()
 }
 
-// RateLimit creates a rate limiting middleware
+// RateLimitMiddleware is a rate limiting middleware together with the
+// limiters it created, which Close releases
+type RateLimitMiddleware struct {
+	handler gin.HandlerFunc
+	owned   []io.Closer
+}
+
+// Handler returns the middleware handler
+func (m *RateLimitMiddleware) Handler() gin.HandlerFunc {
+	return m.handler
+}
+
+// Close stops the limiters the middleware created; limiters passed in the
+// configuration are left to their owner
+func (m *RateLimitMiddleware) Close() error {
+	var errs []error
+	for _, c := range m.owned {
+		errs = append(errs, c.Close())
+	}
+	m.owned = nil
+	return errors.Join(errs...)
+}
+
+// RateLimit creates a rate limiting middleware. A limiter it creates, when
+// config.Limiter is nil, lives as long as the process; use NewRateLimit to
+// release it.
 func RateLimit(config RateLimitConfig) gin.HandlerFunc {
+	return NewRateLimit(config).Handler()
+}
+
+// NewRateLimit creates a rate limiting middleware; Close it when the router
+// is discarded
+func NewRateLimit(config RateLimitConfig) *RateLimitMiddleware {
 	if !config.Enabled {
-		return func(c *gin.Context) {
+		return &RateLimitMiddleware{handler: func(c *gin.Context) {
 			c.Next()
-		}
+		}}
 	}
 
+	m := &RateLimitMiddleware{}
+
 	// Create limiter
-	limiter := NewTokenBucketLimiter(
-		config.Rate,
-		config.Burst,
-		time.Minute,
-		5*time.Minute,
-	)
+	limiter := config.Limiter
+	if limiter == nil && config.Policies == nil {
+		bucketLimiter := NewTokenBucketLimiter(
+			config.Rate,
+			config.Burst,
+			time.Minute,
+			5*time.Minute,
+		)
+		m.owned = append(m.owned, bucketLimiter)
+		limiter = bucketLimiter
+	}
 
 	// Build skip map
 	skipMap := make(map[string]bool)
@@ -220,32 +413,43 @@ func RateLimit(config RateLimitConfig) gin.HandlerFunc {
 		skipMap[path] = true
 	}
 
-	return func(c *gin.Context) {
+	m.handler = func(c *gin.Context) {
 		// Check if should skip
 		if skipMap[c.Request.URL.Path] || (config.SkipFunc != nil && config.SkipFunc(c)) {
 			c.Next()
 			return
 		}
 
-		// Get rate limit key
-		key := config.KeyFunc(c)
+		// Check rate limit
+		var allowed bool
+		var info RateLimitInfo
+		var key, policy string
+		if config.Policies != nil {
+			decision := config.Policies.Check(RateLimitRequestFromGin(c))
+			allowed, info, key, policy = decision.Allowed, decision.Info, decision.Key, decision.Policy
+		} else if key = config.KeyFunc(c); key != "" {
+			allowed, info = limiter.Allow(key)
+		}
 		if key == "" {
 			c.Next()
 			return
 		}
 
-		// Check rate limit
-		allowed, info := limiter.Allow(key)
+		if policy == "" {
+			policy = defaultRateLimitPolicy
+		}
+		recordRateLimitDecision("http", policy, key, allowed)
 
 		// Set rate limit headers
-		c.Header("X-RateLimit-Limit", strconv.Itoa(info.Limit))
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(info.Remaining))
-		c.Header("X-RateLimit-Reset", strconv.FormatInt(info.ResetTime.Unix(), 10))
+		for _, h := range rateLimitHeaders(config.Headers, policy, info) {
+			c.Header(h[0], h[1])
+		}
 
 		if !allowed {
 			// Log rate limit exceeded
 			logger.Warn(c.Request.Context(), "Rate limit exceeded",
 				zap.String("key", key),
+				zap.String("policy", policy),
 				zap.String("path", c.Request.URL.Path),
 				zap.String("method", c.Request.Method),
 				zap.String("ip", c.ClientIP()),
@@ -267,50 +471,61 @@ func RateLimit(config RateLimitConfig) gin.HandlerFunc {
 
 		c.Next()
 	}
+	return m
 }
 
 // IPRateLimit creates a simple IP-based rate limiter
 func IPRateLimit(requestsPerMinute int) gin.HandlerFunc {
-	config := DefaultRateLimitConfig()
-	config.Rate = requestsPerMinute
-	config.Burst = min(requestsPerMinute/6, 10) // Allow 10% burst or 10, whichever is smaller
-	return RateLimit(config)
+	return RateLimit(RateLimitConfigForType("ip", requestsPerMinute))
 }
 
 // UserRateLimit creates a user-based rate limiter
 func UserRateLimit(requestsPerMinute int) gin.HandlerFunc {
-	config := DefaultRateLimitConfig()
-	config.Rate = requestsPerMinute
-	config.Burst = min(requestsPerMinute/6, 20) // Allow 10% burst or 20, whichever is smaller
-	config.KeyFunc = func(c *gin.Context) string {
-		// Try to get user ID from context or header
-		if userID := ExtractUserID(c); userID != "" {
-			return fmt.Sprintf("user:%s", userID)
-		}
-		// Fall back to IP
-		return c.ClientIP()
-	}
-	return RateLimit(config)
+	return RateLimit(RateLimitConfigForType("user", requestsPerMinute))
 }
 
 // APIKeyRateLimit creates an API key-based rate limiter
 func APIKeyRateLimit(requestsPerMinute int) gin.HandlerFunc {
+	return RateLimit(RateLimitConfigForType("api_key", requestsPerMinute))
+}
+
+// RateLimitConfigForType returns the rate limit configuration used by
+// IPRateLimit, UserRateLimit and APIKeyRateLimit for a rate limit type
+// ("ip", "user" or "api_key")
+func RateLimitConfigForType(rateLimitType string, requestsPerMinute int) RateLimitConfig {
 	config := DefaultRateLimitConfig()
 	config.Rate = requestsPerMinute
-	config.Burst = min(requestsPerMinute/6, 50) // Allow 10% burst or 50, whichever is smaller
-	config.KeyFunc = func(c *gin.Context) string {
-		// Try to get API key from header
-		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
-			return fmt.Sprintf("api:%s", apiKey)
+
+	switch rateLimitType {
+	case "user":
+		config.Burst = min(requestsPerMinute/6, 20) // Allow 10% burst or 20, whichever is smaller
+		config.KeyFunc = func(c *gin.Context) string {
+			// Try to get user ID from context or header
+			if userID := ExtractUserID(c); userID != "" {
+				return fmt.Sprintf("user:%s", userID)
+			}
+			// Fall back to IP
+			return c.ClientIP()
 		}
-		// Try Bearer token
-		if auth := c.GetHeader("Authorization"); len(auth) > 7 && auth[:7] == "Bearer " {
-			return fmt.Sprintf("bearer:%s", auth[7:])
+	case "api_key":
+		config.Burst = min(requestsPerMinute/6, 50) // Allow 10% burst or 50, whichever is smaller
+		config.KeyFunc = func(c *gin.Context) string {
+			// Try to get API key from header
+			if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+				return fmt.Sprintf("api:%s", apiKey)
+			}
+			// Try Bearer token
+			if auth := c.GetHeader("Authorization"); len(auth) > 7 && auth[:7] == "Bearer " {
+				return fmt.Sprintf("bearer:%s", auth[7:])
+			}
+			// Fall back to IP
+			return c.ClientIP()
 		}
-		// Fall back to IP
-		return c.ClientIP()
+	default: // "ip"
+		config.Burst = min(requestsPerMinute/6, 10) // Allow 10% burst or 10, whichever is smaller
 	}
-	return RateLimit(config)
+
+	return config
 }
 
 // SlidingWindowLimiter implements sliding window algorithm
@@ -321,6 +536,10 @@ type SlidingWindowLimiter struct {
 	window  time.Duration
 	ttl     time.Duration
 	cleanup time.Duration
+
+	closeOnce sync.Once
+	stop      chan struct{} // closed by Close
+	done      chan struct{} // closed when the cleanup goroutine exits
 }
 
 type slidingWindow struct {
@@ -328,7 +547,8 @@ type slidingWindow struct {
 	lastSeen time.Time
 }
 
-// NewSlidingWindowLimiter creates a new sliding window rate limiter
+// NewSlidingWindowLimiter creates a new sliding window rate limiter. Close
+// stops its cleanup goroutine.
 func NewSlidingWindowLimiter(limit int, window, ttl time.Duration) *SlidingWindowLimiter {
 	limiter := &SlidingWindowLimiter{
 		windows: make(map[string]*slidingWindow),
@@ -336,6 +556,8 @@ func NewSlidingWindowLimiter(limit int, window, ttl time.Duration) *SlidingWindo
 		window:  window,
 		ttl:     ttl,
 		cleanup: ttl / 2,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
 	}
 
 	// Start cleanup goroutine
@@ -364,6 +586,7 @@ func (l *SlidingWindowLimiter) Allow(key string) (bool, RateLimitInfo) {
 			Limit:     l.limit,
 			Remaining: l.limit - 1,
 			ResetTime: now.Add(l.window),
+			Window:    l.window,
 		}
 	}
 
@@ -385,6 +608,7 @@ func (l *SlidingWindowLimiter) Allow(key string) (bool, RateLimitInfo) {
 			Limit:     l.limit,
 			Remaining: l.limit - len(w.requests),
 			ResetTime: w.requests[0].Add(l.window),
+			Window:    l.window,
 		}
 	}
 
@@ -395,6 +619,7 @@ func (l *SlidingWindowLimiter) Allow(key string) (bool, RateLimitInfo) {
 		Limit:     l.limit,
 		Remaining: 0,
 		ResetTime: resetTime,
+		Window:    l.window,
 	}
 }
 
@@ -405,12 +630,52 @@ func (l *SlidingWindowLimiter) Reset(key string) {
 	delete(l.windows, key)
 }
 
-// cleanupRoutine periodically cleans up old windows
+// Snapshot returns a snapshot of every window, sorted by key
+func (l *SlidingWindowLimiter) Snapshot() []BucketState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := time.Now()
+	cutoff := now.Add(-l.window)
+	states := []BucketState{}
+	for key, w := range l.windows {
+		state := BucketState{Key: key, Tokens: l.limit, LastFill: w.lastSeen, ResetTime: now}
+		for _, req := range w.requests {
+			if req.After(cutoff) {
+				if state.Tokens == l.limit {
+					state.ResetTime = req.Add(l.window)
+				}
+				state.Tokens--
+			}
+		}
+		state.Tokens = max(state.Tokens, 0)
+		states = append(states, state)
+	}
+	sortBucketStates(states)
+	return states
+}
+
+// Close stops the cleanup goroutine; the limiter keeps working, but no
+// longer forgets inactive keys
+func (l *SlidingWindowLimiter) Close() error {
+	l.closeOnce.Do(func() { close(l.stop) })
+	<-l.done
+	return nil
+}
+
+// cleanupRoutine periodically cleans up old windows until Close
 func (l *SlidingWindowLimiter) cleanupRoutine() {
+	defer close(l.done)
 	ticker := time.NewTicker(l.cleanup)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.stop:
+			return
+		}
+
 		l.mu.Lock()
 		now := time.Now()
 		for key, w := range l.windows {
@@ -422,15 +687,16 @@ func (l *SlidingWindowLimiter) cleanupRoutine() {
 	}
 }
 
-// DistributedRateLimiter implements distributed rate limiting using Redis
-type DistributedRateLimiter struct {
-	// This would use Redis or another distributed store
-	// Implementation depends on your cache/redis package
-	// Placeholder for now
+// RateLimitByEndpoint creates per-endpoint rate limits. Its limiters live as
+// long as the process; use NewRateLimitByEndpoint to release them.
+func RateLimitByEndpoint(limits map[string]int) gin.HandlerFunc {
+	return NewRateLimitByEndpoint(limits).Handler()
 }
 
-// RateLimitByEndpoint creates per-endpoint rate limits
-func RateLimitByEndpoint(limits map[string]int) gin.HandlerFunc {
+// NewRateLimitByEndpoint creates per-endpoint rate limits; Close it when the
+// router is discarded
+func NewRateLimitByEndpoint(limits map[string]int) *RateLimitMiddleware {
+	m := &RateLimitMiddleware{}
 	limiters := make(map[string]*TokenBucketLimiter)
 
 	for endpoint, limit := range limits {
@@ -440,11 +706,13 @@ func RateLimitByEndpoint(limits map[string]int) gin.HandlerFunc {
 			time.Minute,
 			5*time.Minute,
 		)
+		m.owned = append(m.owned, limiters[endpoint])
 	}
 
 	defaultLimiter := NewTokenBucketLimiter(60, 10, time.Minute, 5*time.Minute)
+	m.owned = append(m.owned, defaultLimiter)
 
-	return func(c *gin.Context) {
+	m.handler = func(c *gin.Context) {
 		path := c.FullPath()
 		if path == "" {
 			path = c.Request.URL.Path
@@ -463,9 +731,9 @@ func RateLimitByEndpoint(limits map[string]int) gin.HandlerFunc {
 		allowed, info := limiter.Allow(key)
 
 		// Set headers
-		c.Header("X-RateLimit-Limit", strconv.Itoa(info.Limit))
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(info.Remaining))
-		c.Header("X-RateLimit-Reset", strconv.FormatInt(info.ResetTime.Unix(), 10))
+		for _, h := range rateLimitHeaders(RateLimitHeadersLegacy, path, info) {
+			c.Header(h[0], h[1])
+		}
 
 		if !allowed {
 			logger.Warn(context.Background(), "Rate limit exceeded",
@@ -479,4 +747,5 @@ func RateLimitByEndpoint(limits map[string]int) gin.HandlerFunc {
 
 		c.Next()
 	}
+	return m
 }
@@ -0,0 +1,179 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRateLimitRedis returns the Redis client keeping the rate limit counters,
+// or nil unless the store is Redis. It also returns nil, leaving each replica
+// to limit requests on its own, if the Redis URL cannot be parsed. One client
+// serves both servers; close it once they are stopped.
+func NewRateLimitRedis(cfg *config.Config) *redis.Client {
+	if !cfg.Middleware.RateLimitEnabled || cfg.Middleware.RateLimitStore != "redis" {
+		return nil
+	}
+
+	url, _ := cfg.GetRedisURL()
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		logger.Error(context.Background(), "Invalid Redis URL, rate limiting each replica separately", err)
+		return nil
+	}
+	return redis.NewClient(opts)
+}
+
+// RateLimiters enforce the rate limits of one transport: the global limit, in
+// memory or in Redis with the in-memory limiter as fallback while Redis is
+// down, and the configured policies and plans
+type RateLimiters struct {
+	local       *TokenBucketLimiter
+	distributed *DistributedRateLimiter // nil without a Redis client
+	policies    *RateLimitPolicies      // nil when the policies are invalid
+}
+
+// NewRateLimiters creates the rate limiters from the middleware configuration,
+// keeping the counters in Redis under prefix when client is not nil. Invalid
+// policies are logged and only the global limit applied. Close stops them;
+// the client is left to its owner.
+func NewRateLimiters(cfg config.MiddlewareConfig, client *redis.Client, prefix string) *RateLimiters {
+	r := &RateLimiters{
+		local: NewTokenBucketLimiter(cfg.RateLimitRate, cfg.RateLimitBurst, time.Minute, 5*time.Minute),
+	}
+	if client != nil {
+		limiterConfig := DefaultDistributedRateLimitConfig()
+		limiterConfig.Client = client
+		limiterConfig.Prefix = prefix + "global:"
+		limiterConfig.Algorithm = cfg.RateLimitAlgorithm
+		limiterConfig.Rate = cfg.RateLimitRate
+		limiterConfig.Burst = cfg.RateLimitBurst
+		limiterConfig.FailureMode = cfg.RateLimitFailureMode
+		limiterConfig.Fallback = r.local
+		r.distributed = NewDistributedRateLimiter(limiterConfig)
+	}
+
+	policies, err := NewRateLimitPoliciesFromConfig(cfg, r.Limiter(), client, prefix)
+	if err != nil {
+		logger.Error(context.Background(), "Invalid rate limit policies, applying the global limit only", err)
+	} else {
+		r.policies = policies
+	}
+	return r
+}
+
+// Limiter returns the limiter enforcing the global limit
+func (r *RateLimiters) Limiter() RateLimiter {
+	if r.distributed != nil {
+		return r.distributed
+	}
+	return r.local
+}
+
+// Policies returns the global limit together with the configured policies
+// and plans, or nil when the policies are invalid
+func (r *RateLimiters) Policies() *RateLimitPolicies {
+	return r.policies
+}
+
+// Reset resets the rate limits for a key, in Redis and in memory
+func (r *RateLimiters) Reset(key string) {
+	if r.policies != nil {
+		r.policies.Reset(key)
+		return
+	}
+	r.Limiter().Reset(key)
+}
+
+// Snapshot returns the rate limit buckets, read from Redis when it keeps
+// the counters
+func (r *RateLimiters) Snapshot() []BucketState {
+	switch {
+	case r.policies != nil:
+		return r.policies.Snapshot()
+	case r.distributed != nil:
+		return r.distributed.Snapshot()
+	}
+	return r.local.Snapshot()
+}
+
+// SetLimits changes the global rate and burst
+func (r *RateLimiters) SetLimits(rate, burst int) {
+	r.local.SetLimits(rate, burst)
+	if r.distributed != nil {
+		r.distributed.SetLimits(rate, burst)
+	}
+}
+
+// Close stops the limiters' cleanup goroutines. It is safe to call more than once.
+func (r *RateLimiters) Close() error {
+	var errs []error
+	if r.policies != nil {
+		errs = append(errs, r.policies.Close())
+	}
+	if r.distributed != nil {
+		errs = append(errs, r.distributed.Close())
+	}
+	errs = append(errs, r.local.Close())
+	return errors.Join(errs...)
+}
+
+// NewRateLimitPoliciesFromConfig combines the global limit, kept by global,
+// with the configured policies and plans. Their counters are kept in Redis
+// under prefix + "policy:" when client is not nil.
+func NewRateLimitPoliciesFromConfig(cfg config.MiddlewareConfig, global RateLimiter, client *redis.Client, prefix string) (*RateLimitPolicies, error) {
+	policies := []RateLimitPolicy{{
+		Name:     "global",
+		Identity: cfg.RateLimitType,
+		Limiter:  global,
+	}}
+	for _, policy := range cfg.RateLimitPolicies {
+		policies = append(policies, RateLimitPolicy{
+			Name:      policy.Name,
+			Routes:    policy.Routes,
+			Methods:   policy.Methods,
+			Identity:  policy.Identity,
+			Algorithm: policy.Algorithm,
+			Rate:      policy.Rate,
+			Burst:     policy.Burst,
+			Window:    policy.Window,
+		})
+	}
+
+	plans := make(map[string]RateLimitPlan, len(cfg.RateLimitPlans))
+	for name, plan := range cfg.RateLimitPlans {
+		plans[name] = RateLimitPlan{
+			Algorithm: plan.Algorithm,
+			Rate:      plan.Rate,
+			Burst:     plan.Burst,
+			Window:    plan.Window,
+		}
+	}
+	// API key plans are checked by Validate
+	apiKeyPlans, _ := cfg.RateLimitAPIKeyPlanMap()
+
+	policiesConfig := RateLimitPoliciesConfig{
+		Policies:    policies,
+		Plans:       plans,
+		APIKeyPlans: apiKeyPlans,
+	}
+	if client != nil {
+		policiesConfig.NewLimiter = func(policy RateLimitPolicy) RateLimiter {
+			limiterConfig := DefaultDistributedRateLimitConfig()
+			limiterConfig.Client = client
+			limiterConfig.Prefix = prefix + "policy:" + policy.Name + ":"
+			limiterConfig.Algorithm = policy.Algorithm
+			limiterConfig.Rate = policy.Rate
+			limiterConfig.Burst = policy.Burst
+			limiterConfig.Interval = policy.Window
+			limiterConfig.FailureMode = cfg.RateLimitFailureMode
+			return NewDistributedRateLimiter(limiterConfig)
+		}
+	}
+	return NewRateLimitPolicies(policiesConfig)
+}
@@ -0,0 +1,87 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+)
+
+// Rate limit header formats
+const (
+	// RateLimitHeadersLegacy sends X-RateLimit-Limit, X-RateLimit-Remaining
+	// and X-RateLimit-Reset (a Unix time)
+	RateLimitHeadersLegacy = "legacy"
+	// RateLimitHeadersIETF sends the RateLimit and RateLimit-Policy structured
+	// fields of the IETF draft (draft-ietf-httpapi-ratelimit-headers)
+	RateLimitHeadersIETF = "ietf"
+	// RateLimitHeadersBoth sends both formats
+	RateLimitHeadersBoth = "both"
+)
+
+// defaultRateLimitPolicy names the limit of a middleware without policies
+const defaultRateLimitPolicy = "default"
+
+// rateLimitHeaders returns the header fields describing a limiter result in
+// format, "" meaning RateLimitHeadersLegacy. The IETF fields look like
+//
+//	RateLimit: "default";r=5;t=30
+//	RateLimit-Policy: "default";q=60;w=60
+func rateLimitHeaders(format, policy string, info RateLimitInfo) [][2]string {
+	var fields [][2]string
+	if format != RateLimitHeadersIETF {
+		fields = append(fields,
+			[2]string{"X-RateLimit-Limit", strconv.Itoa(info.Limit)},
+			[2]string{"X-RateLimit-Remaining", strconv.Itoa(info.Remaining)},
+			[2]string{"X-RateLimit-Reset", strconv.FormatInt(info.ResetTime.Unix(), 10)},
+		)
+	}
+	if format == RateLimitHeadersIETF || format == RateLimitHeadersBoth {
+		name := strconv.Quote(policy)
+		fields = append(fields, [2]string{"RateLimit",
+			name + ";r=" + strconv.Itoa(max(info.Remaining, 0)) + ";t=" + strconv.Itoa(secondsUntil(info.ResetTime))})
+		if info.Window > 0 {
+			fields = append(fields, [2]string{"RateLimit-Policy",
+				name + ";q=" + strconv.Itoa(info.Limit) + ";w=" + strconv.Itoa(int(math.Ceil(info.Window.Seconds())))})
+		}
+	}
+	return fields
+}
+
+// secondsUntil returns the whole seconds until t, rounded up and never negative
+func secondsUntil(t time.Time) int {
+	return max(int(math.Ceil(time.Until(t).Seconds())), 0)
+}
+
+// retryAfterSeconds returns the Retry-After value for a rejected request:
+// the seconds until the next request is allowed, at least one
+func retryAfterSeconds(info RateLimitInfo) int {
+	return max(secondsUntil(info.ResetTime), 1)
+}
+
+// rateLimitKeyType returns the kind of client a rate limit key identifies:
+// "user", "api_key", "tenant" or "ip"
+func rateLimitKeyType(key string) string {
+	prefix, _, ok := strings.Cut(key, ":")
+	if !ok {
+		return IdentityIP
+	}
+	switch prefix {
+	case "user":
+		return IdentityUser
+	case "api", "bearer":
+		return IdentityAPIKey
+	case "tenant":
+		return IdentityTenant
+	}
+	// IPv6 addresses contain colons too
+	return IdentityIP
+}
+
+// recordRateLimitDecision counts a rate limit decision by policy and key type
+func recordRateLimitDecision(transport, policy, key string, allowed bool) {
+	metrics.RecordRateLimitDecision(transport, policy, rateLimitKeyType(key), allowed)
+}
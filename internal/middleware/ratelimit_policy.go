@@ -0,0 +1,419 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Identities a rate limit policy keeps its budget per
+const (
+	IdentityIP     = "ip"
+	IdentityUser   = "user"
+	IdentityAPIKey = "api_key"
+	IdentityTenant = "tenant"
+)
+
+// RateLimitPolicy is a rate limit applied to the requests it matches
+type RateLimitPolicy struct {
+	// Name identifies the policy in logs and limiter keys
+	Name string
+	// Routes lists the HTTP paths or routes, as registered (e.g.
+	// "/api/v1/examples/:id"), or gRPC full methods the policy applies to;
+	// a trailing "*" matches a prefix. Empty matches every route.
+	Routes []string
+	// Methods lists the HTTP methods the policy applies to; empty matches
+	// every method. Policies listing methods never match gRPC calls.
+	Methods []string
+	// Identity is what the budget is kept per: IdentityIP (default),
+	// IdentityUser, IdentityAPIKey or IdentityTenant. Requests without the
+	// identity are limited per IP.
+	Identity string
+	// Algorithm is RateLimitTokenBucket (default) or RateLimitSlidingWindow
+	Algorithm string
+	// Rate is the number of requests per Window
+	Rate int
+	// Burst is the token bucket size; defaults to Rate
+	Burst int
+	// Window is the period Rate applies to; defaults to a minute
+	Window time.Duration
+	// Limiter is the limiter to use; when nil one is created with the
+	// configuration's NewLimiter
+	Limiter RateLimiter
+}
+
+// RateLimitPlan is a quota shared by all requests made with one API key on the plan
+type RateLimitPlan struct {
+	// Algorithm is RateLimitTokenBucket (default) or RateLimitSlidingWindow
+	Algorithm string
+	// Rate is the number of requests per Window
+	Rate int
+	// Burst is the token bucket size; defaults to Rate
+	Burst int
+	// Window is the period Rate applies to; defaults to a minute
+	Window time.Duration
+}
+
+// RateLimitPoliciesConfig provides configuration for policy-based rate limiting
+type RateLimitPoliciesConfig struct {
+	// Policies are evaluated together; the most restrictive one wins
+	Policies []RateLimitPolicy
+	// Plans are quotas by plan name
+	Plans map[string]RateLimitPlan
+	// APIKeyPlans maps API keys, sent as X-API-Key or bearer tokens, to plan names
+	APIKeyPlans map[string]string
+	// NewLimiter creates the limiter of a policy, or of a plan as a policy
+	// named "plan:<name>"; when nil NewPolicyLimiter is used
+	NewLimiter func(policy RateLimitPolicy) RateLimiter
+}
+
+// RateLimitIdentity identifies the client making a request
+type RateLimitIdentity struct {
+	IP          string
+	UserID      string
+	APIKey      string
+	BearerToken string
+	TenantID    string
+}
+
+// RateLimitRequest describes a request checked against the policies
+type RateLimitRequest struct {
+	// Route is the HTTP route, as registered, or gRPC full method
+	Route string
+	// Path is the HTTP request path, matched as well as Route
+	Path string
+	// Method is the HTTP method, "" for gRPC calls
+	Method   string
+	Identity RateLimitIdentity
+}
+
+// RateLimitDecision is the outcome of the most restrictive policy matching a request
+type RateLimitDecision struct {
+	Allowed bool
+	// Policy is the name of the deciding policy, "" if none matched
+	Policy string
+	// Key is the limiter key of the request under the deciding policy
+	Key  string
+	Info RateLimitInfo
+}
+
+// RateLimitPolicies limits requests by the policies and plans they match
+type RateLimitPolicies struct {
+	policies    []*ratePolicy
+	plans       map[string]*ratePolicy
+	apiKeyPlans map[string]string
+	owned       []io.Closer
+}
+
+// ratePolicy is a policy with its matchers and limiter
+type ratePolicy struct {
+	name     string
+	identity string
+	exact    map[string]bool
+	prefixes []string
+	methods  map[string]bool
+	limiter  RateLimiter
+}
+
+// NewRateLimitPolicies creates the limiters of the policies and plans;
+// Close stops those it created
+func NewRateLimitPolicies(config RateLimitPoliciesConfig) (*RateLimitPolicies, error) {
+	if config.NewLimiter == nil {
+		config.NewLimiter = NewPolicyLimiter
+	}
+
+	p := &RateLimitPolicies{
+		plans:       make(map[string]*ratePolicy, len(config.Plans)),
+		apiKeyPlans: config.APIKeyPlans,
+	}
+
+	names := make(map[string]bool)
+	for _, policy := range config.Policies {
+		if policy.Name == "" {
+			return nil, errors.New("rate limit policy name is required")
+		}
+		if names[policy.Name] {
+			return nil, fmt.Errorf("duplicate rate limit policy %q", policy.Name)
+		}
+		names[policy.Name] = true
+
+		compiled, err := p.compile(policy, config.NewLimiter)
+		if err != nil {
+			return nil, errors.Join(err, p.Close())
+		}
+		p.policies = append(p.policies, compiled)
+	}
+
+	for name, plan := range config.Plans {
+		compiled, err := p.compile(RateLimitPolicy{
+			Name:      "plan:" + name,
+			Identity:  IdentityAPIKey,
+			Algorithm: plan.Algorithm,
+			Rate:      plan.Rate,
+			Burst:     plan.Burst,
+			Window:    plan.Window,
+		}, config.NewLimiter)
+		if err != nil {
+			return nil, errors.Join(err, p.Close())
+		}
+		p.plans[name] = compiled
+	}
+
+	for _, plan := range config.APIKeyPlans {
+		if _, ok := p.plans[plan]; !ok {
+			return nil, errors.Join(fmt.Errorf("unknown rate limit plan %q", plan), p.Close())
+		}
+	}
+
+	return p, nil
+}
+
+// compile applies the policy defaults, checks it and creates its limiter
+func (p *RateLimitPolicies) compile(policy RateLimitPolicy, newLimiter func(RateLimitPolicy) RateLimiter) (*ratePolicy, error) {
+	if policy.Identity == "" {
+		policy.Identity = IdentityIP
+	}
+	if policy.Algorithm == "" {
+		policy.Algorithm = RateLimitTokenBucket
+	}
+	if policy.Burst <= 0 {
+		policy.Burst = policy.Rate
+	}
+	if policy.Window <= 0 {
+		policy.Window = time.Minute
+	}
+
+	switch {
+	case policy.Identity != IdentityIP && policy.Identity != IdentityUser &&
+		policy.Identity != IdentityAPIKey && policy.Identity != IdentityTenant:
+		return nil, fmt.Errorf("rate limit policy %q: invalid identity %q", policy.Name, policy.Identity)
+	case policy.Algorithm != RateLimitTokenBucket && policy.Algorithm != RateLimitSlidingWindow:
+		return nil, fmt.Errorf("rate limit policy %q: invalid algorithm %q", policy.Name, policy.Algorithm)
+	case policy.Rate <= 0 && policy.Limiter == nil:
+		return nil, fmt.Errorf("rate limit policy %q: rate must be positive", policy.Name)
+	}
+
+	compiled := &ratePolicy{
+		name:     policy.Name,
+		identity: policy.Identity,
+		exact:    make(map[string]bool),
+		limiter:  policy.Limiter,
+	}
+	for _, route := range policy.Routes {
+		if prefix, ok := strings.CutSuffix(route, "*"); ok {
+			compiled.prefixes = append(compiled.prefixes, prefix)
+		} else {
+			compiled.exact[route] = true
+		}
+	}
+	if len(policy.Methods) > 0 {
+		compiled.methods = make(map[string]bool, len(policy.Methods))
+		for _, method := range policy.Methods {
+			compiled.methods[strings.ToUpper(method)] = true
+		}
+	}
+
+	if compiled.limiter == nil {
+		compiled.limiter = newLimiter(policy)
+		if closer, ok := compiled.limiter.(io.Closer); ok {
+			p.owned = append(p.owned, closer)
+		}
+	}
+	return compiled, nil
+}
+
+// NewPolicyLimiter creates an in-memory limiter for a policy
+func NewPolicyLimiter(policy RateLimitPolicy) RateLimiter {
+	if policy.Algorithm == RateLimitSlidingWindow {
+		return NewSlidingWindowLimiter(policy.Rate, policy.Window, 5*policy.Window)
+	}
+	return NewTokenBucketLimiter(policy.Rate, policy.Burst, policy.Window, 5*policy.Window)
+}
+
+// Check counts the request against every policy and plan it matches, and
+// returns the decision of the most restrictive: a rejection over an
+// allowance, the longest wait over shorter ones, and the fewest remaining
+// requests otherwise
+func (p *RateLimitPolicies) Check(req RateLimitRequest) RateLimitDecision {
+	decision := RateLimitDecision{Allowed: true}
+
+	apply := func(policy *ratePolicy) {
+		key := identityKey(policy.identity, req.Identity)
+		if key == "" {
+			return
+		}
+		allowed, info := policy.limiter.Allow(key)
+		if decision.Policy == "" || moreRestrictive(allowed, info, decision) {
+			decision = RateLimitDecision{Allowed: allowed, Policy: policy.name, Key: key, Info: info}
+		}
+	}
+
+	for _, policy := range p.policies {
+		if policy.matches(req) {
+			apply(policy)
+		}
+	}
+	if plan := p.planFor(req.Identity); plan != nil {
+		apply(plan)
+	}
+	return decision
+}
+
+// moreRestrictive reports whether a limiter result is more restrictive than decision
+func moreRestrictive(allowed bool, info RateLimitInfo, decision RateLimitDecision) bool {
+	if allowed != decision.Allowed {
+		return !allowed
+	}
+	if !allowed {
+		return info.ResetTime.After(decision.Info.ResetTime)
+	}
+	return info.Remaining < decision.Info.Remaining
+}
+
+// planFor returns the plan of the request's API key, or nil
+func (p *RateLimitPolicies) planFor(identity RateLimitIdentity) *ratePolicy {
+	for _, key := range []string{identity.APIKey, identity.BearerToken} {
+		if key == "" {
+			continue
+		}
+		if plan, ok := p.apiKeyPlans[key]; ok {
+			return p.plans[plan]
+		}
+	}
+	return nil
+}
+
+// matches reports whether the policy applies to a request
+func (p *ratePolicy) matches(req RateLimitRequest) bool {
+	if p.methods != nil && !p.methods[req.Method] {
+		return false
+	}
+	if len(p.exact) == 0 && len(p.prefixes) == 0 {
+		return true
+	}
+	return p.matchesRoute(req.Route) || (req.Path != "" && p.matchesRoute(req.Path))
+}
+
+// matchesRoute reports whether a route or path is one of the policy's routes
+func (p *ratePolicy) matchesRoute(route string) bool {
+	if p.exact[route] {
+		return true
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(route, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// identityKey returns the limiter key of an identity, falling back to the IP
+func identityKey(identity string, id RateLimitIdentity) string {
+	switch identity {
+	case IdentityUser:
+		if id.UserID != "" {
+			return fmt.Sprintf("user:%s", id.UserID)
+		}
+	case IdentityAPIKey:
+		if id.APIKey != "" {
+			return fmt.Sprintf("api:%s", id.APIKey)
+		}
+		if id.BearerToken != "" {
+			return fmt.Sprintf("bearer:%s", id.BearerToken)
+		}
+	case IdentityTenant:
+		if id.TenantID != "" {
+			return fmt.Sprintf("tenant:%s", id.TenantID)
+		}
+	}
+	return id.IP
+}
+
+// Reset resets the rate limit for a key under every policy and plan
+func (p *RateLimitPolicies) Reset(key string) {
+	for _, policy := range p.all() {
+		policy.limiter.Reset(key)
+	}
+}
+
+// Snapshot returns the buckets of every policy and plan, sorted by key then
+// policy; limiters that cannot list their buckets are left out
+func (p *RateLimitPolicies) Snapshot() []BucketState {
+	states := []BucketState{}
+	for _, policy := range p.all() {
+		limiter, ok := policy.limiter.(InspectableRateLimiter)
+		if !ok {
+			continue
+		}
+		for _, state := range limiter.Snapshot() {
+			state.Policy = policy.name
+			states = append(states, state)
+		}
+	}
+	sortBucketStates(states)
+	return states
+}
+
+// all returns the policies followed by the plans
+func (p *RateLimitPolicies) all() []*ratePolicy {
+	all := append([]*ratePolicy(nil), p.policies...)
+	for _, plan := range p.plans {
+		all = append(all, plan)
+	}
+	return all
+}
+
+// Close stops the limiters the policies created; limiters passed in a
+// policy are left to their owner
+func (p *RateLimitPolicies) Close() error {
+	var errs []error
+	for _, c := range p.owned {
+		errs = append(errs, c.Close())
+	}
+	p.owned = nil
+	return errors.Join(errs...)
+}
+
+// RateLimitRequestFromGin describes an HTTP request for the rate limit policies
+func RateLimitRequestFromGin(c *gin.Context) RateLimitRequest {
+	identity := RateLimitIdentity{
+		IP:       c.ClientIP(),
+		UserID:   ExtractUserID(c),
+		APIKey:   c.GetHeader("X-API-Key"),
+		TenantID: c.GetString("tenant_id"),
+	}
+	if identity.TenantID == "" {
+		identity.TenantID = c.GetHeader(HeaderTenantID)
+	}
+	if auth := c.GetHeader("Authorization"); len(auth) > 7 && auth[:7] == "Bearer " {
+		identity.BearerToken = auth[7:]
+	}
+
+	return RateLimitRequest{
+		Route:    c.FullPath(),
+		Path:     c.Request.URL.Path,
+		Method:   c.Request.Method,
+		Identity: identity,
+	}
+}
+
+// RateLimitRequestFromGRPC describes a gRPC call for the rate limit policies
+func RateLimitRequestFromGRPC(ctx context.Context, fullMethod string) RateLimitRequest {
+	identity := RateLimitIdentity{
+		IP:       PeerIP(ctx),
+		UserID:   metadataValue(ctx, strings.ToLower(HeaderUserID)),
+		APIKey:   metadataValue(ctx, "x-api-key"),
+		TenantID: metadataValue(ctx, strings.ToLower(HeaderTenantID)),
+	}
+	if auth := metadataValue(ctx, "authorization"); len(auth) > 7 && auth[:7] == "Bearer " {
+		identity.BearerToken = auth[7:]
+	}
+
+	return RateLimitRequest{Route: fullMethod, Identity: identity}
+}
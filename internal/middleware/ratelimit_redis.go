@@ -0,0 +1,379 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Distributed rate limiting algorithms
+const (
+	RateLimitTokenBucket   = "token_bucket"
+	RateLimitSlidingWindow = "sliding_window"
+)
+
+// Behaviours of the distributed rate limiter while Redis is unavailable
+const (
+	// FailLocal limits each replica on its own with the fallback limiter
+	FailLocal = "local"
+	// FailOpen allows every request
+	FailOpen = "open"
+	// FailClosed rejects every request
+	FailClosed = "closed"
+)
+
+// tokenBucketScript refills and takes a token from the bucket in KEYS[1].
+// Time comes from the Redis server so every replica shares one clock.
+// Returns {allowed, remaining, milliseconds until reset}.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local interval = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / interval)
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+local full = math.ceil((burst - tokens) * interval / rate)
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.max(full, 1))
+
+local reset = full
+if allowed == 0 then
+  reset = math.ceil((1 - tokens) * interval / rate)
+end
+return {allowed, math.floor(tokens), reset}
+`)
+
+// slidingWindowScript records a request in the sorted set in KEYS[1] if fewer
+// than the limit were made in the window. ARGV[3] is a unique member.
+// Returns {allowed, remaining, milliseconds until reset}.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+if count < limit then
+  redis.call('ZADD', KEYS[1], now, ARGV[3])
+  count = count + 1
+  allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+  reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+// DistributedRateLimitConfig provides configuration for the distributed rate limiter
+type DistributedRateLimitConfig struct {
+	// Client is the Redis client storing the counters
+	Client redis.UniversalClient
+	// Prefix is prepended to every rate limit key
+	Prefix string
+	// Algorithm is RateLimitTokenBucket (default) or RateLimitSlidingWindow
+	Algorithm string
+	// Rate is the number of requests per Interval
+	Rate int
+	// Burst is the token bucket size; unused by the sliding window
+	Burst int
+	// Interval is the period Rate applies to, and the sliding window size
+	Interval time.Duration
+	// Timeout bounds each Redis call
+	Timeout time.Duration
+	// RetryInterval is how long Redis is bypassed after a failed call
+	RetryInterval time.Duration
+	// FailureMode is FailLocal (default), FailOpen or FailClosed
+	FailureMode string
+	// Fallback limits requests while Redis is unavailable in FailLocal mode;
+	// when nil a token bucket limiter is created from Rate and Burst, and
+	// stopped by Close
+	Fallback RateLimiter
+}
+
+// DefaultDistributedRateLimitConfig returns the default distributed rate
+// limit configuration; Client must be set
+func DefaultDistributedRateLimitConfig() DistributedRateLimitConfig {
+	return DistributedRateLimitConfig{
+		Prefix:        "ratelimit:",
+		Algorithm:     RateLimitTokenBucket,
+		Rate:          60,
+		Burst:         10,
+		Interval:      time.Minute,
+		Timeout:       100 * time.Millisecond,
+		RetryInterval: time.Second,
+		FailureMode:   FailLocal,
+	}
+}
+
+// DistributedRateLimiter enforces one rate limit across every replica by
+// keeping the counters in Redis, updated atomically by Lua scripts
+type DistributedRateLimiter struct {
+	config        DistributedRateLimitConfig
+	ownedFallback *TokenBucketLimiter // created by the limiter, nil if passed in
+
+	mu    sync.RWMutex
+	rate  int
+	burst int
+
+	seq       atomic.Uint64 // makes sliding window members unique
+	downUntil atomic.Int64  // unix nanoseconds until Redis is retried
+	down      atomic.Bool   // whether the last Redis call failed
+}
+
+// NewDistributedRateLimiter creates a Redis-backed rate limiter
+func NewDistributedRateLimiter(config DistributedRateLimitConfig) *DistributedRateLimiter {
+	defaults := DefaultDistributedRateLimitConfig()
+	if config.Algorithm == "" {
+		config.Algorithm = defaults.Algorithm
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaults.RetryInterval
+	}
+	if config.FailureMode == "" {
+		config.FailureMode = defaults.FailureMode
+	}
+	var owned *TokenBucketLimiter
+	if config.Fallback == nil && config.FailureMode == FailLocal {
+		owned = NewTokenBucketLimiter(config.Rate, config.Burst, config.Interval, 5*config.Interval)
+		config.Fallback = owned
+	}
+
+	return &DistributedRateLimiter{
+		config:        config,
+		ownedFallback: owned,
+		rate:          config.Rate,
+		burst:         config.Burst,
+	}
+}
+
+// Allow checks if request is allowed, applying the failure mode when Redis
+// cannot be reached
+func (l *DistributedRateLimiter) Allow(key string) (bool, RateLimitInfo) {
+	l.mu.RLock()
+	rate, burst := l.rate, l.burst
+	l.mu.RUnlock()
+
+	if time.Now().UnixNano() >= l.downUntil.Load() {
+		allowed, info, err := l.allow(key, rate, burst)
+		if err == nil {
+			if l.down.Swap(false) {
+				logger.Info(context.Background(), "Rate limit store recovered")
+			}
+			return allowed, info
+		}
+
+		l.downUntil.Store(time.Now().Add(l.config.RetryInterval).UnixNano())
+		if !l.down.Swap(true) {
+			logger.Warn(context.Background(), "Rate limit store unavailable",
+				zap.Error(err),
+				zap.String("failure_mode", l.config.FailureMode),
+			)
+		}
+	}
+
+	switch l.config.FailureMode {
+	case FailOpen:
+		return true, RateLimitInfo{Limit: rate, Remaining: rate, ResetTime: time.Now().Add(l.config.Interval), Window: l.config.Interval}
+	case FailClosed:
+		return false, RateLimitInfo{Limit: rate, Remaining: 0, ResetTime: time.Now().Add(l.config.RetryInterval), Window: l.config.Interval}
+	default:
+		return l.config.Fallback.Allow(key)
+	}
+}
+
+// allow runs the configured algorithm's script for key
+func (l *DistributedRateLimiter) allow(key string, rate, burst int) (bool, RateLimitInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.config.Timeout)
+	defer cancel()
+
+	interval := l.config.Interval.Milliseconds()
+	rate = max(rate, 1)
+
+	var result []int64
+	var err error
+	switch l.config.Algorithm {
+	case RateLimitSlidingWindow:
+		member := strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatUint(l.seq.Add(1), 36)
+		result, err = slidingWindowScript.Run(ctx, l.config.Client, []string{l.config.Prefix + key},
+			rate, interval, member).Int64Slice()
+	default:
+		result, err = tokenBucketScript.Run(ctx, l.config.Client, []string{l.config.Prefix + key},
+			rate, max(burst, 1), interval).Int64Slice()
+	}
+	if err != nil {
+		return false, RateLimitInfo{}, err
+	}
+	if len(result) != 3 {
+		return false, RateLimitInfo{}, errors.New("unexpected rate limit script result")
+	}
+
+	return result[0] == 1, RateLimitInfo{
+		Limit:     rate,
+		Remaining: int(result[1]),
+		ResetTime: time.Now().Add(time.Duration(result[2]) * time.Millisecond),
+		Window:    l.config.Interval,
+	}, nil
+}
+
+// SetLimits changes the rate and burst applied to all keys, and to the fallback limiter
+func (l *DistributedRateLimiter) SetLimits(rate, burst int) {
+	l.mu.Lock()
+	l.rate = rate
+	l.burst = burst
+	l.mu.Unlock()
+
+	if fallback, ok := l.config.Fallback.(interface{ SetLimits(rate, burst int) }); ok {
+		fallback.SetLimits(rate, burst)
+	}
+}
+
+// Reset resets the rate limit for a key, in Redis and the fallback limiter
+func (l *DistributedRateLimiter) Reset(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.config.Timeout)
+	defer cancel()
+
+	if err := l.config.Client.Del(ctx, l.config.Prefix+key).Err(); err != nil {
+		logger.Warn(ctx, "Failed to reset distributed rate limit",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+	}
+	if l.config.Fallback != nil {
+		l.config.Fallback.Reset(key)
+	}
+}
+
+// snapshotTimeout bounds listing the keys in Redis, which takes several calls
+const snapshotTimeout = 5 * time.Second
+
+// redisPatternEscaper escapes the glob characters of a Redis SCAN pattern
+var redisPatternEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// Snapshot returns the state of every key in Redis, sorted by key, or the
+// fallback limiter's while Redis is unavailable
+func (l *DistributedRateLimiter) Snapshot() []BucketState {
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+
+	states, err := l.snapshot(ctx)
+	if err == nil {
+		return states
+	}
+	logger.Warn(ctx, "Failed to list distributed rate limits", zap.Error(err))
+	if fallback, ok := l.config.Fallback.(InspectableRateLimiter); ok {
+		return fallback.Snapshot()
+	}
+	return []BucketState{}
+}
+
+// snapshot reads the state of every key under the prefix, timed by the Redis clock
+func (l *DistributedRateLimiter) snapshot(ctx context.Context) ([]BucketState, error) {
+	l.mu.RLock()
+	rate, burst := max(l.rate, 1), max(l.burst, 1)
+	l.mu.RUnlock()
+
+	now, err := l.config.Client.Time(ctx).Result()
+	if err != nil {
+		return nil, err
+	}
+	interval := l.config.Interval
+
+	states := []BucketState{}
+	iter := l.config.Client.Scan(ctx, 0, redisPatternEscaper.Replace(l.config.Prefix)+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		state := BucketState{Key: strings.TrimPrefix(key, l.config.Prefix)}
+
+		switch l.config.Algorithm {
+		case RateLimitSlidingWindow:
+			requests, err := l.config.Client.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+				Min: "(" + strconv.FormatInt(now.Add(-interval).UnixMilli(), 10),
+				Max: "+inf",
+			}).Result()
+			if err != nil {
+				return nil, err
+			}
+			if len(requests) == 0 {
+				continue
+			}
+			state.Tokens = max(rate-len(requests), 0)
+			state.LastFill = time.UnixMilli(int64(requests[len(requests)-1].Score))
+			state.ResetTime = time.UnixMilli(int64(requests[0].Score)).Add(interval)
+		default:
+			values, err := l.config.Client.HMGet(ctx, key, "tokens", "ts").Result()
+			if err != nil {
+				return nil, err
+			}
+			tokensValue, ok1 := values[0].(string)
+			tsValue, ok2 := values[1].(string)
+			if !ok1 || !ok2 {
+				continue // expired since the scan
+			}
+			tokens, _ := strconv.ParseFloat(tokensValue, 64)
+			ts, _ := strconv.ParseInt(tsValue, 10, 64)
+			lastFill := time.UnixMilli(ts)
+
+			// Refill as the script does, up to the burst
+			elapsed := max(now.Sub(lastFill), 0)
+			tokens = math.Min(float64(burst), tokens+float64(elapsed)*float64(rate)/float64(interval))
+			state.Tokens = int(tokens)
+			state.LastFill = lastFill
+			state.ResetTime = now.Add(time.Duration((float64(burst) - tokens) * float64(interval) / float64(rate)))
+		}
+		states = append(states, state)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	sortBucketStates(states)
+	return states, nil
+}
+
+// Close stops the fallback limiter if the limiter created it. The Redis
+// client is left to its owner.
+func (l *DistributedRateLimiter) Close() error {
+	if l.ownedFallback != nil {
+		return l.ownedFallback.Close()
+	}
+	return nil
+}
+
+// Available reports whether the last call to Redis succeeded
+func (l *DistributedRateLimiter) Available() bool {
+	return !l.down.Load()
+}
@@ -0,0 +1,193 @@
+// Package middleware provides HTTP middleware components
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HeaderRequestTimeout carries the time a client is willing to wait, as a Go
+// duration ("1.5s", "250ms") or whole seconds. Outbound requests made with
+// DeadlineTransport carry the remaining time of the incoming request.
+const HeaderRequestTimeout = "X-Request-Timeout"
+
+// TimeoutConfig provides configuration for the request timeout middleware
+type TimeoutConfig struct {
+	// Default is the timeout of requests without a matching route; 0 disables it
+	Default time.Duration
+	// Routes overrides the timeout by path (HTTP) or full method (gRPC)
+	// prefix; the longest matching prefix wins and 0 disables the timeout
+	Routes map[string]time.Duration
+}
+
+// DefaultTimeoutConfig returns the default request timeout configuration
+func DefaultTimeoutConfig() TimeoutConfig {
+	return TimeoutConfig{
+		Default: 10 * time.Second,
+	}
+}
+
+// timeoutFor returns the configured timeout for a path or full method
+func (config TimeoutConfig) timeoutFor(route string) time.Duration {
+	timeout := config.Default
+	longest := -1
+	for prefix, t := range config.Routes {
+		if strings.HasPrefix(route, prefix) && len(prefix) > longest {
+			timeout, longest = t, len(prefix)
+		}
+	}
+	return timeout
+}
+
+// Timeout sets a deadline on each request's context, shortened by the
+// client's X-Request-Timeout, and responds 504 Gateway Timeout if the deadline
+// passes before the handler writes a response. Handlers must honour the
+// context to stop early; apply it to API routes, not to long-running ops
+// routes such as pprof profiles.
+func Timeout(config TimeoutConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := config.timeoutFor(c.Request.URL.Path)
+		if requested, ok := ParseRequestTimeout(c.GetHeader(HeaderRequestTimeout), timeout); ok {
+			timeout = requested
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "not_found"
+		}
+		recordTimeout(ctx, "http", route, timeout)
+
+		if !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{
+				"error":      "timeout",
+				"message":    "The request timed out",
+				"request_id": ExtractRequestID(c),
+				"timestamp":  time.Now().Unix(),
+			})
+		}
+	}
+}
+
+// UnaryTimeoutInterceptor sets a deadline on unary RPCs; a shorter deadline
+// sent by the client (grpc-timeout) still applies. Streams are not limited.
+func UnaryTimeoutInterceptor(config TimeoutConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		timeout := config.timeoutFor(info.FullMethod)
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			recordTimeout(ctx, "grpc", info.FullMethod, timeout)
+			if _, ok := status.FromError(err); !ok || status.Code(err) == codes.Unknown {
+				err = status.Error(codes.DeadlineExceeded, "The request timed out")
+			}
+		}
+		return resp, err
+	}
+}
+
+// recordTimeout logs a timed out request and records it in metrics and on the span
+func recordTimeout(ctx context.Context, transport, route string, timeout time.Duration) {
+	metrics.RecordRequestTimeout(transport, route)
+	trace.SpanFromContext(ctx).AddEvent("request.timeout", trace.WithAttributes(
+		attribute.Int64("timeout_ms", timeout.Milliseconds()),
+	))
+	logger.Warn(ctx, "Request timed out",
+		zap.String("transport", transport),
+		zap.String("route", route),
+		zap.Duration("timeout", timeout),
+	)
+}
+
+// ParseRequestTimeout parses an X-Request-Timeout value, capped at limit when
+// limit is positive. Whole seconds are capped before conversion so that large
+// values cannot overflow.
+func ParseRequestTimeout(value string, limit time.Duration) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if limit <= 0 {
+		limit = math.MaxInt64
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0, false
+		}
+		if seconds > int(limit/time.Second) {
+			return limit, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	if d > limit {
+		return limit, true
+	}
+	return d, true
+}
+
+// DeadlineTransport propagates the deadline of an outbound request's context
+// to the downstream service as X-Request-Timeout. gRPC clients propagate
+// deadlines themselves, as grpc-timeout.
+type DeadlineTransport struct {
+	// Base is the underlying transport; http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+// RoundTrip sets X-Request-Timeout to the time remaining before the deadline,
+// failing without a request if it has already passed
+func (t *DeadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return base.RoundTrip(req)
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return nil, context.DeadlineExceeded
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(HeaderRequestTimeout, strconv.FormatInt(max(remaining.Milliseconds(), 1), 10)+"ms")
+	return base.RoundTrip(req)
+}
@@ -19,13 +19,14 @@ import (
 type TracingConfig struct {
 	// ServiceName is the name of the service
 	ServiceName string
-	// SpanNameFormatter formats the span name
+	// SpanNameFormatter formats the span name; unused by the gRPC interceptors
 	SpanNameFormatter func(*gin.Context) string
 	// TracerProvider allows custom tracer provider
 	TracerProvider trace.TracerProvider
 	// Propagator allows custom propagator
 	Propagator propagation.TextMapPropagator
-	// SkipPaths skips tracing for these paths
+	// SkipPaths skips tracing for these paths, or for these full method names
+	// such as "/grpc.health.v1.Health/Check" in the gRPC interceptors
 	SkipPaths []string
 	// RecordError records errors in spans
 	RecordError bool
@@ -35,6 +35,8 @@ var (
 	globalLogger *zap.Logger
 	// global sugar logger for convenience
 	globalSugar *zap.SugaredLogger
+	// global level, adjustable at runtime
+	globalLevel = zap.NewAtomicLevel()
 )
 
 // Config holds logger configuration
@@ -104,8 +106,9 @@ func Initialize(cfg Config) error {
 	}
 
 	// Build zap config
+	globalLevel.SetLevel(level)
 	zapConfig := zap.Config{
-		Level:             zap.NewAtomicLevelAt(level),
+		Level:             globalLevel,
 		Development:       cfg.Development,
 		DisableCaller:     cfg.DisableCaller,
 		DisableStacktrace: cfg.DisableStacktrace,
@@ -143,6 +146,21 @@ func Initialize(cfg Config) error {
 	return nil
 }
 
+// SetLevel changes the minimum enabled logging level without rebuilding the logger
+func SetLevel(level string) error {
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(strings.ToLower(level))); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	globalLevel.SetLevel(l)
+	return nil
+}
+
+// GetLevel returns the current minimum enabled logging level
+func GetLevel() string {
+	return globalLevel.Level().String()
+}
+
 // Get returns the global logger instance
 func Get() *zap.Logger {
 	if globalLogger == nil {
@@ -19,6 +19,7 @@ type Metrics struct {
 	HTTPRequestDuration   *prometheus.HistogramVec
 	HTTPRequestsInFlight  prometheus.Gauge
 	HTTPResponseSizeBytes *prometheus.HistogramVec
+	HTTPShutdownRequests  *prometheus.CounterVec
 
 	// gRPC metrics
 	GRPCRequestsTotal   *prometheus.CounterVec
@@ -46,7 +47,15 @@ type Metrics struct {
 	AppInfo           *prometheus.GaugeVec
 	ProcessUptime     prometheus.Counter
 	HealthCheckStatus prometheus.Gauge
+	ReadinessChecks   *prometheus.GaugeVec
 	PanicsTotal       prometheus.Counter
+	RequestTimeouts   *prometheus.CounterVec
+
+	// Rate limit metrics
+	RateLimitDecisions *prometheus.CounterVec
+
+	// Feature flag metrics
+	FeatureFlagEvaluations *prometheus.CounterVec
 }
 
 var (
@@ -130,6 +139,15 @@ func Initialize(namespace, subsystem string) *Metrics {
 			},
 			[]string{"method", "path", "status"},
 		),
+		HTTPShutdownRequests: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "http_shutdown_requests_total",
+				Help:      "HTTP requests in flight at shutdown, by whether they drained or were cut at the drain deadline",
+			},
+			[]string{"outcome"},
+		),
 
 		// gRPC metrics
 		GRPCRequestsTotal: promauto.NewCounterVec(
@@ -289,6 +307,15 @@ func Initialize(namespace, subsystem string) *Metrics {
 				Help:      "Health check status (1 = healthy, 0 = unhealthy)",
 			},
 		),
+		ReadinessChecks: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "readiness_check_status",
+				Help:      "Readiness check status by check (1 = passing, 0 = failing)",
+			},
+			[]string{"check"},
+		),
 		PanicsTotal: promauto.NewCounter(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -297,6 +324,37 @@ func Initialize(namespace, subsystem string) *Metrics {
 				Help:      "Total number of panics recovered",
 			},
 		),
+		RequestTimeouts: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "request_timeouts_total",
+				Help:      "Requests that exceeded their deadline, by transport (http, grpc) and route",
+			},
+			[]string{"transport", "route"},
+		),
+
+		// Rate limit metrics
+		RateLimitDecisions: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "rate_limit_decisions_total",
+				Help:      "Rate limit decisions by transport (http, grpc), policy, key type (ip, user, api_key, tenant) and result (allowed, denied)",
+			},
+			[]string{"transport", "policy", "key_type", "result"},
+		),
+
+		// Feature flag metrics
+		FeatureFlagEvaluations: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "feature_flag_evaluations_total",
+				Help:      "Feature flag evaluations by flag and result (on, off)",
+			},
+			[]string{"flag", "result"},
+		),
 	}
 
 	// Set app info
@@ -340,6 +398,37 @@ func RecordHTTPRequest(method, path, status string, duration time.Duration, size
 	m.HTTPResponseSizeBytes.WithLabelValues(method, path, status).Observe(float64(size))
 }
 
+// RecordShutdownRequests records how many in-flight HTTP requests a shutdown
+// drained or cut
+func RecordShutdownRequests(drained, cut int64) {
+	m := Get()
+	m.HTTPShutdownRequests.WithLabelValues("drained").Add(float64(drained))
+	m.HTTPShutdownRequests.WithLabelValues("cut").Add(float64(cut))
+}
+
+// RecordRequestTimeout records a request that exceeded its deadline
+func RecordRequestTimeout(transport, route string) {
+	Get().RequestTimeouts.WithLabelValues(transport, route).Inc()
+}
+
+// RecordRateLimitDecision records whether a rate limit policy allowed a request
+func RecordRateLimitDecision(transport, policy, keyType string, allowed bool) {
+	result := "denied"
+	if allowed {
+		result = "allowed"
+	}
+	Get().RateLimitDecisions.WithLabelValues(transport, policy, keyType, result).Inc()
+}
+
+// RecordFeatureFlagEvaluation records the result of evaluating a feature flag
+func RecordFeatureFlagEvaluation(flag string, enabled bool) {
+	result := "off"
+	if enabled {
+		result = "on"
+	}
+	Get().FeatureFlagEvaluations.WithLabelValues(flag, result).Inc()
+}
+
 // RecordGRPCRequest records a gRPC request metric
 func RecordGRPCRequest(service, method, status string, duration time.Duration) {
 	m := Get()
@@ -347,6 +436,11 @@ func RecordGRPCRequest(service, method, status string, duration time.Duration) {
 	m.GRPCRequestDuration.WithLabelValues(service, method, status).Observe(duration.Seconds())
 }
 
+// RecordGRPCStreamMsg records a message sent or received on a gRPC stream
+func RecordGRPCStreamMsg(service, method, direction string) {
+	Get().GRPCStreamMsgs.WithLabelValues(service, method, direction).Inc()
+}
+
 // RecordBusinessOperation records a business operation metric
 func RecordBusinessOperation(operation, status string, duration time.Duration) {
 	m := Get()
@@ -390,6 +484,15 @@ func SetHealthStatus(healthy bool) {
 	}
 }
 
+// SetHealthCheckStatus sets the status of a single readiness check
+func SetHealthCheckStatus(check string, healthy bool) {
+	if healthy {
+		Get().ReadinessChecks.WithLabelValues(check).Set(1)
+	} else {
+		Get().ReadinessChecks.WithLabelValues(check).Set(0)
+	}
+}
+
 // UpdateDBConnectionMetrics updates database connection metrics
 func UpdateDBConnectionMetrics(open, inUse int) {
 	m := Get()
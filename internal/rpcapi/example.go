@@ -0,0 +1,169 @@
+// Package rpcapi provides gRPC server setup and service handlers
+package rpcapi
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/lumitut/lumi-go/api/proto/v1"
+	"github.com/lumitut/lumi-go/internal/features"
+	"github.com/lumitut/lumi-go/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// exampleServer implements lumigo.api.v1.ExampleService on top of service.ExampleService
+type exampleServer struct {
+	pb.UnimplementedExampleServiceServer
+	svc   *service.ExampleService
+	flags *features.Evaluator // nil turns beta features off
+}
+
+func newExampleServer(svc *service.ExampleService, flags *features.Evaluator) *exampleServer {
+	return &exampleServer{svc: svc, flags: flags}
+}
+
+// betaFeatures reports whether beta features are on for the caller in ctx
+func (e *exampleServer) betaFeatures(ctx context.Context) bool {
+	return e.flags != nil && e.flags.Enabled(ctx, features.FlagBetaFeatures)
+}
+
+// GetExample retrieves an example by ID
+func (e *exampleServer) GetExample(ctx context.Context, req *pb.GetExampleRequest) (*pb.ExampleResponse, error) {
+	example, err := e.svc.GetExample(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &pb.ExampleResponse{Example: toProtoExample(example)}, nil
+}
+
+// ListExamples retrieves a list of examples
+func (e *exampleServer) ListExamples(ctx context.Context, req *pb.ListExamplesRequest) (*pb.ListExamplesResponse, error) {
+	list, err := e.svc.ListExamples(ctx, service.ListExamplesOptions{
+		Limit:  int(req.GetLimit()),
+		Offset: int(req.GetOffset()),
+		SortBy: req.GetSortBy(),
+		Filter: req.GetFilter(),
+
+		FilterMetadata: e.betaFeatures(ctx),
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	items := make([]*pb.Example, 0, len(list.Items))
+	for _, example := range list.Items {
+		items = append(items, toProtoExample(example))
+	}
+
+	return &pb.ListExamplesResponse{
+		Items:   items,
+		Total:   int32(list.Total),
+		Limit:   int32(list.Limit),
+		Offset:  int32(list.Offset),
+		HasMore: list.HasMore,
+	}, nil
+}
+
+// CreateExample creates a new example
+func (e *exampleServer) CreateExample(ctx context.Context, req *pb.CreateExampleRequest) (*pb.ExampleResponse, error) {
+	example, err := e.svc.CreateExample(ctx, req.GetName(), req.GetDescription(), req.GetMetadata())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &pb.ExampleResponse{Example: toProtoExample(example)}, nil
+}
+
+// UpdateExample updates an existing example
+func (e *exampleServer) UpdateExample(ctx context.Context, req *pb.UpdateExampleRequest) (*pb.ExampleResponse, error) {
+	example, err := e.svc.UpdateExample(ctx, req.GetId(), req.GetName(), req.GetDescription(), req.GetMetadata())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &pb.ExampleResponse{Example: toProtoExample(example)}, nil
+}
+
+// DeleteExample deletes an example
+func (e *exampleServer) DeleteExample(ctx context.Context, req *pb.DeleteExampleRequest) (*emptypb.Empty, error) {
+	if err := e.svc.DeleteExample(ctx, req.GetId()); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// StreamExamples sends the current matching examples, then every matching
+// create or update until the client cancels or the server shuts down
+func (e *exampleServer) StreamExamples(req *pb.StreamExamplesRequest, stream grpc.ServerStreamingServer[pb.ExampleResponse]) error {
+	ctx := stream.Context()
+
+	// Subscribe before taking the snapshot so no change is missed in between
+	updates := e.svc.Subscribe(ctx)
+	filterMetadata := e.betaFeatures(ctx)
+
+	for offset := 0; ; {
+		list, err := e.svc.ListExamples(ctx, service.ListExamplesOptions{
+			Offset: offset,
+			Filter: req.GetFilter(),
+
+			FilterMetadata: filterMetadata,
+		})
+		if err != nil {
+			return toStatusError(err)
+		}
+		for _, example := range list.Items {
+			if err := stream.Send(&pb.ExampleResponse{Example: toProtoExample(example)}); err != nil {
+				return err
+			}
+		}
+		if !list.HasMore {
+			break
+		}
+		offset += len(list.Items)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case example, ok := <-updates:
+			if !ok {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			if !example.Matches(req.GetFilter(), filterMetadata) {
+				continue
+			}
+			if err := stream.Send(&pb.ExampleResponse{Example: toProtoExample(example)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toProtoExample converts a service example into its proto form
+func toProtoExample(example *service.Example) *pb.Example {
+	return &pb.Example{
+		Id:          example.ID,
+		Name:        example.Name,
+		Description: example.Description,
+		Metadata:    example.Metadata,
+		CreatedAt:   timestamppb.New(example.CreatedAt),
+		UpdatedAt:   timestamppb.New(example.UpdatedAt),
+	}
+}
+
+// toStatusError maps service errors onto gRPC status codes
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrExampleNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidExample):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,179 @@
+// Package rpcapi provides gRPC server setup and service handlers
+package rpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	pb "github.com/lumitut/lumi-go/api/proto/v1"
+	"github.com/lumitut/lumi-go/internal/features"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/lumitut/lumi-go/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// gatewayHeaders are forwarded to the service as incoming gRPC metadata so
+// handlers see the same values over both transports
+var gatewayHeaders = map[string]bool{
+	middleware.HeaderRequestID:     true,
+	middleware.HeaderCorrelationID: true,
+	middleware.HeaderUserID:        true,
+	middleware.HeaderTenantID:      true,
+	"X-Api-Key":                    true,
+}
+
+// errorCodes maps gRPC status codes onto the error codes of the JSON error envelope
+var errorCodes = map[codes.Code]string{
+	codes.InvalidArgument:    "invalid_request",
+	codes.FailedPrecondition: "invalid_request",
+	codes.OutOfRange:         "invalid_request",
+	codes.NotFound:           "not_found",
+	codes.AlreadyExists:      "conflict",
+	codes.Aborted:            "conflict",
+	codes.Unauthenticated:    "unauthorized",
+	codes.PermissionDenied:   "forbidden",
+	codes.ResourceExhausted:  "rate_limit_exceeded",
+	codes.Unimplemented:      "not_implemented",
+	codes.Unavailable:        "service_unavailable",
+	codes.DeadlineExceeded:   "timeout",
+	codes.Canceled:           "request_canceled",
+}
+
+// NewGateway returns an HTTP handler that transcodes the REST routes declared
+// with google.api.http annotations in service.proto into in-process
+// ExampleService calls. Requests reach it through the Gin middleware stack,
+// so no gRPC interceptors run on this path. flags, when not nil, turns beta
+// features on per request. Like Gin route registration it panics if the
+// routes cannot be registered.
+func NewGateway(examples *service.ExampleService, flags *features.Evaluator) http.Handler {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
+		}),
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithForwardResponseOption(gatewayResponseStatus),
+		runtime.WithErrorHandler(gatewayErrorHandler),
+		runtime.WithRoutingErrorHandler(gatewayRoutingErrorHandler),
+	)
+
+	if err := pb.RegisterExampleServiceHandlerServer(context.Background(), mux, newExampleServer(examples, flags)); err != nil {
+		panic(fmt.Sprintf("failed to register ExampleService gateway: %v", err))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(&gatewayResponseWriter{ResponseWriter: w}, r)
+	})
+}
+
+// gatewayHeaderMatcher forwards correlation and identity headers in addition
+// to the gateway defaults
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if gatewayHeaders[http.CanonicalHeaderKey(key)] {
+		return strings.ToLower(key), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayResponseStatus answers resource creation with 201 Created and
+// deletion with 204 No Content
+func gatewayResponseStatus(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	method, _ := runtime.RPCMethod(ctx)
+	switch method {
+	case pb.ExampleService_CreateExample_FullMethodName:
+		w.WriteHeader(http.StatusCreated)
+	case pb.ExampleService_DeleteExample_FullMethodName:
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+	}
+	return nil
+}
+
+// gatewayResponseWriter drops the body the gateway writes after a 204 No
+// Content status, which HTTP does not allow
+type gatewayResponseWriter struct {
+	http.ResponseWriter
+	noContent bool
+}
+
+func (w *gatewayResponseWriter) WriteHeader(code int) {
+	w.noContent = code == http.StatusNoContent
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gatewayResponseWriter) Write(b []byte) (int, error) {
+	if w.noContent {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *gatewayResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// gatewayErrorHandler writes gRPC errors using the JSON error envelope shared
+// with the Gin middleware
+func gatewayErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+
+	message := st.Message()
+	if st.Code() == codes.Internal || st.Code() == codes.Unknown {
+		// Don't leak internal error details to clients
+		message = "An internal server error occurred"
+	}
+
+	writeGatewayError(w, r, runtime.HTTPStatusFromCode(st.Code()), ErrorCode(st.Code()), message)
+}
+
+// gatewayRoutingErrorHandler reports unmatched routes and methods with the JSON error envelope
+func gatewayRoutingErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+	switch httpStatus {
+	case http.StatusNotFound:
+		writeGatewayError(w, r, http.StatusNotFound, "not_found", "The requested resource was not found")
+	case http.StatusMethodNotAllowed:
+		writeGatewayError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	default:
+		runtime.DefaultRoutingErrorHandler(ctx, mux, marshaler, w, r, httpStatus)
+	}
+}
+
+// writeGatewayError writes the JSON error envelope
+func writeGatewayError(w http.ResponseWriter, r *http.Request, httpStatus int, code, message string) {
+	body, err := json.Marshal(map[string]interface{}{
+		"error":      code,
+		"message":    message,
+		"request_id": middleware.RequestIDFromContext(r.Context()),
+		"timestamp":  time.Now().Unix(),
+	})
+	if err != nil {
+		http.Error(w, http.StatusText(httpStatus), httpStatus)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(httpStatus)
+	_, _ = w.Write(body)
+}
+
+// ErrorCode returns the JSON error envelope code for a gRPC status code
+func ErrorCode(code codes.Code) string {
+	if errorCode, ok := errorCodes[code]; ok {
+		return errorCode
+	}
+	return "internal_server_error"
+}
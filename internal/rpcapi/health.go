@@ -0,0 +1,87 @@
+// Package rpcapi provides gRPC server setup and service handlers
+package rpcapi
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/lumitut/lumi-go/api/proto/v1"
+	"github.com/lumitut/lumi-go/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// healthServer implements lumigo.api.v1.HealthService on top of service.HealthService
+type healthServer struct {
+	pb.UnimplementedHealthServiceServer
+	svc *service.HealthService
+}
+
+func newHealthServer(svc *service.HealthService) *healthServer {
+	return &healthServer{svc: svc}
+}
+
+// GetHealth returns the health status of the service
+func (h *healthServer) GetHealth(ctx context.Context, _ *emptypb.Empty) (*pb.HealthResponse, error) {
+	health, err := h.svc.GetHealth(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get health: %v", err)
+	}
+
+	details := make(map[string]string, len(health.Details))
+	for k, v := range health.Details {
+		details[k] = fmt.Sprint(v)
+	}
+
+	healthStatus := pb.HealthResponse_UNHEALTHY
+	if health.Status == "healthy" {
+		healthStatus = pb.HealthResponse_HEALTHY
+	}
+
+	return &pb.HealthResponse{
+		Status:      healthStatus,
+		Service:     health.Service,
+		Version:     health.Version,
+		Environment: health.Environment,
+		Timestamp:   health.Timestamp,
+		Uptime:      health.Uptime,
+		Details:     details,
+	}, nil
+}
+
+// GetReadiness returns the readiness status of the service
+func (h *healthServer) GetReadiness(ctx context.Context, _ *emptypb.Empty) (*pb.ReadinessResponse, error) {
+	readiness, err := h.svc.GetReadiness(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get readiness: %v", err)
+	}
+
+	checks := make(map[string]*pb.CheckResult, len(readiness.Checks))
+	for name, check := range readiness.Checks {
+		checks[name] = toCheckResult(check)
+	}
+
+	return &pb.ReadinessResponse{
+		Ready:     readiness.Ready,
+		Timestamp: readiness.Timestamp,
+		Checks:    checks,
+		Service:   readiness.Service,
+	}, nil
+}
+
+// toCheckResult converts a service readiness check into its proto form
+func toCheckResult(check service.Check) *pb.CheckResult {
+	checkStatus := pb.CheckResult_UNKNOWN
+	switch check.Status {
+	case "ready":
+		checkStatus = pb.CheckResult_READY
+	case "not_ready":
+		checkStatus = pb.CheckResult_NOT_READY
+	}
+
+	return &pb.CheckResult{
+		Status:  checkStatus,
+		Message: check.Message,
+	}
+}
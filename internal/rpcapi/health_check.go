@@ -0,0 +1,144 @@
+// Package rpcapi provides gRPC server setup and service handlers
+package rpcapi
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthCheckServer implements the standard grpc.health.v1.Health service
+// used by Kubernetes gRPC probes and tools like grpcurl. Unlike the reference
+// implementation it ends Watch streams on shutdown, after delivering the final
+// NOT_SERVING status, so they don't hold up a graceful stop.
+type healthCheckServer struct {
+	healthpb.UnimplementedHealthServer
+
+	mu       sync.Mutex
+	statuses map[string]healthpb.HealthCheckResponse_ServingStatus
+	watchers map[string]map[chan healthpb.HealthCheckResponse_ServingStatus]struct{}
+	stopped  bool
+	done     chan struct{}
+}
+
+func newHealthCheckServer() *healthCheckServer {
+	return &healthCheckServer{
+		statuses: make(map[string]healthpb.HealthCheckResponse_ServingStatus),
+		watchers: make(map[string]map[chan healthpb.HealthCheckResponse_ServingStatus]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Check returns the serving status of a service ("" for the whole server)
+func (h *healthCheckServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	servingStatus, ok := h.statuses[req.GetService()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch streams the serving status of a service whenever it changes
+func (h *healthCheckServer) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	service := req.GetService()
+	updates := make(chan healthpb.HealthCheckResponse_ServingStatus, 1)
+
+	h.mu.Lock()
+	current, ok := h.statuses[service]
+	if !ok {
+		current = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	if h.watchers[service] == nil {
+		h.watchers[service] = make(map[chan healthpb.HealthCheckResponse_ServingStatus]struct{})
+	}
+	h.watchers[service][updates] = struct{}{}
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.watchers[service], updates)
+		h.mu.Unlock()
+	}()
+
+	last := current
+	if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+		return err
+	}
+
+	send := func(servingStatus healthpb.HealthCheckResponse_ServingStatus) error {
+		if servingStatus == last {
+			return nil
+		}
+		last = servingStatus
+		return stream.Send(&healthpb.HealthCheckResponse{Status: servingStatus})
+	}
+
+	for {
+		select {
+		case servingStatus := <-updates:
+			if err := send(servingStatus); err != nil {
+				return err
+			}
+		case <-h.done:
+			// Deliver the final status published by shutdown before ending the stream
+			select {
+			case servingStatus := <-updates:
+				if err := send(servingStatus); err != nil {
+					return err
+				}
+			default:
+			}
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+// setServingStatus records the serving status of a service and notifies watchers.
+// It is a no-op after shutdown.
+func (h *healthCheckServer) setServingStatus(service string, servingStatus healthpb.HealthCheckResponse_ServingStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopped {
+		return
+	}
+	h.setLocked(service, servingStatus)
+}
+
+// shutdown marks every service NOT_SERVING and ends all Watch streams
+func (h *healthCheckServer) shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopped {
+		return
+	}
+	for service := range h.statuses {
+		h.setLocked(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	h.stopped = true
+	close(h.done)
+}
+
+// setLocked updates a status and notifies watchers; h.mu must be held
+func (h *healthCheckServer) setLocked(service string, servingStatus healthpb.HealthCheckResponse_ServingStatus) {
+	h.statuses[service] = servingStatus
+
+	for updates := range h.watchers[service] {
+		// Replace any status the watcher has not consumed yet
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- servingStatus
+	}
+}
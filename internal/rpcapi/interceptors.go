@@ -0,0 +1,110 @@
+// Package rpcapi provides gRPC server setup and service handlers
+package rpcapi
+
+import (
+	pb "github.com/lumitut/lumi-go/api/proto/v1"
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// opsMethods are the gRPC counterparts of the HTTP ops routes in
+// MiddlewareConfig.LogSkipPaths; they are not traced, logged, measured, limited
+// or rejected in maintenance mode
+var opsMethods = []string{
+	pb.HealthService_GetHealth_FullMethodName,
+	pb.HealthService_GetReadiness_FullMethodName,
+	healthpb.Health_Check_FullMethodName,
+	healthpb.Health_Watch_FullMethodName,
+}
+
+// interceptorChain builds the unary and stream interceptor chains from the
+// same middleware configuration that drives the HTTP router. rateLimiters are
+// shared by unary and streaming calls, nil when rate limiting is disabled,
+// and maintenance is the maintenance switch, nil to serve every call.
+func interceptorChain(cfg *config.Config, rateLimiters *middleware.RateLimiters, maintenance *middleware.MaintenanceMode) []grpc.ServerOption {
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+
+	// Interceptors - Order matters!
+
+	// 1. Correlation IDs (before recovery so panic logs carry request IDs)
+	unary = append(unary, middleware.UnaryCorrelationInterceptor())
+	stream = append(stream, middleware.StreamCorrelationInterceptor())
+
+	// 2. Recovery
+	recoveryConfig := middleware.RecoveryConfig{
+		EnableStackTrace: cfg.Middleware.RecoveryStackTrace,
+		StackTraceSize:   cfg.Middleware.RecoveryStackSize,
+		PrintStack:       cfg.Middleware.RecoveryPrintStack,
+		LogLevel:         "error",
+	}
+	unary = append(unary, middleware.UnaryRecoveryInterceptor(recoveryConfig))
+	stream = append(stream, middleware.StreamRecoveryInterceptor(recoveryConfig))
+
+	// 3. OpenTelemetry tracing
+	if cfg.IsTracingEnabled() {
+		tracingConfig := middleware.TracingConfig{
+			ServiceName: cfg.Service.Name,
+			SkipPaths:   opsMethods,
+			RecordError: true,
+		}
+		unary = append(unary, middleware.UnaryTracingInterceptor(tracingConfig))
+		stream = append(stream, middleware.StreamTracingInterceptor(tracingConfig))
+	}
+
+	// 4. Access logging
+	loggingConfig := middleware.LoggingConfig{
+		SkipPaths:       opsMethods,
+		LogRequestBody:  cfg.Middleware.LogRequestBody,
+		LogResponseBody: cfg.Middleware.LogResponseBody,
+		SlowThreshold:   cfg.Middleware.LogSlowThreshold,
+	}
+	unary = append(unary, middleware.UnaryLoggingInterceptor(loggingConfig))
+	stream = append(stream, middleware.StreamLoggingInterceptor(loggingConfig))
+
+	// 5. Metrics
+	if cfg.Observability.MetricsEnabled {
+		metricsConfig := middleware.MetricsConfig{
+			SkipPaths: opsMethods,
+		}
+		unary = append(unary, middleware.UnaryMetricsInterceptor(metricsConfig))
+		stream = append(stream, middleware.StreamMetricsInterceptor(metricsConfig))
+	}
+
+	// 6. Rate limiting: the global limit, policies and API key plans
+	if cfg.Middleware.RateLimitEnabled && rateLimiters != nil {
+		// The limiters are passed in, so there is nothing for rateLimit to close
+		rateLimit := middleware.NewGRPCRateLimit(middleware.GRPCRateLimitConfig{
+			Enabled:   true,
+			Rate:      cfg.Middleware.RateLimitRate,
+			Burst:     cfg.Middleware.RateLimitBurst,
+			Limiter:   rateLimiters.Limiter(),
+			KeyFunc:   middleware.GRPCRateLimitKeyFunc(cfg.Middleware.RateLimitType),
+			Policies:  rateLimiters.Policies(),
+			Headers:   cfg.Middleware.RateLimitHeaders,
+			SkipPaths: opsMethods,
+		})
+		unary = append(unary, rateLimit.UnaryInterceptor())
+		stream = append(stream, rateLimit.StreamInterceptor())
+	}
+
+	// 7. Maintenance mode
+	if maintenance != nil {
+		unary = append(unary, middleware.UnaryMaintenanceInterceptor(maintenance))
+		stream = append(stream, middleware.StreamMaintenanceInterceptor(maintenance))
+	}
+
+	// 8. Request timeouts (unary only; streams keep the client's deadline)
+	routes, _ := cfg.Middleware.RequestTimeoutRouteMap() // checked by Validate
+	unary = append(unary, middleware.UnaryTimeoutInterceptor(middleware.TimeoutConfig{
+		Default: cfg.Middleware.RequestTimeout,
+		Routes:  routes,
+	}))
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
@@ -0,0 +1,361 @@
+// Package rpcapi provides gRPC server setup and service handlers
+package rpcapi
+
+//go:generate protoc -I ../../api/proto -I ${GOOGLEAPIS_DIR} --go_out=../.. --go_opt=module=github.com/lumitut/lumi-go --go-grpc_out=../.. --go-grpc_opt=module=github.com/lumitut/lumi-go --grpc-gateway_out=../.. --grpc-gateway_opt=module=github.com/lumitut/lumi-go service.proto
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	pb "github.com/lumitut/lumi-go/api/proto/v1"
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/features"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/lumitut/lumi-go/internal/service"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+)
+
+// healthPollInterval is how often readiness is mirrored into the standard health service
+const healthPollInterval = time.Second
+
+// readinessLoop names the watchdog heartbeat of the readiness mirroring loop
+const readinessLoop = "rpc-readiness"
+
+// Server represents the gRPC server
+type Server struct {
+	config      *config.Config
+	grpcServer  *grpc.Server
+	health      *service.HealthService
+	examples    *service.ExampleService
+	healthCheck *healthCheckServer
+	maintenance *middleware.MaintenanceMode
+
+	// Global rate limit, nil when rate limiting is disabled
+	rateLimiters *middleware.RateLimiters
+
+	// Keeps the rate limit counters shared by every replica, nil unless the
+	// store is Redis; owned by the caller
+	redis *redis.Client
+
+	// Cancelled at the drain timeout to end the streams still open
+	drainCtx     context.Context
+	closeStreams context.CancelFunc
+
+	mu         sync.RWMutex
+	listener   net.Listener
+	isReady    bool
+	stopHealth context.CancelFunc
+}
+
+// NewServer creates a new gRPC server with all services registered. The
+// ExampleService and HealthService are shared with the HTTP server so both
+// transports see the same data and readiness. Rate limits are kept in memory
+// and beta features are off; use NewServerWithOptions to change that.
+func NewServer(cfg *config.Config, examples *service.ExampleService, health *service.HealthService) *Server {
+	return NewServerWithOptions(cfg, examples, health, Options{})
+}
+
+// Options are the optional dependencies of a server
+type Options struct {
+	// Redis keeps the rate limit counters shared by every replica when not
+	// nil, as returned by middleware.NewRateLimitRedis; the caller closes it
+	Redis *redis.Client
+	// Features turns beta features on per call when not nil
+	Features *features.Evaluator
+}
+
+// NewServerWithOptions creates a new gRPC server with the given dependencies
+func NewServerWithOptions(cfg *config.Config, examples *service.ExampleService, health *service.HealthService, opts Options) *Server {
+	s := &Server{
+		config:      cfg,
+		health:      health,
+		examples:    examples,
+		healthCheck: newHealthCheckServer(),
+		redis:       opts.Redis,
+	}
+	s.drainCtx, s.closeStreams = context.WithCancel(context.Background())
+
+	// Startup completes once the server is listening
+	health.Lifecycle().Register("rpc")
+
+	// Unary and streaming calls share one rate limit budget per key
+	if cfg.Middleware.RateLimitEnabled {
+		s.rateLimiters = middleware.NewRateLimiters(cfg.Middleware, s.redis, cfg.Service.Name+":ratelimit:rpc:")
+	}
+
+	// Maintenance switch, toggled by feature flag changes
+	s.maintenance = middleware.NewMaintenanceMode(middleware.MaintenanceConfig{
+		RetryAfter:   cfg.Middleware.MaintenanceRetryAfter,
+		AllowIPs:     cfg.Middleware.MaintenanceAllowIPs,
+		AllowAPIKeys: cfg.Middleware.MaintenanceAllowAPIKeys,
+		SkipMethods:  opsMethods,
+	}, cfg.Features.MaintenanceMode)
+
+	// Streams run innermost, so handlers see the drain cancellation
+	grpcOpts := serverOptions(cfg, s.rateLimiters, s.maintenance)
+	grpcOpts = append(grpcOpts, grpc.ChainStreamInterceptor(middleware.StreamDrainInterceptor(s.drainCtx)))
+	s.grpcServer = grpc.NewServer(grpcOpts...)
+
+	// Register services
+	pb.RegisterHealthServiceServer(s.grpcServer, newHealthServer(s.health))
+	pb.RegisterExampleServiceServer(s.grpcServer, newExampleServer(s.examples, opts.Features))
+
+	// Standard health checking protocol; the custom HealthService reports
+	// liveness, the overall and ExampleService statuses readiness
+	healthpb.RegisterHealthServer(s.grpcServer, s.healthCheck)
+	s.updateServingStatus(context.Background())
+
+	// Server reflection (only in non-production and if explicitly enabled)
+	if cfg.Service.Environment != "production" && cfg.Server.EnableReflection {
+		reflection.Register(s.grpcServer)
+	}
+
+	return s
+}
+
+// serverOptions maps server configuration onto gRPC server options
+func serverOptions(cfg *config.Config, rateLimiters *middleware.RateLimiters, maintenance *middleware.MaintenanceMode) []grpc.ServerOption {
+	opts := interceptorChain(cfg, rateLimiters, maintenance)
+
+	// Read timeout bounds connection establishment (including the handshake)
+	if cfg.Server.RPCReadTimeout > 0 {
+		opts = append(opts, grpc.ConnectionTimeout(cfg.Server.RPCReadTimeout))
+	}
+
+	// Write timeout bounds how long an unresponsive peer can hold a connection
+	if cfg.Server.RPCWriteTimeout > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			Timeout: cfg.Server.RPCWriteTimeout,
+		}))
+	}
+
+	return opts
+}
+
+// OnConfigChange applies a configuration change that takes effect without a
+// restart. Enabling or disabling rate limiting still requires a restart.
+func (s *Server) OnConfigChange(event config.Event) {
+	switch e := event.(type) {
+	case config.RateLimitChanged:
+		if s.rateLimiters != nil {
+			s.rateLimiters.SetLimits(e.Rate, e.Burst)
+		}
+	case config.FeaturesChanged:
+		s.maintenance.SetEnabled(e.New.MaintenanceMode)
+	}
+}
+
+// Start listens on the configured RPC port and serves until shutdown
+func (s *Server) Start(ctx context.Context) error {
+	lis, err := net.Listen("tcp", ":"+s.config.Server.RPCPort)
+	if err != nil {
+		return fmt.Errorf("failed to listen on RPC port: %w", err)
+	}
+
+	return s.Serve(ctx, lis)
+}
+
+// Serve serves gRPC requests on the given listener until shutdown
+func (s *Server) Serve(ctx context.Context, lis net.Listener) error {
+	logger.Info(ctx, "Starting gRPC server",
+		zap.String("address", lis.Addr().String()),
+		zap.String("environment", s.config.Service.Environment),
+	)
+
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	defer stopHealth()
+
+	s.mu.Lock()
+	s.listener = lis
+	s.isReady = true
+	s.stopHealth = stopHealth
+	s.mu.Unlock()
+
+	s.health.Lifecycle().MarkInitialized("rpc")
+	go s.watchReadiness(healthCtx)
+
+	if err := s.grpcServer.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+		s.setReady(false)
+		return fmt.Errorf("failed to start gRPC server: %w", err)
+	}
+
+	return nil
+}
+
+// Shutdown gracefully stops the gRPC server. In-flight RPCs get the drain
+// timeout to finish, streams still open are then cancelled, and the server is
+// forced closed if ctx expires.
+func (s *Server) Shutdown(ctx context.Context) error {
+	logger.Info(ctx, "Shutting down gRPC server")
+
+	// Mark as not ready
+	s.setReady(false)
+
+	// Report NOT_SERVING to health checkers and end Watch streams
+	s.mu.RLock()
+	stopHealth := s.stopHealth
+	s.mu.RUnlock()
+	if stopHealth != nil {
+		stopHealth()
+	}
+	s.healthCheck.shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	// In-flight RPCs get the drain timeout to finish, then open streams are
+	// cancelled so they end rather than hold the shutdown
+	var drainExpired <-chan time.Time
+	if timeout := s.config.Server.DrainTimeout; timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		drainExpired = timer.C
+	}
+	select {
+	case <-stopped:
+	case <-drainExpired:
+		logger.Warn(ctx, "Drain timeout exceeded, closing streams")
+		s.closeStreams()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+		}
+	case <-ctx.Done():
+	}
+	s.closeStreams()
+
+	var err error
+	select {
+	case <-stopped:
+		logger.Info(ctx, "gRPC server shutdown complete")
+	default:
+		// Cancels in-flight RPCs and closes the connections
+		s.grpcServer.Stop()
+		<-stopped
+		err = fmt.Errorf("failed to gracefully shutdown gRPC server: %w", ctx.Err())
+	}
+
+	_ = s.Close()
+	return err
+}
+
+// Name identifies the server as an application component
+func (s *Server) Name() string {
+	return "rpc"
+}
+
+// Stop gracefully shuts down the gRPC server as an application component
+func (s *Server) Stop(ctx context.Context) error {
+	return s.Shutdown(ctx)
+}
+
+// Close releases the server's rate limiters. Shutdown calls it; close a
+// server that is never started directly. It is safe to call more than once.
+func (s *Server) Close() error {
+	if s.rateLimiters == nil {
+		return nil
+	}
+	return s.rateLimiters.Close()
+}
+
+// Ready reports whether the server accepts requests
+func (s *Server) Ready() bool {
+	return s.IsReady()
+}
+
+// watchReadiness mirrors HealthService readiness into the standard health
+// service until ctx is cancelled. The loop beats a watchdog heartbeat, so
+// liveness fails if it wedges, e.g. on a deadlocked check.
+func (s *Server) watchReadiness(ctx context.Context) {
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	heartbeat, err := s.health.Watchdog().Register(readinessLoop, 10*healthPollInterval)
+	if err != nil {
+		// Another server shares the health service and already watches its loop
+		logger.Warn(ctx, "Readiness loop runs without a watchdog heartbeat", zap.Error(err))
+	} else {
+		defer s.health.Watchdog().Unregister(readinessLoop)
+	}
+
+	for {
+		if heartbeat != nil {
+			heartbeat.Beat()
+		}
+		s.updateServingStatus(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// updateServingStatus sets the overall and per-service serving status from
+// the current liveness and readiness
+func (s *Server) updateServingStatus(ctx context.Context) {
+	liveStatus := healthpb.HealthCheckResponse_NOT_SERVING
+	if health, err := s.health.GetHealth(ctx); err == nil && health.Status == "healthy" {
+		liveStatus = healthpb.HealthCheckResponse_SERVING
+	}
+	s.healthCheck.setServingStatus(pb.HealthService_ServiceDesc.ServiceName, liveStatus)
+
+	servingStatus := healthpb.HealthCheckResponse_NOT_SERVING
+	if readiness, err := s.health.GetReadiness(ctx); err == nil && readiness.Ready && s.IsReady() {
+		servingStatus = healthpb.HealthCheckResponse_SERVING
+	}
+
+	s.healthCheck.setServingStatus("", servingStatus)
+	s.healthCheck.setServingStatus(pb.ExampleService_ServiceDesc.ServiceName, servingStatus)
+}
+
+// RateLimiter returns the limiters serving requests, or nil when rate
+// limiting is disabled
+func (s *Server) RateLimiter() middleware.InspectableRateLimiter {
+	if s.rateLimiters == nil {
+		return nil
+	}
+	return s.rateLimiters
+}
+
+// GRPCServer returns the underlying gRPC server
+func (s *Server) GRPCServer() *grpc.Server {
+	return s.grpcServer
+}
+
+// Addr returns the address the server is listening on, or nil before Start
+func (s *Server) Addr() net.Addr {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// setReady sets the readiness state
+func (s *Server) setReady(ready bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.isReady = ready
+}
+
+// IsReady returns the readiness state
+func (s *Server) IsReady() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.isReady
+}
@@ -0,0 +1,276 @@
+// Package service contains the business logic layer
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrExampleNotFound is returned when an example does not exist
+	ErrExampleNotFound = errors.New("example not found")
+	// ErrInvalidExample is returned when an example fails validation
+	ErrInvalidExample = errors.New("invalid example")
+)
+
+// Example represents an example resource
+type Example struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// ListExamplesOptions controls pagination, sorting and filtering of examples
+type ListExamplesOptions struct {
+	Limit  int
+	Offset int
+	SortBy string // "name", "created_at" or "updated_at"
+	Filter string // case-insensitive substring match on name and description
+
+	// FilterMetadata also matches Filter against metadata values
+	FilterMetadata bool
+}
+
+// ExampleList is a page of examples
+type ExampleList struct {
+	Items   []*Example `json:"items"`
+	Total   int        `json:"total"`
+	Limit   int        `json:"limit"`
+	Offset  int        `json:"offset"`
+	HasMore bool       `json:"has_more"`
+}
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ExampleService provides an in-memory example resource store.
+// Replace the storage with a repository once a database client is configured.
+type ExampleService struct {
+	mu          sync.RWMutex
+	examples    map[string]*Example
+	subscribers map[chan *Example]struct{}
+}
+
+// NewExampleService creates a new example service
+func NewExampleService() *ExampleService {
+	return &ExampleService{
+		examples:    make(map[string]*Example),
+		subscribers: make(map[chan *Example]struct{}),
+	}
+}
+
+// GetExample returns an example by ID
+func (s *ExampleService) GetExample(ctx context.Context, id string) (*Example, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: id is required", ErrInvalidExample)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	example, ok := s.examples[id]
+	if !ok {
+		return nil, ErrExampleNotFound
+	}
+	return example.clone(), nil
+}
+
+// ListExamples returns a page of examples
+func (s *ExampleService) ListExamples(ctx context.Context, opts ListExamplesOptions) (*ExampleList, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = defaultListLimit
+	}
+	if opts.Limit > maxListLimit {
+		opts.Limit = maxListLimit
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+
+	s.mu.RLock()
+	matched := make([]*Example, 0, len(s.examples))
+	for _, example := range s.examples {
+		if example.Matches(opts.Filter, opts.FilterMetadata) {
+			matched = append(matched, example.clone())
+		}
+	}
+	s.mu.RUnlock()
+
+	sortExamples(matched, opts.SortBy)
+
+	total := len(matched)
+	start := min(opts.Offset, total)
+	end := min(start+opts.Limit, total)
+
+	return &ExampleList{
+		Items:   matched[start:end],
+		Total:   total,
+		Limit:   opts.Limit,
+		Offset:  opts.Offset,
+		HasMore: end < total,
+	}, nil
+}
+
+// CreateExample creates a new example
+func (s *ExampleService) CreateExample(ctx context.Context, name, description string, metadata map[string]string) (*Example, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidExample)
+	}
+
+	now := time.Now().UTC()
+	example := &Example{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: description,
+		Metadata:    copyMetadata(metadata),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	s.mu.Lock()
+	s.examples[example.ID] = example
+	s.publish(example)
+	s.mu.Unlock()
+
+	return example.clone(), nil
+}
+
+// UpdateExample updates an existing example.
+// Empty name and description leave the current values unchanged; a non-nil
+// metadata map replaces the existing metadata.
+func (s *ExampleService) UpdateExample(ctx context.Context, id, name, description string, metadata map[string]string) (*Example, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: id is required", ErrInvalidExample)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	example, ok := s.examples[id]
+	if !ok {
+		return nil, ErrExampleNotFound
+	}
+
+	if name != "" {
+		example.Name = name
+	}
+	if description != "" {
+		example.Description = description
+	}
+	if metadata != nil {
+		example.Metadata = copyMetadata(metadata)
+	}
+	example.UpdatedAt = time.Now().UTC()
+	s.publish(example)
+
+	return example.clone(), nil
+}
+
+// DeleteExample deletes an example by ID
+func (s *ExampleService) DeleteExample(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: id is required", ErrInvalidExample)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.examples[id]; !ok {
+		return ErrExampleNotFound
+	}
+	delete(s.examples, id)
+	return nil
+}
+
+// Subscribe returns a channel receiving every created or updated example
+// until ctx is cancelled. Slow subscribers miss events rather than block writers.
+func (s *ExampleService) Subscribe(ctx context.Context) <-chan *Example {
+	ch := make(chan *Example, 16)
+
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		close(ch)
+		s.mu.Unlock()
+	}()
+
+	return ch
+}
+
+// publish fans an example out to subscribers; callers must hold s.mu
+func (s *ExampleService) publish(example *Example) {
+	for ch := range s.subscribers {
+		select {
+		case ch <- example.clone():
+		default:
+		}
+	}
+}
+
+// Matches reports whether the example matches a list or stream filter,
+// searching metadata values too when metadata is set
+func (e *Example) Matches(filter string, metadata bool) bool {
+	if filter == "" {
+		return true
+	}
+	filter = strings.ToLower(filter)
+	if strings.Contains(strings.ToLower(e.Name), filter) ||
+		strings.Contains(strings.ToLower(e.Description), filter) {
+		return true
+	}
+	if metadata {
+		for _, value := range e.Metadata {
+			if strings.Contains(strings.ToLower(value), filter) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *Example) clone() *Example {
+	c := *e
+	c.Metadata = copyMetadata(e.Metadata)
+	return &c
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	c := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		c[k] = v
+	}
+	return c
+}
+
+func sortExamples(examples []*Example, sortBy string) {
+	sort.SliceStable(examples, func(i, j int) bool {
+		switch sortBy {
+		case "name":
+			return examples[i].Name < examples[j].Name
+		case "updated_at":
+			return examples[i].UpdatedAt.Before(examples[j].UpdatedAt)
+		default:
+			return examples[i].CreatedAt.Before(examples[j].CreatedAt)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+// Package service contains the business logic layer
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+)
+
+// CheckFunc checks a single dependency, returning an error when it is unavailable
+type CheckFunc func(ctx context.Context) error
+
+// CheckConfig holds the settings of a registered check
+type CheckConfig struct {
+	Timeout  time.Duration // Bounds a single run of the check
+	Critical bool          // A failing critical check makes the service not ready
+}
+
+// DefaultCheckConfig returns the settings used for dependency checks
+func DefaultCheckConfig() CheckConfig {
+	return CheckConfig{
+		Timeout:  2 * time.Second,
+		Critical: true,
+	}
+}
+
+// HealthChecker is a registry of named readiness checks. Checks run
+// concurrently, each bounded by its timeout, and results are cached so that
+// frequent probes from several transports don't hammer dependencies.
+type HealthChecker struct {
+	cacheTTL time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]*registeredCheck
+}
+
+// registeredCheck is a check with its settings and last result
+type registeredCheck struct {
+	name  string
+	check CheckFunc
+	cfg   CheckConfig
+
+	mu        sync.Mutex // serializes runs so concurrent probes share one result
+	result    Check
+	checkedAt time.Time
+}
+
+// NewHealthChecker creates an empty registry caching results for cacheTTL
+func NewHealthChecker(cacheTTL time.Duration) *HealthChecker {
+	return &HealthChecker{
+		cacheTTL: cacheTTL,
+		checks:   make(map[string]*registeredCheck),
+	}
+}
+
+// Register adds a named check, replacing any check with the same name
+func (h *HealthChecker) Register(name string, check CheckFunc, cfg CheckConfig) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultCheckConfig().Timeout
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = &registeredCheck{name: name, check: check, cfg: cfg}
+}
+
+// Unregister removes a named check
+func (h *HealthChecker) Unregister(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.checks, name)
+}
+
+// Names returns the names of the registered checks in sorted order
+func (h *HealthChecker) Names() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check runs every registered check, or reuses results younger than the
+// cache TTL, and reports whether all critical checks pass. Results are
+// exported as per-check metrics.
+func (h *HealthChecker) Check(ctx context.Context) (map[string]Check, bool) {
+	h.mu.RLock()
+	checks := make([]*registeredCheck, 0, len(h.checks))
+	for _, c := range h.checks {
+		checks = append(checks, c)
+	}
+	h.mu.RUnlock()
+
+	results := make([]Check, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *registeredCheck) {
+			defer wg.Done()
+			results[i] = c.run(ctx, h.cacheTTL)
+		}(i, c)
+	}
+	wg.Wait()
+
+	ready := true
+	byName := make(map[string]Check, len(checks))
+	for i, c := range checks {
+		result := results[i]
+		byName[c.name] = result
+		metrics.SetHealthCheckStatus(c.name, result.Status == "ready")
+		if result.Status != "ready" && c.cfg.Critical {
+			ready = false
+		}
+	}
+
+	return byName, ready
+}
+
+// run returns the cached result if it is fresh, otherwise runs the check.
+// The result is shared with every other probe, so the check is bounded by its
+// timeout alone: a caller going away must not cache a failure.
+func (c *registeredCheck) run(ctx context.Context, cacheTTL time.Duration) Check {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < cacheTTL {
+		return c.result
+	}
+
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runCheck(checkCtx, c.check)
+
+	result := Check{
+		Status:   "ready",
+		Critical: c.cfg.Critical,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = "not_ready"
+		result.Message = err.Error()
+	}
+
+	c.result = result
+	c.checkedAt = time.Now()
+	return result
+}
+
+// runCheck runs check, returning when it finishes or ctx expires, whichever
+// comes first, so a check that ignores its context cannot block readiness
+func runCheck(ctx context.Context, check CheckFunc) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+}
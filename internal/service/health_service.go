@@ -3,23 +3,78 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
 )
 
-// HealthService provides health and readiness checks
+// HealthService provides startup, liveness and readiness checks
 type HealthService struct {
 	cfg       *config.Config
 	startTime time.Time
+	checker   *HealthChecker
+	lifecycle *Lifecycle
+	watchdog  *Watchdog
+}
+
+// HealthConfig holds health service configuration
+type HealthConfig struct {
+	CacheTTL time.Duration // How long dependency check results are reused
+}
+
+// DefaultHealthConfig returns default health service configuration
+func DefaultHealthConfig() HealthConfig {
+	return HealthConfig{
+		CacheTTL: time.Second,
+	}
 }
 
 // NewHealthService creates a new health service
 func NewHealthService(cfg *config.Config) *HealthService {
-	return &HealthService{
+	return NewHealthServiceWithConfig(cfg, DefaultHealthConfig())
+}
+
+// NewHealthServiceWithConfig creates a health service with custom configuration.
+// Connectivity checks are registered for the enabled database and Redis clients.
+func NewHealthServiceWithConfig(cfg *config.Config, healthCfg HealthConfig) *HealthService {
+	s := &HealthService{
 		cfg:       cfg,
 		startTime: time.Now(),
+		checker:   NewHealthChecker(healthCfg.CacheTTL),
+		lifecycle: NewLifecycle(),
+		watchdog:  NewWatchdog(),
+	}
+
+	// Only dial the port; app.New replaces the redis check with a ping when it
+	// holds a client
+	if cfg.Clients.Database.Enabled {
+		s.checker.Register("database", DialCheck(cfg.Clients.Database.URL, "5432"), DefaultCheckConfig())
+	}
+	if cfg.Clients.Redis.Enabled {
+		s.checker.Register("redis", DialCheck(cfg.Clients.Redis.URL, "6379"), DefaultCheckConfig())
 	}
+
+	return s
+}
+
+// Checker returns the registry of readiness checks
+func (s *HealthService) Checker() *HealthChecker {
+	return s.checker
+}
+
+// Lifecycle returns the lifecycle that gates startup and readiness
+func (s *HealthService) Lifecycle() *Lifecycle {
+	return s.lifecycle
+}
+
+// Watchdog returns the event loop heartbeats that liveness is based on
+func (s *HealthService) Watchdog() *Watchdog {
+	return s.watchdog
 }
 
 // HealthStatus represents the health status of a component
@@ -33,11 +88,12 @@ type HealthStatus struct {
 	Details     map[string]interface{} `json:"details,omitempty"`
 }
 
-// GetHealth returns the current health status
+// GetHealth returns the current liveness status. The service is unhealthy
+// while any watchdog heartbeat is overdue, meaning an event loop is wedged.
 func (s *HealthService) GetHealth(ctx context.Context) (*HealthStatus, error) {
 	uptime := time.Since(s.startTime)
 
-	return &HealthStatus{
+	health := &HealthStatus{
 		Status:      "healthy",
 		Timestamp:   time.Now().Unix(),
 		Service:     s.cfg.Service.Name,
@@ -47,7 +103,42 @@ func (s *HealthService) GetHealth(ctx context.Context) (*HealthStatus, error) {
 		Details: map[string]interface{}{
 			"uptime_seconds": uptime.Seconds(),
 			"go_version":     getGoVersion(),
+			"state":          s.lifecycle.State().String(),
 		},
+	}
+
+	if stalled := s.watchdog.Stalled(); len(stalled) > 0 {
+		loops := make(map[string]string, len(stalled))
+		for name, since := range stalled {
+			loops[name] = since.Round(time.Millisecond).String()
+		}
+		health.Status = "unhealthy"
+		health.Details["stalled_loops"] = loops
+	}
+
+	return health, nil
+}
+
+// StartupStatus represents the startup status
+type StartupStatus struct {
+	Started    bool            `json:"started"`
+	State      string          `json:"state"`
+	Timestamp  int64           `json:"timestamp"`
+	Components map[string]bool `json:"components"`
+	Service    string          `json:"service"`
+}
+
+// GetStartup returns whether the service has finished starting, i.e. every
+// registered component has reported initialized
+func (s *HealthService) GetStartup(ctx context.Context) (*StartupStatus, error) {
+	state := s.lifecycle.State()
+
+	return &StartupStatus{
+		Started:    state != StateStarting,
+		State:      state.String(),
+		Timestamp:  time.Now().Unix(),
+		Components: s.lifecycle.Components(),
+		Service:    s.cfg.Service.Name,
 	}, nil
 }
 
@@ -61,56 +152,36 @@ type ReadinessStatus struct {
 
 // Check represents a readiness check result
 type Check struct {
-	Status  string `json:"status"`
-	Message string `json:"message,omitempty"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+	Critical bool   `json:"critical"`
+	Duration string `json:"duration,omitempty"`
 }
 
-// GetReadiness returns the current readiness status
+// GetReadiness returns the current readiness status. The service is ready
+// while its lifecycle is in the ready state and every critical check passes.
 func (s *HealthService) GetReadiness(ctx context.Context) (*ReadinessStatus, error) {
-	checks := make(map[string]Check)
-	ready := true
-
-	// Check if service has been up for minimum time
-	if time.Since(s.startTime) < 5*time.Second {
-		checks["startup"] = Check{
-			Status:  "not_ready",
-			Message: "Service is still starting up",
-		}
-		ready = false
-	} else {
-		checks["startup"] = Check{
-			Status: "ready",
+	checks, ready := s.checker.Check(ctx)
+
+	lifecycle := Check{Status: "ready", Critical: true}
+	switch state := s.lifecycle.State(); state {
+	case StateReady:
+	case StateStarting:
+		lifecycle.Status = "not_ready"
+		lifecycle.Message = "Service is starting"
+		if pending := s.lifecycle.Pending(); len(pending) > 0 {
+			lifecycle.Message += ", waiting for " + strings.Join(pending, ", ")
 		}
+	default:
+		lifecycle.Status = "not_ready"
+		lifecycle.Message = "Service is " + state.String()
 	}
-
-	// Check external dependencies if configured
-	if s.cfg.Clients.Database.Enabled {
-		if dbCheck := s.checkDatabase(ctx); !dbCheck {
-			checks["database"] = Check{
-				Status:  "not_ready",
-				Message: "Database connection not available",
-			}
-			ready = false
-		} else {
-			checks["database"] = Check{
-				Status: "ready",
-			}
-		}
+	if lifecycle.Status != "ready" {
+		ready = false
 	}
+	checks["lifecycle"] = lifecycle
 
-	if s.cfg.Clients.Redis.Enabled {
-		if redisCheck := s.checkRedis(ctx); !redisCheck {
-			checks["redis"] = Check{
-				Status:  "not_ready",
-				Message: "Redis connection not available",
-			}
-			ready = false
-		} else {
-			checks["redis"] = Check{
-				Status: "ready",
-			}
-		}
-	}
+	metrics.SetHealthStatus(ready)
 
 	return &ReadinessStatus{
 		Ready:     ready,
@@ -120,18 +191,26 @@ func (s *HealthService) GetReadiness(ctx context.Context) (*ReadinessStatus, err
 	}, nil
 }
 
-// checkDatabase checks database connectivity
-func (s *HealthService) checkDatabase(ctx context.Context) bool {
-	// TODO: Implement actual database check
-	// This would typically ping the database connection
-	return true
-}
+// DialCheck returns a check that opens a TCP connection to the host of a
+// client URL, using defaultPort when the URL has none
+func DialCheck(rawURL, defaultPort string) CheckFunc {
+	return func(ctx context.Context) error {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Hostname() == "" {
+			return fmt.Errorf("invalid URL")
+		}
+		port := u.Port()
+		if port == "" {
+			port = defaultPort
+		}
 
-// checkRedis checks Redis connectivity
-func (s *HealthService) checkRedis(ctx context.Context) bool {
-	// TODO: Implement actual Redis check
-	// This would typically ping the Redis connection
-	return true
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", net.JoinHostPort(u.Hostname(), port), err)
+		}
+		return conn.Close()
+	}
 }
 
 // getGoVersion returns the Go runtime version
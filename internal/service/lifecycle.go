@@ -0,0 +1,135 @@
+// Package service contains the business logic layer
+package service
+
+import (
+	"sort"
+	"sync"
+)
+
+// LifecycleState is a stage of the service lifecycle
+type LifecycleState int32
+
+// Lifecycle states, in the only order they are entered
+const (
+	StateStarting LifecycleState = iota
+	StateReady
+	StateDraining
+	StateStopped
+)
+
+// String returns the name of the state
+func (s LifecycleState) String() string {
+	switch s {
+	case StateStarting:
+		return "starting"
+	case StateReady:
+		return "ready"
+	case StateDraining:
+		return "draining"
+	case StateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// Lifecycle tracks the service through starting → ready → draining →
+// stopped. The service leaves starting once every registered component has
+// reported that it is initialized; states are never re-entered.
+type Lifecycle struct {
+	mu         sync.RWMutex
+	state      LifecycleState
+	components map[string]bool // component name → initialized
+}
+
+// NewLifecycle creates a lifecycle in the starting state
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{
+		state:      StateStarting,
+		components: make(map[string]bool),
+	}
+}
+
+// Register adds a component that must report initialized before the service
+// is ready. Components registered once the service is ready are listed but
+// don't affect the state.
+func (l *Lifecycle) Register(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.components[name]; !ok {
+		l.components[name] = false
+	}
+}
+
+// MarkInitialized records that a component is initialized, moving the
+// service to ready when it was the last one still starting
+func (l *Lifecycle) MarkInitialized(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.components[name] = true
+	if l.state != StateStarting {
+		return
+	}
+	for _, initialized := range l.components {
+		if !initialized {
+			return
+		}
+	}
+	l.state = StateReady
+}
+
+// Drain moves the service to draining, e.g. on a shutdown signal
+func (l *Lifecycle) Drain() {
+	l.advance(StateDraining)
+}
+
+// Stop moves the service to stopped once shutdown has completed
+func (l *Lifecycle) Stop() {
+	l.advance(StateStopped)
+}
+
+// advance moves to state unless the service is already past it
+func (l *Lifecycle) advance(state LifecycleState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.state < state {
+		l.state = state
+	}
+}
+
+// State returns the current state
+func (l *Lifecycle) State() LifecycleState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.state
+}
+
+// Components returns whether each registered component is initialized
+func (l *Lifecycle) Components() map[string]bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	components := make(map[string]bool, len(l.components))
+	for name, initialized := range l.components {
+		components[name] = initialized
+	}
+	return components
+}
+
+// Pending returns the components that are not initialized yet, in sorted order
+func (l *Lifecycle) Pending() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var pending []string
+	for name, initialized := range l.components {
+		if !initialized {
+			pending = append(pending, name)
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
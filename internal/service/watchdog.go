@@ -0,0 +1,99 @@
+// Package service contains the business logic layer
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// runtimeLoop is the heartbeat Watchdog.Run beats from its own goroutine
+const runtimeLoop = "runtime"
+
+// Watchdog detects event loops that have stopped making progress, such as a
+// loop blocked on a deadlocked mutex or a process starved by the scheduler.
+// Liveness fails while any registered heartbeat is overdue.
+type Watchdog struct {
+	mu         sync.RWMutex
+	heartbeats map[string]*Heartbeat
+}
+
+// Heartbeat is beaten by an event loop on every iteration
+type Heartbeat struct {
+	timeout time.Duration
+	last    atomic.Int64 // unix nanoseconds of the last beat
+}
+
+// NewWatchdog creates a watchdog with no heartbeats
+func NewWatchdog() *Watchdog {
+	return &Watchdog{heartbeats: make(map[string]*Heartbeat)}
+}
+
+// Register adds a heartbeat that is overdue when not beaten within timeout.
+// Names are unique: registering a name already in use fails, so one loop
+// cannot replace or unregister another's heartbeat.
+func (w *Watchdog) Register(name string, timeout time.Duration) (*Heartbeat, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, exists := w.heartbeats[name]; exists {
+		return nil, fmt.Errorf("heartbeat %q is already registered", name)
+	}
+
+	hb := &Heartbeat{timeout: timeout}
+	hb.Beat()
+	w.heartbeats[name] = hb
+	return hb, nil
+}
+
+// Unregister removes a heartbeat registered by the caller, e.g. when its
+// loop exits normally
+func (w *Watchdog) Unregister(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.heartbeats, name)
+}
+
+// Beat records that the loop made progress
+func (hb *Heartbeat) Beat() {
+	hb.last.Store(time.Now().UnixNano())
+}
+
+// Stalled returns how long each overdue heartbeat has gone without a beat
+func (w *Watchdog) Stalled() map[string]time.Duration {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	now := time.Now()
+	stalled := make(map[string]time.Duration)
+	for name, hb := range w.heartbeats {
+		if since := now.Sub(time.Unix(0, hb.last.Load())); since > hb.timeout {
+			stalled[name] = since
+		}
+	}
+	return stalled
+}
+
+// Run beats a runtime heartbeat every interval until ctx is cancelled,
+// reporting the process as wedged if it goes ten intervals without running.
+// It fails if the watchdog is already running.
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) error {
+	hb, err := w.Register(runtimeLoop, 10*interval)
+	if err != nil {
+		return err
+	}
+	defer w.Unregister(runtimeLoop)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			hb.Beat()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/lumitut/lumi-go/internal/config"
 	"github.com/lumitut/lumi-go/internal/httpapi"
 	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/lumitut/lumi-go/internal/service"
 	"github.com/stretchr/testify/require"
 )
 
@@ -50,7 +51,7 @@ func SetupTestServer(t *testing.T) (*httptest.Server, *gin.Engine, func()) {
 	require.NoError(t, err)
 
 	// Create server
-	server := httpapi.NewServer(cfg)
+	server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
 	require.NotNil(t, server)
 
 	// Get the router
@@ -84,6 +85,8 @@ func TestConfig() *config.Config {
 			RPCReadTimeout:          10 * time.Second,
 			RPCWriteTimeout:         10 * time.Second,
 			GracefulShutdownTimeout: 5 * time.Second,
+			PreStopDelay:            0, // No load balancer in tests
+			DrainTimeout:            time.Second,
 			EnablePProf:             true,
 			PProfPort:               "0",
 		},
@@ -0,0 +1,258 @@
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/httpapi"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/lumitut/lumi-go/internal/service"
+	"github.com/lumitut/lumi-go/tests/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const adminToken = "test-admin-token"
+
+// staticSource reloads a fixed configuration, validating it as the loader does
+type staticSource struct {
+	cfg *config.Config
+}
+
+func (s *staticSource) Reload() (*config.Config, error) {
+	if err := s.cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return s.cfg, nil
+}
+
+// setupAdminAPI returns the admin router of a server with rate limiting enabled
+func setupAdminAPI(t *testing.T) (*gin.Engine, *httpapi.Server, *config.Watcher, *staticSource) {
+	t.Helper()
+	cfg, cleanup := helpers.SetupTest(t)
+	t.Cleanup(cleanup)
+	cfg.Middleware.RateLimitEnabled = true
+	cfg.Server.AdminToken = adminToken
+	// Updates are validated; nothing listens on these ports
+	cfg.Service.Environment = "development"
+	cfg.Server.HTTPPort = "8080"
+	cfg.Server.RPCPort = "8081"
+	cfg.Observability.MetricsPort = "0"
+	cfg.Server.PProfPort = "0"
+
+	server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+	source := &staticSource{cfg: cfg}
+	watcher := config.NewWatcherWithSource(source, nil, cfg)
+
+	api := httpapi.NewAdminAPI(cfg.Server.AdminToken, watcher)
+	api.AddRateLimiter("http", server.RateLimiter())
+
+	admins := server.AdminServers(api)
+	require.Len(t, admins, 1)
+	return admins[0].Router(), server, watcher, source
+}
+
+// adminRequest sends an authenticated admin request and decodes the response
+func adminRequest(t *testing.T, router *gin.Engine, method, path string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var result map[string]interface{}
+	if w.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	}
+	return w.Code, result
+}
+
+func TestAdminAPI_Authentication(t *testing.T) {
+	router, _, _, _ := setupAdminAPI(t)
+
+	for name, header := range map[string]string{
+		"missing":    "",
+		"wrong":      "Bearer nope",
+		"not bearer": "Basic " + adminToken,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, `Bearer realm="admin"`, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+
+	t.Run("disabled without a token", func(t *testing.T) {
+		cfg, cleanup := helpers.SetupTest(t)
+		defer cleanup()
+		cfg.Observability.MetricsPort = "0"
+		server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+		api := httpapi.NewAdminAPI("", config.NewWatcherWithSource(&staticSource{cfg: cfg}, nil, cfg))
+
+		admins := server.AdminServers(api)
+		require.Len(t, admins, 1)
+		w := httptest.NewRecorder()
+		admins[0].Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAdminAPI_LogLevel(t *testing.T) {
+	router, _, _, _ := setupAdminAPI(t)
+	defer func() { _ = logger.SetLevel("debug") }()
+
+	code, result := adminRequest(t, router, http.MethodPut, "/admin/log-level", map[string]string{"level": "warn"})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "warn", result["level"])
+
+	code, result = adminRequest(t, router, http.MethodGet, "/admin/log-level", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "warn", result["level"])
+
+	code, _ = adminRequest(t, router, http.MethodPut, "/admin/log-level", map[string]string{"level": "verbose"})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestAdminAPI_Features(t *testing.T) {
+	router, _, watcher, _ := setupAdminAPI(t)
+	var events []config.Event
+	watcher.Subscribe(func(event config.Event) { events = append(events, event) })
+
+	code, result := adminRequest(t, router, http.MethodPut, "/admin/features/maintenanceMode", map[string]bool{"enabled": true})
+	require.Equal(t, http.StatusOK, code, result)
+	assert.Equal(t, true, result["features"].(map[string]interface{})["maintenanceMode"])
+	assert.True(t, watcher.Config().Features.MaintenanceMode)
+	require.NotEmpty(t, events)
+	assert.IsType(t, config.FeaturesChanged{}, events[0])
+
+	code, _ = adminRequest(t, router, http.MethodPut, "/admin/features/unknown", map[string]bool{"enabled": true})
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = adminRequest(t, router, http.MethodPut, "/admin/features/maintenanceMode", map[string]string{})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestAdminAPI_RateLimits(t *testing.T) {
+	router, server, _, _ := setupAdminAPI(t)
+
+	// Spend a token as a client
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	server.Router().ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	code, result := adminRequest(t, router, http.MethodGet, "/admin/ratelimits", nil)
+	assert.Equal(t, http.StatusOK, code)
+	buckets := result["limiters"].(map[string]interface{})["http"].([]interface{})
+	require.Len(t, buckets, 1)
+	key := buckets[0].(map[string]interface{})["key"].(string)
+
+	code, result = adminRequest(t, router, http.MethodGet, "/admin/ratelimits/http/"+key, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, key, result["key"])
+
+	code, _ = adminRequest(t, router, http.MethodDelete, "/admin/ratelimits/http/"+key, nil)
+	assert.Equal(t, http.StatusNoContent, code)
+
+	code, _ = adminRequest(t, router, http.MethodGet, "/admin/ratelimits/http/"+key, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = adminRequest(t, router, http.MethodDelete, "/admin/ratelimits/rpc/"+key, nil)
+	assert.Equal(t, http.StatusNotFound, code, "disabled limiters are not exposed")
+}
+
+func TestAdminAPI_DistributedRateLimits(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	t.Cleanup(cleanup)
+	redisServer := miniredis.RunT(t)
+	cfg.Clients.Redis = config.RedisClientConfig{Enabled: true, URL: "redis://" + redisServer.Addr()}
+	cfg.Middleware.RateLimitEnabled = true
+	cfg.Middleware.RateLimitStore = "redis"
+	cfg.Middleware.RateLimitAlgorithm = "token_bucket"
+	cfg.Middleware.RateLimitFailureMode = "local"
+	cfg.Middleware.RateLimitPolicies = []config.RateLimitPolicyConfig{{Name: "examples", Rate: 10}}
+	cfg.Server.AdminToken = adminToken
+	cfg.Observability.MetricsPort = "0"
+	cfg.Server.PProfPort = "0"
+
+	client := middleware.NewRateLimitRedis(cfg)
+	require.NotNil(t, client)
+	defer client.Close()
+	server := httpapi.NewServerWithOptions(cfg, service.NewExampleService(), service.NewHealthService(cfg), httpapi.Options{Redis: client})
+	defer server.Shutdown(context.Background())
+
+	api := httpapi.NewAdminAPI(cfg.Server.AdminToken, config.NewWatcherWithSource(&staticSource{cfg: cfg}, nil, cfg))
+	api.AddRateLimiter("http", server.RateLimiter())
+	admins := server.AdminServers(api)
+	require.Len(t, admins, 1)
+	router := admins[0].Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	server.Router().ServeHTTP(httptest.NewRecorder(), req)
+	require.Len(t, redisServer.Keys(), 2, "the global limit and the policy")
+
+	code, result := adminRequest(t, router, http.MethodGet, "/admin/ratelimits/http/10.0.0.1", nil)
+	assert.Equal(t, http.StatusOK, code)
+	var policies []string
+	for _, bucket := range result["buckets"].([]interface{}) {
+		policies = append(policies, bucket.(map[string]interface{})["policy"].(string))
+	}
+	assert.Equal(t, []string{"examples", "global"}, policies)
+
+	// Resets reach the counters every replica shares
+	code, _ = adminRequest(t, router, http.MethodDelete, "/admin/ratelimits/http/10.0.0.1", nil)
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Empty(t, redisServer.Keys())
+}
+
+func TestAdminAPI_Config(t *testing.T) {
+	router, _, watcher, source := setupAdminAPI(t)
+
+	code, result := adminRequest(t, router, http.MethodGet, "/admin/config", nil)
+	assert.Equal(t, http.StatusOK, code)
+	server := result["server"].(map[string]interface{})
+	assert.Equal(t, "[REDACTED]", server["adminToken"])
+
+	// Reload picks up the source's configuration
+	reloaded := *watcher.Config()
+	reloaded.Middleware.RateLimitRate = 42
+	source.cfg = &reloaded
+	code, result = adminRequest(t, router, http.MethodPost, "/admin/config/reload", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "reloaded", result["status"])
+	assert.Equal(t, 42, watcher.Config().Middleware.RateLimitRate)
+
+	// Invalid configurations are rejected
+	invalid := reloaded
+	invalid.Service.Name = ""
+	source.cfg = &invalid
+	code, result = adminRequest(t, router, http.MethodPost, "/admin/config/reload", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, "reload_failed", result["error"])
+}
@@ -0,0 +1,93 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/lumitut/lumi-go/api/proto/v1"
+	"github.com/lumitut/lumi-go/internal/features"
+	"github.com/lumitut/lumi-go/internal/httpapi"
+	"github.com/lumitut/lumi-go/internal/rpcapi"
+	"github.com/lumitut/lumi-go/internal/service"
+	"github.com/lumitut/lumi-go/tests/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBetaFeatures(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+
+	// Beta features start off, and the file is watched for changes
+	path := filepath.Join(t.TempDir(), "flags.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"flags": {"enableBetaFeatures": {"enabled": false}}}`), 0o600))
+	flagsFile, err := features.NewFileProvider(path)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = flagsFile.Run(ctx) }()
+	flags := features.NewEvaluator(flagsFile)
+
+	examples := service.NewExampleService()
+	_, err = examples.CreateExample(ctx, "widget", "", map[string]string{"team": "blue"})
+	require.NoError(t, err)
+	health := service.NewHealthService(cfg)
+
+	httpServer := httpapi.NewServerWithOptions(cfg, examples, health, httpapi.Options{Features: flags})
+	defer httpServer.Close()
+	rpcServer := rpcapi.NewServerWithOptions(cfg, examples, health, rpcapi.Options{Features: flags})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = rpcServer.Serve(ctx, lis) }()
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		_ = rpcServer.Shutdown(shutdownCtx)
+	}()
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewExampleServiceClient(conn)
+
+	// Count the examples matching "blue", which only their metadata contains
+	listHTTP := func(user string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/examples?filter=blue", nil)
+		if user != "" {
+			req.Header.Set("X-User-ID", user)
+		}
+		w := httptest.NewRecorder()
+		httpServer.Router().ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var result struct {
+			Total int `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return result.Total
+	}
+	listRPC := func(user string) int {
+		callCtx := metadata.AppendToOutgoingContext(ctx, "x-user-id", user)
+		resp, err := client.ListExamples(callCtx, &pb.ListExamplesRequest{Filter: "blue"})
+		require.NoError(t, err)
+		return int(resp.GetTotal())
+	}
+
+	assert.Equal(t, 0, listHTTP("alice"))
+	assert.Equal(t, 0, listRPC("alice"))
+
+	// Turning the flag on for alice reaches both servers without a restart
+	require.NoError(t, os.WriteFile(path, []byte(`{"flags": {"enableBetaFeatures": {"enabled": true, "users": ["alice"]}}}`), 0o600))
+	assert.Eventually(t, func() bool { return listHTTP("alice") == 1 }, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 1, listRPC("alice"))
+	assert.Equal(t, 0, listHTTP("bob"), "the flag only targets alice")
+	assert.Equal(t, 0, listRPC("bob"))
+}
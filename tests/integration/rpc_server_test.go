@@ -0,0 +1,391 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/lumitut/lumi-go/api/proto/v1"
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/rpcapi"
+	"github.com/lumitut/lumi-go/internal/service"
+	"github.com/lumitut/lumi-go/tests/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// startRPCServer starts a gRPC server on a random local port and returns a client connection
+func startRPCServer(t *testing.T, configure ...func(*config.Config)) (*rpcapi.Server, *grpc.ClientConn) {
+	t.Helper()
+
+	cfg, cleanup := helpers.SetupTest(t)
+	t.Cleanup(cleanup)
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	server := rpcapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Serve(context.Background(), lis)
+	}()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, server.Shutdown(ctx))
+		assert.NoError(t, <-serverErr)
+	})
+
+	return server, conn
+}
+
+func TestRPCServerLifecycle(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+
+	cfg.Server.RPCPort = "0"
+	server := rpcapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+	assert.False(t, server.IsReady())
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Start(context.Background())
+	}()
+
+	require.Eventually(t, server.IsReady, time.Second, 10*time.Millisecond)
+	assert.NotNil(t, server.Addr())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, server.Shutdown(shutdownCtx))
+	assert.False(t, server.IsReady())
+
+	select {
+	case err := <-serverErr:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("RPC server did not stop within timeout")
+	}
+}
+
+func TestRPCHealthService(t *testing.T) {
+	_, conn := startRPCServer(t)
+	client := pb.NewHealthServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	health, err := client.GetHealth(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, pb.HealthResponse_HEALTHY, health.GetStatus())
+	assert.Equal(t, "test-service", health.GetService())
+	assert.Contains(t, health.GetDetails(), "uptime_seconds")
+
+	readiness, err := client.GetReadiness(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, "test-service", readiness.GetService())
+	assert.Contains(t, readiness.GetChecks(), "lifecycle")
+}
+
+func TestRPCHealthCheckProtocol(t *testing.T) {
+	server, conn := startRPCServer(t)
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("check", func(t *testing.T) {
+		// Readiness turns serving once the server has initialized
+		serving := func(service string) func() bool {
+			return func() bool {
+				resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+				return err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+			}
+		}
+		assert.Eventually(t, serving(""), 5*time.Second, 10*time.Millisecond)
+		assert.Eventually(t, serving("lumigo.api.v1.ExampleService"), 5*time.Second, 10*time.Millisecond)
+
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "lumigo.api.v1.HealthService"})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+		_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown.Service"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("watch reports shutdown", func(t *testing.T) {
+		stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "lumigo.api.v1.HealthService"})
+		require.NoError(t, err)
+
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+		shutdownErr := make(chan error, 1)
+		go func() {
+			shutdownErr <- server.Shutdown(ctx)
+		}()
+
+		resp, err = stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+
+		// Watch streams must not hold up the graceful stop
+		assert.NoError(t, <-shutdownErr)
+	})
+}
+
+func TestRPCReadinessChecks(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+
+	health := service.NewHealthServiceWithConfig(cfg, service.HealthConfig{})
+	failing := errors.New("connection refused")
+	var mu sync.Mutex
+	health.Checker().Register("database", func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return failing
+	}, service.DefaultCheckConfig())
+
+	server := rpcapi.NewServer(cfg, service.NewExampleService(), health)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = server.Serve(context.Background(), lis)
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	readiness, err := pb.NewHealthServiceClient(conn).GetReadiness(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.False(t, readiness.GetReady())
+	assert.Equal(t, pb.CheckResult_NOT_READY, readiness.GetChecks()["database"].GetStatus())
+	assert.Equal(t, "connection refused", readiness.GetChecks()["database"].GetMessage())
+
+	client := healthpb.NewHealthClient(conn)
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	// The standard health service follows once the dependency recovers
+	mu.Lock()
+	failing = nil
+	mu.Unlock()
+	assert.Eventually(t, func() bool {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		return err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestRPCReflection(t *testing.T) {
+	listServices := func(t *testing.T, conn *grpc.ClientConn) ([]string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		}))
+
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for _, svc := range resp.GetListServicesResponse().GetService() {
+			names = append(names, svc.GetName())
+		}
+		return names, nil
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		_, conn := startRPCServer(t, func(cfg *config.Config) {
+			cfg.Server.EnableReflection = true
+		})
+
+		names, err := listServices(t, conn)
+		require.NoError(t, err)
+		assert.Contains(t, names, "lumigo.api.v1.ExampleService")
+		assert.Contains(t, names, "grpc.health.v1.Health")
+	})
+
+	t.Run("disabled in production", func(t *testing.T) {
+		_, conn := startRPCServer(t, func(cfg *config.Config) {
+			cfg.Service.Environment = "production"
+			cfg.Server.EnableReflection = true
+		})
+
+		_, err := listServices(t, conn)
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
+
+func TestRPCExampleService(t *testing.T) {
+	_, conn := startRPCServer(t)
+	client := pb.NewExampleServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	created, err := client.CreateExample(ctx, &pb.CreateExampleRequest{
+		Name:        "first",
+		Description: "the first example",
+		Metadata:    map[string]string{"team": "core"},
+	})
+	require.NoError(t, err)
+	id := created.GetExample().GetId()
+	require.NotEmpty(t, id)
+	assert.NotNil(t, created.GetExample().GetCreatedAt())
+
+	got, err := client.GetExample(ctx, &pb.GetExampleRequest{Id: id})
+	require.NoError(t, err)
+	assert.Equal(t, "first", got.GetExample().GetName())
+	assert.Equal(t, "core", got.GetExample().GetMetadata()["team"])
+
+	updated, err := client.UpdateExample(ctx, &pb.UpdateExampleRequest{Id: id, Name: "renamed"})
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", updated.GetExample().GetName())
+	assert.Equal(t, "the first example", updated.GetExample().GetDescription())
+
+	_, err = client.CreateExample(ctx, &pb.CreateExampleRequest{Name: "second"})
+	require.NoError(t, err)
+
+	list, err := client.ListExamples(ctx, &pb.ListExamplesRequest{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, list.GetItems(), 1)
+	assert.Equal(t, int32(2), list.GetTotal())
+	assert.True(t, list.GetHasMore())
+
+	_, err = client.DeleteExample(ctx, &pb.DeleteExampleRequest{Id: id})
+	require.NoError(t, err)
+
+	_, err = client.GetExample(ctx, &pb.GetExampleRequest{Id: id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreateExample(ctx, &pb.CreateExampleRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRPCStreamExamples(t *testing.T) {
+	_, conn := startRPCServer(t)
+	client := pb.NewExampleServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.CreateExample(ctx, &pb.CreateExampleRequest{Name: "existing widget"})
+	require.NoError(t, err)
+
+	streamCtx, streamCancel := context.WithCancel(ctx)
+	stream, err := client.StreamExamples(streamCtx, &pb.StreamExamplesRequest{Filter: "widget"})
+	require.NoError(t, err)
+
+	// Snapshot of existing examples comes first
+	msg, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "existing widget", msg.GetExample().GetName())
+
+	// Non-matching changes are filtered, matching ones are pushed
+	_, err = client.CreateExample(ctx, &pb.CreateExampleRequest{Name: "gadget"})
+	require.NoError(t, err)
+	_, err = client.CreateExample(ctx, &pb.CreateExampleRequest{Name: "new widget"})
+	require.NoError(t, err)
+
+	msg, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "new widget", msg.GetExample().GetName())
+
+	streamCancel()
+	_, err = stream.Recv()
+	require.Error(t, err)
+	assert.NotEqual(t, io.EOF, err)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestRPCShutdownEndsStreams(t *testing.T) {
+	server, conn := startRPCServer(t, func(cfg *config.Config) {
+		cfg.Server.DrainTimeout = 200 * time.Millisecond
+	})
+	client := pb.NewExampleServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.CreateExample(ctx, &pb.CreateExampleRequest{Name: "widget"})
+	require.NoError(t, err)
+
+	stream, err := client.StreamExamples(ctx, &pb.StreamExamplesRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	start := time.Now()
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- server.Shutdown(ctx)
+	}()
+
+	// The stream is cancelled at the drain timeout rather than holding the
+	// shutdown until ctx expires
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.NoError(t, <-shutdownErr)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestRPCMaintenanceMode(t *testing.T) {
+	server, conn := startRPCServer(t, func(cfg *config.Config) {
+		cfg.Features.MaintenanceMode = true
+	})
+	examples := pb.NewExampleServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := examples.ListExamples(ctx, &pb.ListExamplesRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	// Health checks keep working
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	server.OnConfigChange(config.FeaturesChanged{New: config.FeaturesConfig{MaintenanceMode: false}})
+	_, err = examples.ListExamples(ctx, &pb.ListExamplesRequest{})
+	require.NoError(t, err)
+}
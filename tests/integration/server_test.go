@@ -4,14 +4,27 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/config"
 	"github.com/lumitut/lumi-go/internal/httpapi"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"github.com/lumitut/lumi-go/internal/rpcapi"
+	"github.com/lumitut/lumi-go/internal/service"
 	"github.com/lumitut/lumi-go/tests/helpers"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
 )
 
 func TestServerLifecycle(t *testing.T) {
@@ -23,7 +36,7 @@ func TestServerLifecycle(t *testing.T) {
 	cfg.Server.HTTPPort = "0" // Let the OS assign a port
 
 	// Create server
-	server := httpapi.NewServer(cfg)
+	server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
 	require.NotNil(t, server)
 
 	// Start server
@@ -58,13 +71,16 @@ func TestHealthEndpoints(t *testing.T) {
 	defer cleanup()
 
 	tests := []struct {
-		name     string
-		endpoint string
+		name       string
+		endpoint   string
+		wantCode   int
+		wantStatus string
 	}{
-		{"health endpoint", "/health"},
-		{"healthz endpoint", "/healthz"},
-		{"ready endpoint", "/ready"},
-		{"readyz endpoint", "/readyz"},
+		{"health endpoint", "/health", http.StatusOK, "healthy"},
+		{"healthz endpoint", "/healthz", http.StatusOK, "healthy"},
+		// The test server is never started, so it is not ready
+		{"ready endpoint", "/ready", http.StatusServiceUnavailable, "not_ready"},
+		{"readyz endpoint", "/readyz", http.StatusServiceUnavailable, "not_ready"},
 	}
 
 	for _, tt := range tests {
@@ -73,22 +89,182 @@ func TestHealthEndpoints(t *testing.T) {
 			require.NoError(t, err)
 			defer resp.Body.Close()
 
-			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
 
 			var result map[string]interface{}
 			err = json.NewDecoder(resp.Body).Decode(&result)
 			require.NoError(t, err)
 
-			if tt.endpoint == "/health" || tt.endpoint == "/healthz" {
-				assert.Equal(t, "healthy", result["status"])
-			} else {
-				assert.Equal(t, "ready", result["status"])
-			}
+			assert.Equal(t, tt.wantStatus, result["status"])
 			assert.NotNil(t, result["time"])
 		})
 	}
 }
 
+func TestReadinessEndpoints(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+
+	health := service.NewHealthServiceWithConfig(cfg, service.HealthConfig{})
+	server := httpapi.NewServer(cfg, service.NewExampleService(), health)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = server.Start(ctx)
+	}()
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	require.Eventually(t, server.IsReady, time.Second, 10*time.Millisecond)
+
+	getReadiness := func(t *testing.T) (int, map[string]interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return w.Code, result
+	}
+
+	t.Run("ready when all checks pass", func(t *testing.T) {
+		health.Checker().Register("cache", func(ctx context.Context) error { return nil }, service.DefaultCheckConfig())
+		defer health.Checker().Unregister("cache")
+
+		code, result := getReadiness(t)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ready", result["status"])
+		assert.Equal(t, true, result["ready"])
+		assert.Contains(t, result["checks"], "cache")
+	})
+
+	t.Run("not ready when a critical check fails", func(t *testing.T) {
+		health.Checker().Register("database", func(ctx context.Context) error {
+			return errors.New("connection refused")
+		}, service.DefaultCheckConfig())
+		defer health.Checker().Unregister("database")
+
+		code, result := getReadiness(t)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "not_ready", result["status"])
+
+		database := result["checks"].(map[string]interface{})["database"].(map[string]interface{})
+		assert.Equal(t, "not_ready", database["status"])
+		assert.Equal(t, "connection refused", database["message"])
+	})
+
+	t.Run("ready when only a non-critical check fails", func(t *testing.T) {
+		health.Checker().Register("search", func(ctx context.Context) error {
+			return errors.New("timeout")
+		}, service.CheckConfig{Critical: false})
+		defer health.Checker().Unregister("search")
+
+		code, _ := getReadiness(t)
+		assert.Equal(t, http.StatusOK, code)
+	})
+}
+
+func TestProbeEndpoints(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+
+	health := service.NewHealthServiceWithConfig(cfg, service.HealthConfig{})
+	server := httpapi.NewServer(cfg, service.NewExampleService(), health)
+
+	// A component that initializes after the HTTP server gates startup and readiness
+	health.Lifecycle().Register("cache")
+
+	probe := func(t *testing.T, path string) (int, map[string]interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return w.Code, result
+	}
+
+	code, result := probe(t, "/startupz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "starting", result["status"])
+	assert.Equal(t, map[string]interface{}{"http": false, "cache": false}, result["components"])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = server.Start(ctx)
+	}()
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	require.Eventually(t, server.IsReady, time.Second, 10*time.Millisecond)
+
+	// Listening is not enough while another component is starting
+	code, _ = probe(t, "/startupz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	code, result = probe(t, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	lifecycle := result["checks"].(map[string]interface{})["lifecycle"].(map[string]interface{})
+	assert.Equal(t, "Service is starting, waiting for cache", lifecycle["message"])
+
+	health.Lifecycle().MarkInitialized("cache")
+
+	code, result = probe(t, "/startupz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", result["status"])
+	code, _ = probe(t, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	// Draining fails readiness but not liveness
+	health.Lifecycle().Drain()
+	code, _ = probe(t, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	code, _ = probe(t, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	// A wedged event loop fails liveness
+	health.Watchdog().Register("stuck-loop", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	code, result = probe(t, "/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unhealthy", result["status"])
+	assert.Contains(t, result["details"], "stalled_loops")
+}
+
+func TestProbesNotRateLimited(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+	cfg.Middleware.RateLimitEnabled = true
+	cfg.Middleware.RateLimitType = "ip"
+	cfg.Middleware.RateLimitRate = 6
+	cfg.Middleware.RateLimitBurst = 1
+
+	server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+	defer server.Shutdown(context.Background())
+
+	for _, path := range []string{"/healthz", "/readyz", "/startupz", "/health", "/ready"} {
+		for i := 0; i < 5; i++ {
+			w := httptest.NewRecorder()
+			server.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.NotEqual(t, http.StatusTooManyRequests, w.Code, "%s request %d", path, i+1)
+		}
+	}
+
+	// API routes from the same client are still limited
+	codes := make([]int, 2)
+	for i := range codes {
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/examples", nil))
+		codes[i] = w.Code
+	}
+	assert.Equal(t, http.StatusTooManyRequests, codes[1])
+}
+
 func TestMetricsEndpoint(t *testing.T) {
 	// Setup test server
 	ts, _, cleanup := helpers.SetupTestServer(t)
@@ -133,86 +309,113 @@ func TestAPIEndpoints(t *testing.T) {
 	ts, _, cleanup := helpers.SetupTestServer(t)
 	defer cleanup()
 
-	t.Run("GET user", func(t *testing.T) {
-		resp, err := http.Get(ts.URL + "/api/v1/users/123")
+	client := &http.Client{}
+	var exampleID string
+
+	t.Run("CREATE example", func(t *testing.T) {
+		body := `{"name":"first","description":"created over REST","metadata":{"team":"core"}}`
+		req, err := http.NewRequest("POST", ts.URL+"/api/v1/examples", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
 		require.NoError(t, err)
 		defer resp.Body.Close()
 
-		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
-		var user map[string]interface{}
-		err = json.NewDecoder(resp.Body).Decode(&user)
+		var result map[string]map[string]interface{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
 		require.NoError(t, err)
 
-		assert.Equal(t, "123", user["id"])
-		assert.Equal(t, "john_doe", user["username"])
-		assert.NotNil(t, user["email"])
-		assert.NotNil(t, user["created_at"])
+		example := result["example"]
+		require.NotNil(t, example)
+		assert.NotEmpty(t, example["id"])
+		assert.Equal(t, "first", example["name"])
+		assert.Equal(t, "created over REST", example["description"])
+		assert.NotNil(t, example["created_at"])
+
+		exampleID, _ = example["id"].(string)
 	})
 
-	t.Run("LIST users", func(t *testing.T) {
-		resp, err := http.Get(ts.URL + "/api/v1/users")
+	t.Run("CREATE example with invalid data", func(t *testing.T) {
+		body := `{"description":"missing name"}`
+		req, err := http.NewRequest("POST", ts.URL+"/api/v1/examples", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-ID", "test-request-invalid")
+
+		resp, err := client.Do(req)
 		require.NoError(t, err)
 		defer resp.Body.Close()
 
-		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
 		var result map[string]interface{}
 		err = json.NewDecoder(resp.Body).Decode(&result)
 		require.NoError(t, err)
 
-		users, ok := result["users"].([]interface{})
-		require.True(t, ok)
-		assert.Len(t, users, 2)
-		assert.Equal(t, float64(2), result["total"])
+		assert.Equal(t, "invalid_request", result["error"])
+		assert.NotEmpty(t, result["message"])
+		assert.Equal(t, "test-request-invalid", result["request_id"])
+		assert.NotNil(t, result["timestamp"])
 	})
 
-	t.Run("CREATE user", func(t *testing.T) {
-		client := &http.Client{}
-		body := `{"username":"test_user","email":"test@example.com"}`
-		req, err := http.NewRequest("POST", ts.URL+"/api/v1/users", bytes.NewBufferString(body))
-		require.NoError(t, err)
-		req.Header.Set("Content-Type", "application/json")
+	t.Run("GET example", func(t *testing.T) {
+		require.NotEmpty(t, exampleID)
 
-		resp, err := client.Do(req)
+		resp, err := http.Get(ts.URL + "/api/v1/examples/" + exampleID)
 		require.NoError(t, err)
 		defer resp.Body.Close()
 
-		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-		var user map[string]interface{}
-		err = json.NewDecoder(resp.Body).Decode(&user)
+		var result map[string]map[string]interface{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
 		require.NoError(t, err)
 
-		assert.NotEmpty(t, user["id"])
-		assert.Equal(t, "test_user", user["username"])
-		assert.Equal(t, "test@example.com", user["email"])
+		assert.Equal(t, exampleID, result["example"]["id"])
+		assert.Equal(t, "first", result["example"]["name"])
 	})
 
-	t.Run("CREATE user with invalid data", func(t *testing.T) {
-		client := &http.Client{}
-		body := `{"username":"test_user"}` // Missing email
-		req, err := http.NewRequest("POST", ts.URL+"/api/v1/users", bytes.NewBufferString(body))
+	t.Run("GET missing example", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/api/v1/examples/does-not-exist")
 		require.NoError(t, err)
-		req.Header.Set("Content-Type", "application/json")
+		defer resp.Body.Close()
 
-		resp, err := client.Do(req)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		var result map[string]interface{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		require.NoError(t, err)
+
+		assert.Equal(t, "not_found", result["error"])
+	})
+
+	t.Run("LIST examples", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/api/v1/examples?limit=10&filter=first")
 		require.NoError(t, err)
 		defer resp.Body.Close()
 
-		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
 		var result map[string]interface{}
 		err = json.NewDecoder(resp.Body).Decode(&result)
 		require.NoError(t, err)
 
-		assert.Equal(t, "invalid_request", result["error"])
+		items, ok := result["items"].([]interface{})
+		require.True(t, ok)
+		assert.Len(t, items, 1)
+		assert.Equal(t, float64(1), result["total"])
+		assert.Equal(t, float64(10), result["limit"])
+		assert.Equal(t, false, result["has_more"])
 	})
 
-	t.Run("UPDATE user", func(t *testing.T) {
-		client := &http.Client{}
-		body := `{"username":"updated_user"}`
-		req, err := http.NewRequest("PUT", ts.URL+"/api/v1/users/123", bytes.NewBufferString(body))
+	t.Run("UPDATE example", func(t *testing.T) {
+		require.NotEmpty(t, exampleID)
+
+		body := `{"name":"renamed"}`
+		req, err := http.NewRequest("PUT", ts.URL+"/api/v1/examples/"+exampleID, bytes.NewBufferString(body))
 		require.NoError(t, err)
 		req.Header.Set("Content-Type", "application/json")
 
@@ -222,17 +425,17 @@ func TestAPIEndpoints(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-		var user map[string]interface{}
-		err = json.NewDecoder(resp.Body).Decode(&user)
+		var result map[string]map[string]interface{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
 		require.NoError(t, err)
 
-		assert.Equal(t, "123", user["id"])
-		assert.NotNil(t, user["updated_at"])
+		assert.Equal(t, exampleID, result["example"]["id"])
+		assert.Equal(t, "renamed", result["example"]["name"])
+		assert.NotNil(t, result["example"]["updated_at"])
 	})
 
-	t.Run("DELETE user", func(t *testing.T) {
-		client := &http.Client{}
-		req, err := http.NewRequest("DELETE", ts.URL+"/api/v1/users/123", nil)
+	t.Run("DELETE example", func(t *testing.T) {
+		req, err := http.NewRequest("DELETE", ts.URL+"/api/v1/examples/"+exampleID, nil)
 		require.NoError(t, err)
 
 		resp, err := client.Do(req)
@@ -241,6 +444,20 @@ func TestAPIEndpoints(t *testing.T) {
 
 		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
 	})
+
+	t.Run("unknown API route", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/api/v1/unknown")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		var result map[string]interface{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		require.NoError(t, err)
+
+		assert.Equal(t, "not_found", result["error"])
+	})
 }
 
 func TestMiddlewareIntegration(t *testing.T) {
@@ -250,7 +467,7 @@ func TestMiddlewareIntegration(t *testing.T) {
 
 	t.Run("correlation IDs", func(t *testing.T) {
 		client := &http.Client{}
-		req, err := http.NewRequest("GET", ts.URL+"/api/v1/users/123", nil)
+		req, err := http.NewRequest("GET", ts.URL+"/api/v1/examples", nil)
 		require.NoError(t, err)
 		req.Header.Set("X-Request-ID", "test-request-123")
 
@@ -268,7 +485,7 @@ func TestMiddlewareIntegration(t *testing.T) {
 
 		var lastResp *http.Response
 		for i := 0; i < 15; i++ {
-			req, err := http.NewRequest("GET", ts.URL+"/api/v1/users/123", nil)
+			req, err := http.NewRequest("GET", ts.URL+"/api/v1/examples", nil)
 			require.NoError(t, err)
 
 			resp, err := client.Do(req)
@@ -297,6 +514,162 @@ func TestMiddlewareIntegration(t *testing.T) {
 	})
 }
 
+func TestDistributedRateLimiting(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+	redisServer := miniredis.RunT(t)
+	cfg.Clients.Redis = config.RedisClientConfig{Enabled: true, URL: "redis://" + redisServer.Addr()}
+	cfg.Middleware.RateLimitEnabled = true
+	cfg.Middleware.RateLimitType = "ip"
+	cfg.Middleware.RateLimitRate = 60
+	cfg.Middleware.RateLimitBurst = 10
+	cfg.Middleware.RateLimitStore = "redis"
+	cfg.Middleware.RateLimitAlgorithm = "token_bucket"
+	cfg.Middleware.RateLimitFailureMode = "local"
+
+	client := middleware.NewRateLimitRedis(cfg)
+	require.NotNil(t, client)
+	defer client.Close()
+
+	// Two replicas share one budget per client
+	replicas := []*httpapi.Server{
+		httpapi.NewServerWithOptions(cfg, service.NewExampleService(), service.NewHealthService(cfg), httpapi.Options{Redis: client}),
+		httpapi.NewServerWithOptions(cfg, service.NewExampleService(), service.NewHealthService(cfg), httpapi.Options{Redis: client}),
+	}
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		w := httptest.NewRecorder()
+		replicas[i%2].Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/examples", nil))
+		if w.Code == http.StatusOK {
+			allowed++
+		} else {
+			assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		}
+	}
+	assert.Equal(t, 10, allowed)
+
+	// Without Redis each replica limits on its own
+	redisServer.Close()
+	w := httptest.NewRecorder()
+	replicas[0].Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/examples", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRateLimitPolicies(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+	cfg.Middleware.RateLimitEnabled = true
+	cfg.Middleware.RateLimitType = "ip"
+	cfg.Middleware.RateLimitRate = 600
+	cfg.Middleware.RateLimitPolicies = []config.RateLimitPolicyConfig{
+		{Name: "examples", Routes: []string{"/api/v1/examples*"}, Methods: []string{"GET"}, Rate: 2},
+	}
+	cfg.Middleware.RateLimitPlans = map[string]config.RateLimitPlanConfig{"free": {Rate: 1, Window: time.Hour}}
+	cfg.Middleware.RateLimitAPIKeyPlans = []string{"free-key=free"}
+	cfg.Middleware.RateLimitHeaders = "both"
+
+	server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+	defer server.Shutdown(context.Background())
+
+	get := func(path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, req)
+		return w
+	}
+
+	// The route policy is stricter than the global limit
+	for i := 0; i < 2; i++ {
+		w := get("/api/v1/examples", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, `"examples";q=2;w=60`, w.Header().Get("RateLimit-Policy"))
+	}
+	w := get("/api/v1/examples", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Regexp(t, `^"examples";r=0;t=\d+$`, w.Header().Get("RateLimit"))
+	assert.NotEqual(t, "0", w.Header().Get("Retry-After"))
+
+	// API keys on a plan share its quota across routes
+	assert.NotEqual(t, http.StatusTooManyRequests, get("/api/v1/other", "free-key").Code)
+	assert.Equal(t, http.StatusTooManyRequests, get("/api/v1/other", "free-key").Code)
+	assert.NotEqual(t, http.StatusTooManyRequests, get("/api/v1/other", "").Code)
+}
+
+func TestRateLimitReload(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+	cfg.Middleware.RateLimitEnabled = true
+	cfg.Middleware.RateLimitType = "ip"
+	cfg.Middleware.RateLimitRate = 6
+	cfg.Middleware.RateLimitBurst = 1
+
+	server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+	defer server.Shutdown(context.Background())
+
+	// Each request comes from a fresh client so only the burst limits it
+	get := func(client string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/examples", nil)
+		req.RemoteAddr = client + ":1234"
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, req)
+		return w.Code
+	}
+	allowed := func(client string) int {
+		n := 0
+		for get(client) != http.StatusTooManyRequests {
+			n++
+		}
+		return n
+	}
+	assert.Equal(t, 1, allowed("10.0.0.1"))
+
+	server.OnConfigChange(config.RateLimitChanged{Rate: 6, Burst: 3, Type: "ip"})
+	assert.Equal(t, 3, allowed("10.0.0.2"), "the reloaded burst applies")
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+	cfg.Middleware.RateLimitEnabled = false
+	cfg.Middleware.MaintenanceRetryAfter = time.Minute
+	cfg.Middleware.MaintenanceAllowAPIKeys = []string{"ops-key"}
+
+	server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+	serve := func(path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, serve("/api/v1/examples", "").Code)
+
+	// Toggled at runtime, as by the admin API or a reload
+	server.OnConfigChange(config.FeaturesChanged{New: config.FeaturesConfig{MaintenanceMode: true}})
+
+	w := serve("/api/v1/examples", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "maintenance", result["error"])
+
+	// Ops routes and allowlisted clients are still served
+	assert.Equal(t, http.StatusOK, serve("/healthz", "").Code)
+	assert.Equal(t, http.StatusOK, serve("/version", "").Code)
+	assert.Equal(t, http.StatusOK, serve("/api/v1/examples", "ops-key").Code)
+
+	server.OnConfigChange(config.FeaturesChanged{New: config.FeaturesConfig{MaintenanceMode: false}})
+	assert.Equal(t, http.StatusOK, serve("/api/v1/examples", "").Code)
+}
+
 func TestPprofEndpoints(t *testing.T) {
 	// Setup test server
 	ts, _, cleanup := helpers.SetupTestServer(t)
@@ -328,13 +701,101 @@ func TestPprofEndpoints(t *testing.T) {
 	}
 }
 
+func TestAdminServers(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+	cfg.Server.AdminHealth = true
+	cfg.Server.AdminOnly = true
+	cfg.Observability.MetricsPort = "0"
+	cfg.Server.PProfPort = "0"
+
+	health := service.NewHealthServiceWithConfig(cfg, service.HealthConfig{})
+	server := httpapi.NewServer(cfg, service.NewExampleService(), health)
+
+	// Matching ports share one listener
+	admins := server.AdminServers(nil)
+	require.Len(t, admins, 1)
+	admin := admins[0]
+	assert.Equal(t, "admin:0", admin.Name())
+
+	go func() {
+		_ = admin.Start(context.Background())
+	}()
+	defer func() {
+		require.NoError(t, admin.Stop(context.Background()))
+	}()
+	require.Eventually(t, admin.Ready, time.Second, 10*time.Millisecond)
+	require.NotNil(t, admin.Addr())
+	assert.True(t, admin.Addr().(*net.TCPAddr).IP.IsLoopback(), "admin listeners default to loopback")
+
+	get := func(t *testing.T, path string) int {
+		t.Helper()
+		resp, err := http.Get("http://" + admin.Addr().String() + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("serves metrics, pprof and probes", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get(t, "/metrics"))
+		assert.Equal(t, http.StatusOK, get(t, "/debug/pprof/"))
+		assert.Equal(t, http.StatusOK, get(t, "/healthz"))
+		assert.Equal(t, http.StatusServiceUnavailable, get(t, "/readyz"), "the public server is not started")
+	})
+
+	t.Run("keeps metrics and pprof off the public router", func(t *testing.T) {
+		for _, path := range []string{"/metrics", "/debug/pprof/"} {
+			w := httptest.NewRecorder()
+			server.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusNotFound, w.Code, path)
+		}
+
+		w := httptest.NewRecorder()
+		server.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("separate ports get separate listeners", func(t *testing.T) {
+		cfg := helpers.TestConfig()
+		cfg.Observability.MetricsPort = "9090"
+		cfg.Server.PProfPort = "6060"
+		server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+
+		var names []string
+		for _, admin := range server.AdminServers(nil) {
+			names = append(names, admin.Name())
+		}
+		assert.Equal(t, []string{"admin:9090", "admin:6060"}, names)
+	})
+}
+
+func TestCloseWithoutStart(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	defer cleanup()
+	cfg.Middleware.RateLimitEnabled = true
+	cfg.Middleware.RateLimitPolicies = []config.RateLimitPolicyConfig{{Name: "examples", Rate: 10}}
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	// Servers built but never started still stop their limiters' goroutines
+	httpServer := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+	rpcServer := rpcapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+	require.NotNil(t, httpServer.RateLimiter())
+	require.NotNil(t, rpcServer.RateLimiter())
+
+	assert.NoError(t, httpServer.Close())
+	assert.NoError(t, rpcServer.Close())
+	// Closing again is a no-op
+	assert.NoError(t, httpServer.Close())
+	assert.NoError(t, rpcServer.Close())
+}
+
 func TestGracefulShutdown(t *testing.T) {
 	// Setup
 	cfg, cleanup := helpers.SetupTest(t)
 	defer cleanup()
 
 	// Create and start server
-	server := httpapi.NewServer(cfg)
+	server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -366,3 +827,102 @@ func TestGracefulShutdown(t *testing.T) {
 		t.Fatal("Server did not stop within timeout")
 	}
 }
+
+func TestGracefulShutdownDraining(t *testing.T) {
+	// serve starts a server with the given handler at /work and returns its URL
+	serve := func(t *testing.T, cfg *config.Config, handler gin.HandlerFunc) (*httpapi.Server, string) {
+		t.Helper()
+		server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+		server.Router().GET("/work", handler)
+
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go func() {
+			_ = server.Serve(context.Background(), lis)
+		}()
+		require.Eventually(t, server.IsReady, time.Second, 10*time.Millisecond)
+		return server, "http://" + lis.Addr().String() + "/work"
+	}
+
+	shutdownCount := func(outcome string) float64 {
+		return testutil.ToFloat64(metrics.Get().HTTPShutdownRequests.WithLabelValues(outcome))
+	}
+
+	t.Run("waits for in-flight requests", func(t *testing.T) {
+		cfg, cleanup := helpers.SetupTest(t)
+		defer cleanup()
+
+		started := make(chan struct{})
+		server, url := serve(t, cfg, func(c *gin.Context) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			c.String(http.StatusOK, "done")
+		})
+
+		respCh := make(chan *http.Response, 1)
+		go func() {
+			resp, err := http.Get(url)
+			assert.NoError(t, err)
+			respCh <- resp
+		}()
+		<-started
+
+		drained := shutdownCount("drained")
+		require.NoError(t, server.Shutdown(context.Background()))
+		assert.Equal(t, drained+1, shutdownCount("drained"))
+
+		resp := <-respCh
+		require.NotNil(t, resp)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("closes long-lived requests at the drain deadline", func(t *testing.T) {
+		cfg, cleanup := helpers.SetupTest(t)
+		defer cleanup()
+		cfg.Server.DrainTimeout = 100 * time.Millisecond
+
+		started := make(chan struct{})
+		closed := make(chan struct{})
+		server, url := serve(t, cfg, func(c *gin.Context) {
+			// A stream that only ends when the client or server goes away
+			c.Status(http.StatusOK)
+			c.Writer.Flush()
+			close(started)
+			<-c.Request.Context().Done()
+			close(closed)
+		})
+
+		go func() {
+			resp, err := http.Get(url)
+			if err == nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		}()
+		<-started
+
+		cut := shutdownCount("cut")
+		start := time.Now()
+		require.NoError(t, server.Shutdown(context.Background()))
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, cut+1, shutdownCount("cut"))
+
+		select {
+		case <-closed:
+		default:
+			t.Fatal("long-lived request was not told to close")
+		}
+	})
+
+	t.Run("skips the pre-stop delay when never ready", func(t *testing.T) {
+		cfg, cleanup := helpers.SetupTest(t)
+		defer cleanup()
+		cfg.Server.PreStopDelay = time.Minute
+
+		server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, server.Shutdown(ctx))
+	})
+}
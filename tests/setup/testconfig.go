@@ -18,6 +18,7 @@ import (
 	"github.com/lumitut/lumi-go/internal/httpapi"
 	"github.com/lumitut/lumi-go/internal/observability/logger"
 	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"github.com/lumitut/lumi-go/internal/service"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,6 +40,8 @@ func TestConfig() *config.Config {
 			RPCReadTimeout:          30 * time.Second,
 			RPCWriteTimeout:         30 * time.Second,
 			GracefulShutdownTimeout: 5 * time.Second,
+			PreStopDelay:            0, // No load balancer in tests
+			DrainTimeout:            time.Second,
 			EnablePProf:             true,
 			PProfPort:               "6060",
 		},
@@ -150,7 +153,7 @@ func SetupTestServer(t *testing.T) (*httptest.Server, *gin.Engine, func()) {
 	gin.SetMode(gin.TestMode)
 
 	// Create server
-	server := httpapi.NewServer(cfg)
+	server := httpapi.NewServer(cfg, service.NewExampleService(), service.NewHealthService(cfg))
 
 	// Create test server
 	ts := httptest.NewServer(server.Router())
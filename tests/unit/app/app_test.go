@@ -0,0 +1,211 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/app"
+	"github.com/lumitut/lumi-go/internal/service"
+	"github.com/lumitut/lumi-go/tests/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventLog records component starts and stops in order
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *eventLog) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+// fakeComponent runs until stopped, or fails with startErr
+type fakeComponent struct {
+	name     string
+	log      *eventLog
+	startErr error
+	hangStop bool
+
+	ready    atomic.Bool
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func newFake(name string, log *eventLog) *fakeComponent {
+	return &fakeComponent{name: name, log: log, stop: make(chan struct{})}
+}
+
+func (f *fakeComponent) Name() string { return f.name }
+
+func (f *fakeComponent) Start(ctx context.Context) error {
+	f.log.add("start:" + f.name)
+	if f.startErr != nil {
+		return f.startErr
+	}
+	f.ready.Store(true)
+	<-f.stop
+	return nil
+}
+
+func (f *fakeComponent) Stop(ctx context.Context) error {
+	f.log.add("stop:" + f.name)
+	if !f.hangStop {
+		f.stopOnce.Do(func() { close(f.stop) })
+	}
+	return nil
+}
+
+func (f *fakeComponent) Ready() bool { return f.ready.Load() }
+
+func newApplication(t *testing.T) (*app.Application, *service.HealthService) {
+	cfg, cleanup := helpers.SetupTest(t)
+	t.Cleanup(cleanup)
+	cfg.Server.GracefulShutdownTimeout = time.Second
+
+	health := service.NewHealthServiceWithConfig(cfg, service.HealthConfig{})
+	return app.NewEmpty(cfg, health), health
+}
+
+func TestApplication_DependencyOrder(t *testing.T) {
+	application, health := newApplication(t)
+	log := &eventLog{}
+
+	require.NoError(t, application.Register(newFake("api", log), "db"))
+	require.NoError(t, application.Register(newFake("db", log)))
+	require.NoError(t, application.Register(newFake("cache", log)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- application.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		return health.Lifecycle().State() == service.StateReady
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, true, application.Ready()["ready"])
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, service.StateStopped, health.Lifecycle().State())
+
+	// Dependencies start first and components stop in reverse start order
+	events := log.list()
+	require.Len(t, events, 6)
+	assert.ElementsMatch(t, []string{"start:db", "start:api", "start:cache"}, events[:3])
+	assert.Equal(t, "start:db", events[0])
+	assert.Equal(t, []string{"stop:cache", "stop:api", "stop:db"}, events[3:])
+}
+
+func TestApplication_FatalErrorStopsEverything(t *testing.T) {
+	application, _ := newApplication(t)
+	log := &eventLog{}
+
+	errBroken := errors.New("listen failed")
+	broken := newFake("broken", log)
+	broken.startErr = errBroken
+
+	require.NoError(t, application.Register(newFake("db", log)))
+	require.NoError(t, application.Register(broken, "db"))
+
+	err := application.Run(context.Background())
+	require.ErrorIs(t, err, errBroken)
+	assert.Equal(t, []string{"start:db", "start:broken", "stop:broken", "stop:db"}, log.list())
+}
+
+func TestApplication_UnexpectedExit(t *testing.T) {
+	application, _ := newApplication(t)
+
+	require.NoError(t, application.Register(app.NewWorker("oneshot", func(ctx context.Context) error {
+		return nil
+	})))
+
+	err := application.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "component oneshot exited unexpectedly")
+}
+
+func TestApplication_ShutdownTimeout(t *testing.T) {
+	cfg, cleanup := helpers.SetupTest(t)
+	t.Cleanup(cleanup)
+	cfg.Server.GracefulShutdownTimeout = 50 * time.Millisecond
+
+	health := service.NewHealthServiceWithConfig(cfg, service.HealthConfig{})
+	application := app.NewEmpty(cfg, health)
+
+	stuck := newFake("stuck", &eventLog{})
+	stuck.hangStop = true
+	require.NoError(t, application.Register(stuck))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		assert.Eventually(t, stuck.Ready, time.Second, 5*time.Millisecond)
+		cancel()
+	}()
+
+	err := application.Run(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "component stuck did not stop")
+}
+
+func TestApplication_InvalidDependencies(t *testing.T) {
+	t.Run("duplicate", func(t *testing.T) {
+		application, _ := newApplication(t)
+		require.NoError(t, application.Register(newFake("db", &eventLog{})))
+		assert.EqualError(t, application.Register(newFake("db", &eventLog{})),
+			"component db is already registered")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		application, _ := newApplication(t)
+		require.NoError(t, application.Register(newFake("api", &eventLog{}), "db"))
+		assert.EqualError(t, application.Run(context.Background()),
+			"component api depends on unknown component db")
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		application, _ := newApplication(t)
+		require.NoError(t, application.Register(newFake("a", &eventLog{}), "b"))
+		require.NoError(t, application.Register(newFake("b", &eventLog{}), "a"))
+		assert.EqualError(t, application.Run(context.Background()),
+			"component dependency cycle: a -> b -> a")
+	})
+}
+
+func TestWorker(t *testing.T) {
+	started := make(chan struct{})
+	worker := app.NewWorker("poller", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	})
+	assert.Equal(t, "poller", worker.Name())
+	assert.False(t, worker.Ready())
+
+	done := make(chan error, 1)
+	go func() { done <- worker.Start(context.Background()) }()
+	<-started
+	assert.True(t, worker.Ready())
+
+	require.NoError(t, worker.Stop(context.Background()))
+	require.NoError(t, <-done)
+	assert.False(t, worker.Ready())
+
+	// Worker errors name the worker
+	failing := app.NewWorker("sync", func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	assert.EqualError(t, failing.Start(context.Background()), "worker sync failed: boom")
+}
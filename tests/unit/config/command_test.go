@@ -0,0 +1,132 @@
+package config_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCommand runs the config command and returns its exit code and output
+func runCommand(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := config.RunCommand(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunCommand_Validate(t *testing.T) {
+	dir := t.TempDir()
+	valid := writeConfigFile(t, dir, "valid.json", `{"service": {"name": "cli-test"}}`)
+	invalid := writeConfigFile(t, dir, "invalid.yaml", "service:\n  name: \"\"\n  environment: qa\n")
+	unknown := writeConfigFile(t, dir, "unknown.json", `{"service": {"name": "cli-test", "colour": "blue"}}`)
+	secret := writeConfigFile(t, dir, "secret.json", `{
+		"service": {"name": "cli-test"},
+		"clients": {"database": {"url": "file:///nonexistent/db-url"}}
+	}`)
+
+	t.Run("valid files pass", func(t *testing.T) {
+		code, stdout, _ := runCommand("validate", valid)
+		assert.Equal(t, config.ExitOK, code)
+		assert.Contains(t, stdout, valid+": OK")
+	})
+
+	t.Run("invalid files fail with every problem listed", func(t *testing.T) {
+		code, stdout, stderr := runCommand("validate", valid, invalid)
+		assert.Equal(t, config.ExitInvalid, code)
+		assert.Contains(t, stdout, valid+": OK")
+		assert.Contains(t, stderr, invalid+": 2 configuration errors:")
+		assert.Contains(t, stderr, "service name is required")
+		assert.Contains(t, stderr, "invalid environment: qa")
+	})
+
+	t.Run("strict mode rejects unknown keys", func(t *testing.T) {
+		code, _, _ := runCommand("validate", unknown)
+		assert.Equal(t, config.ExitOK, code)
+
+		code, _, stderr := runCommand("validate", "-strict", unknown)
+		assert.Equal(t, config.ExitInvalid, code)
+		assert.Contains(t, stderr, `unknown key "service.colour"`)
+	})
+
+	t.Run("secret references can be left unresolved", func(t *testing.T) {
+		code, _, stderr := runCommand("validate", secret)
+		assert.Equal(t, config.ExitInvalid, code)
+		assert.Contains(t, stderr, "failed to resolve secrets")
+
+		code, _, _ = runCommand("validate", "-skip-secrets", secret)
+		assert.Equal(t, config.ExitOK, code)
+	})
+
+	t.Run("usage errors", func(t *testing.T) {
+		code, _, _ := runCommand("validate")
+		assert.Equal(t, config.ExitError, code)
+
+		code, _, _ = runCommand("validate", "-unknown-flag", valid)
+		assert.Equal(t, config.ExitError, code)
+
+		code, _, stderr := runCommand("lint", valid)
+		assert.Equal(t, config.ExitError, code)
+		assert.Contains(t, stderr, `unknown config command "lint"`)
+
+		code, _, _ = runCommand()
+		assert.Equal(t, config.ExitError, code)
+	})
+}
+
+func TestRunCommand_Print(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "lumi.toml", `[service]
+name = "cli-test"
+
+[clients.database]
+url = "postgres://user:s3cret@db:5432/app"
+`)
+
+	code, stdout, _ := runCommand("print", "-env", "staging", path)
+	require.Equal(t, config.ExitOK, code)
+	assert.Regexp(t, `service\.name\s+"cli-test"\s+`+path, stdout)
+	assert.Regexp(t, `service\.environment\s+"staging"\s+flag -env`, stdout)
+	assert.Contains(t, stdout, `"[REDACTED]"`)
+	assert.NotContains(t, stdout, "s3cret")
+
+	code, _, _ = runCommand("print", path, path)
+	assert.Equal(t, config.ExitError, code)
+}
+
+func TestRunCommand_Diff(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfigFile(t, dir, "lumi.json", `{
+		"service": {"name": "cli-test"},
+		"clients": {"database": {"url": "postgres://user:one@db/app"}}
+	}`)
+	writeConfigFile(t, dir, "lumi.production.json", `{
+		"server": {"httpPort": "9000"},
+		"clients": {"database": {"url": "postgres://user:two@db/app"}}
+	}`)
+	other := writeConfigFile(t, dir, "other.json", `{"service": {"name": "cli-test"}, "clients": {"database": {"url": "postgres://user:one@db/app"}}}`)
+
+	t.Run("identical configs", func(t *testing.T) {
+		code, stdout, _ := runCommand("diff", base, other)
+		assert.Equal(t, config.ExitOK, code)
+		assert.Empty(t, stdout)
+	})
+
+	t.Run("environments differ", func(t *testing.T) {
+		code, stdout, _ := runCommand("diff", "-left-env", "staging", "-right-env", "production", base)
+		assert.Equal(t, config.ExitInvalid, code)
+		assert.Regexp(t, `service\.environment\s+"staging"\s+"production"`, stdout)
+		assert.Regexp(t, `server\.httpPort\s+"8080"\s+"9000"`, stdout)
+
+		// Changed secrets are reported without their values
+		assert.Regexp(t, `clients\.database\.url\s+"\[REDACTED\]"\s+"\[REDACTED\]"`, stdout)
+		assert.NotContains(t, stdout, "user:two")
+		assert.NotContains(t, stdout, "server.rpcPort")
+	})
+
+	t.Run("load failures are errors", func(t *testing.T) {
+		code, _, stderr := runCommand("diff", base, "missing.json")
+		assert.Equal(t, config.ExitError, code)
+		assert.Contains(t, stderr, "missing.json")
+	})
+}
@@ -3,6 +3,7 @@ package config_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/lumitut/lumi-go/internal/config"
 	"github.com/stretchr/testify/assert"
@@ -118,6 +119,133 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid rate limit type",
 		},
+		{
+			name: "invalid rate limit headers",
+			config: &config.Config{
+				Service: config.ServiceConfig{
+					Name:        "test-service",
+					Environment: "development",
+					LogLevel:    "info",
+				},
+				Server: config.ServerConfig{
+					HTTPPort: "8080",
+					RPCPort:  "8081",
+				},
+				Middleware: config.MiddlewareConfig{
+					RateLimitEnabled: true,
+					RateLimitType:    "ip",
+					RateLimitHeaders: "draft",
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid rate limit headers: draft",
+		},
+		{
+			name: "shutdown timings exceed graceful shutdown timeout",
+			config: &config.Config{
+				Service: config.ServiceConfig{
+					Name:        "test-service",
+					Environment: "development",
+					LogLevel:    "info",
+				},
+				Server: config.ServerConfig{
+					HTTPPort:                "8080",
+					RPCPort:                 "8081",
+					GracefulShutdownTimeout: 10 * time.Second,
+					PreStopDelay:            5 * time.Second,
+					DrainTimeout:            10 * time.Second,
+				},
+			},
+			wantErr: true,
+			errMsg:  "pre-stop delay plus drain timeout (15s) must be less than the graceful shutdown timeout (10s)",
+		},
+		{
+			name: "invalid request timeout route",
+			config: &config.Config{
+				Service: config.ServiceConfig{
+					Name:        "test-service",
+					Environment: "development",
+					LogLevel:    "info",
+				},
+				Server: config.ServerConfig{
+					HTTPPort: "8080",
+					RPCPort:  "8081",
+				},
+				Middleware: config.MiddlewareConfig{
+					RequestTimeoutRoutes: []string{"/api/v1/examples=2s", "/api/v1/reports"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid request timeout route",
+		},
+		{
+			name: "redis rate limit store without redis",
+			config: &config.Config{
+				Service: config.ServiceConfig{
+					Name:        "test-service",
+					Environment: "development",
+					LogLevel:    "info",
+				},
+				Server: config.ServerConfig{
+					HTTPPort: "8080",
+					RPCPort:  "8081",
+				},
+				Middleware: config.MiddlewareConfig{
+					RateLimitEnabled:     true,
+					RateLimitType:        "ip",
+					RateLimitStore:       "redis",
+					RateLimitAlgorithm:   "token_bucket",
+					RateLimitFailureMode: "local",
+				},
+			},
+			wantErr: true,
+			errMsg:  "redis rate limit store requires clients.redis",
+		},
+		{
+			name: "invalid rate limit policies",
+			config: &config.Config{
+				Service: config.ServiceConfig{
+					Name:        "test-service",
+					Environment: "development",
+					LogLevel:    "info",
+				},
+				Server: config.ServerConfig{
+					HTTPPort: "8080",
+					RPCPort:  "8081",
+				},
+				Middleware: config.MiddlewareConfig{
+					RateLimitEnabled: true,
+					RateLimitType:    "ip",
+					RateLimitPolicies: []config.RateLimitPolicyConfig{
+						{Name: "writes", Rate: 10, Identity: "session"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "rate limit policy writes: invalid identity: session",
+		},
+		{
+			name: "rate limit API key with unknown plan",
+			config: &config.Config{
+				Service: config.ServiceConfig{
+					Name:        "test-service",
+					Environment: "development",
+					LogLevel:    "info",
+				},
+				Server: config.ServerConfig{
+					HTTPPort: "8080",
+					RPCPort:  "8081",
+				},
+				Middleware: config.MiddlewareConfig{
+					RateLimitEnabled:     true,
+					RateLimitType:        "ip",
+					RateLimitPlans:       map[string]config.RateLimitPlanConfig{"free": {Rate: 100}},
+					RateLimitAPIKeyPlans: []string{"secret-key=gold"},
+				},
+			},
+			wantErr: true,
+			errMsg:  `invalid rate limit API key plan 0: unknown plan "gold"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -297,6 +425,8 @@ func TestLoadWithDefaults(t *testing.T) {
 	assert.Equal(t, "development", cfg.Service.Environment)
 	assert.Equal(t, "8080", cfg.Server.HTTPPort)
 	assert.Equal(t, "8081", cfg.Server.RPCPort)
+	assert.False(t, cfg.Server.EnableReflection, "reflection is opt-in")
+	assert.Equal(t, "127.0.0.1", cfg.Server.AdminBindAddress)
 	assert.False(t, cfg.Clients.Database.Enabled)
 	assert.False(t, cfg.Clients.Redis.Enabled)
 	assert.False(t, cfg.Clients.Tracing.Enabled)
@@ -332,3 +462,81 @@ func TestConfigFromEnvironment(t *testing.T) {
 	assert.True(t, cfg.Clients.Database.Enabled)
 	assert.Equal(t, "postgres://testdb", cfg.Clients.Database.URL)
 }
+
+func TestRateLimitPoliciesConfig(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "lumi.json", `{
+  "service": {"name": "policy-test"},
+  "middleware": {
+    "rateLimitPolicies": [
+      {"name": "writes", "routes": ["/api/v1/*"], "methods": ["POST", "PUT"], "identity": "tenant", "rate": 100, "window": "1h"},
+      {"name": "search", "routes": ["/api/v1/search"], "algorithm": "sliding_window", "rate": 10}
+    ],
+    "rateLimitPlans": {
+      "Free": {"rate": 1000, "window": "24h"},
+      "pro": {"rate": 600, "burst": 100}
+    },
+    "rateLimitAPIKeyPlans": ["key-1=free", "key-2=Pro"]
+  }
+}`)
+
+	loader := config.NewConfigLoader()
+	loader.SetStrict(true)
+	cfg, err := loader.LoadFromFile(path)
+	require.NoError(t, err)
+
+	require.Len(t, cfg.Middleware.RateLimitPolicies, 2)
+	assert.Equal(t, config.RateLimitPolicyConfig{
+		Name:     "writes",
+		Routes:   []string{"/api/v1/*"},
+		Methods:  []string{"POST", "PUT"},
+		Identity: "tenant",
+		Rate:     100,
+		Window:   time.Hour,
+	}, cfg.Middleware.RateLimitPolicies[0])
+	assert.Equal(t, "sliding_window", cfg.Middleware.RateLimitPolicies[1].Algorithm)
+
+	assert.Equal(t, map[string]config.RateLimitPlanConfig{
+		"free": {Rate: 1000, Window: 24 * time.Hour},
+		"pro":  {Rate: 600, Burst: 100},
+	}, cfg.Middleware.RateLimitPlans)
+
+	plans, err := cfg.Middleware.RateLimitAPIKeyPlanMap()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key-1": "free", "key-2": "pro"}, plans)
+}
+
+func TestShutdownTimings(t *testing.T) {
+	load := func(t *testing.T, server string) (*config.Config, error) {
+		path := writeConfigFile(t, t.TempDir(), "lumi.json", `{"service": {"name": "shutdown-test"}, "server": `+server+`}`)
+		return config.NewConfigLoader().LoadFromFile(path)
+	}
+
+	t.Run("keeps the defaults when they fit", func(t *testing.T) {
+		cfg, err := load(t, `{}`)
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, cfg.Server.PreStopDelay)
+		assert.Equal(t, 20*time.Second, cfg.Server.DrainTimeout)
+	})
+
+	t.Run("derives unset timings from a shorter timeout", func(t *testing.T) {
+		cfg, err := load(t, `{"gracefulShutdownTimeout": "12s"}`)
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Second, cfg.Server.PreStopDelay)
+		assert.Equal(t, 8*time.Second, cfg.Server.DrainTimeout)
+	})
+
+	t.Run("fits unset timings around set ones", func(t *testing.T) {
+		cfg, err := load(t, `{"gracefulShutdownTimeout": "30s", "preStopDelay": "10s"}`)
+		require.NoError(t, err)
+		assert.Equal(t, 15*time.Second, cfg.Server.DrainTimeout)
+
+		cfg, err = load(t, `{"gracefulShutdownTimeout": "12s", "drainTimeout": "11s"}`)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), cfg.Server.PreStopDelay)
+	})
+
+	t.Run("rejects set timings over the timeout", func(t *testing.T) {
+		_, err := load(t, `{"gracefulShutdownTimeout": "12s", "drainTimeout": "20s"}`)
+		assert.ErrorContains(t, err, "must be less than the graceful shutdown timeout")
+	})
+}
@@ -0,0 +1,215 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configFixture is the same configuration written in each supported format
+type configFixture struct {
+	ext      string
+	valid    string
+	invalid  string // same keys as valid, with an invalid environment
+	unknown  string // valid keys plus an unknown server.colour
+	overlay  string // sets server.httpPort to 8100
+	position string // position of the unknown key
+}
+
+var configFixtures = map[string]configFixture{
+	"json": {
+		ext: ".json",
+		valid: `{
+  "service": {"name": "format-test", "environment": "staging"},
+  "server": {"httpPort": "9000", "httpReadTimeout": "20s"},
+  "middleware": {"corsAllowOrigins": ["https://a.example.com", "https://b.example.com"], "rateLimitRate": 30}
+}`,
+		invalid: `{"service": {"name": "format-test", "environment": "qa"}}`,
+		unknown: `{
+  "service": {"name": "format-test"},
+  "server": {"colour": "blue"}
+}`,
+		overlay:  `{"server": {"httpPort": "8100"}}`,
+		position: "3:14",
+	},
+	"yaml": {
+		ext: ".yaml",
+		valid: `service:
+  name: format-test
+  environment: staging
+server:
+  httpPort: "9000"
+  httpReadTimeout: 20s
+middleware:
+  corsAllowOrigins:
+    - https://a.example.com
+    - https://b.example.com
+  rateLimitRate: 30
+`,
+		invalid: `service:
+  name: format-test
+  environment: qa
+`,
+		unknown: `service:
+  name: format-test
+server:
+  colour: blue
+`,
+		overlay: `server:
+  httpPort: "8100"
+`,
+		position: "4:3",
+	},
+	"yml": {
+		ext: ".yml",
+		valid: `service: {name: format-test, environment: staging}
+server: {httpPort: "9000", httpReadTimeout: 20s}
+middleware:
+  corsAllowOrigins: [https://a.example.com, https://b.example.com]
+  rateLimitRate: 30
+`,
+		invalid: `service: {name: format-test, environment: qa}
+`,
+		unknown: `service: {name: format-test}
+
+server: {colour: blue}
+`,
+		overlay: `server: {httpPort: "8100"}
+`,
+		position: "3:10",
+	},
+	"toml": {
+		ext: ".toml",
+		valid: `[service]
+name = "format-test"
+environment = "staging"
+
+[server]
+httpPort = "9000"
+httpReadTimeout = "20s"
+
+[middleware]
+corsAllowOrigins = ["https://a.example.com", "https://b.example.com"]
+rateLimitRate = 30
+`,
+		invalid: `[service]
+name = "format-test"
+environment = "qa"
+`,
+		unknown: `service.name = "format-test"
+[server]
+colour = "blue"
+`,
+		overlay: `[server]
+httpPort = "8100"
+`,
+		position: "3:1",
+	},
+}
+
+// writeConfigFile writes content to name in dir and returns its path
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfigFormats(t *testing.T) {
+	for name, fixture := range configFixtures {
+		t.Run(name, func(t *testing.T) {
+			t.Run("loads values", func(t *testing.T) {
+				path := writeConfigFile(t, t.TempDir(), "lumi"+fixture.ext, fixture.valid)
+
+				cfg, err := config.NewConfigLoader().LoadFromFile(path)
+				require.NoError(t, err)
+
+				assert.Equal(t, "format-test", cfg.Service.Name)
+				assert.Equal(t, "staging", cfg.Service.Environment)
+				assert.Equal(t, "9000", cfg.Server.HTTPPort)
+				assert.Equal(t, 20*time.Second, cfg.Server.HTTPReadTimeout)
+				assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.Middleware.CORSAllowOrigins)
+				assert.Equal(t, 30, cfg.Middleware.RateLimitRate)
+
+				// Unset keys keep their defaults
+				assert.Equal(t, "8081", cfg.Server.RPCPort)
+			})
+
+			t.Run("environment variables override the file", func(t *testing.T) {
+				path := writeConfigFile(t, t.TempDir(), "lumi"+fixture.ext, fixture.valid)
+				t.Setenv("LUMI_SERVER_HTTPPORT", "9999")
+				t.Setenv("LUMI_MIDDLEWARE_RATELIMITRATE", "7")
+
+				cfg, err := config.NewConfigLoader().LoadFromFile(path)
+				require.NoError(t, err)
+				assert.Equal(t, "9999", cfg.Server.HTTPPort)
+				assert.Equal(t, 7, cfg.Middleware.RateLimitRate)
+			})
+
+			t.Run("validation is applied", func(t *testing.T) {
+				path := writeConfigFile(t, t.TempDir(), "lumi"+fixture.ext, fixture.invalid)
+
+				_, err := config.NewConfigLoader().LoadFromFile(path)
+				require.Error(t, err)
+				assert.EqualError(t, err, "invalid configuration: invalid environment: qa")
+			})
+
+			t.Run("strict mode reports unknown keys with their position", func(t *testing.T) {
+				path := writeConfigFile(t, t.TempDir(), "lumi"+fixture.ext, fixture.unknown)
+
+				loader := config.NewConfigLoader()
+				loader.SetStrict(true)
+				_, err := loader.LoadFromFile(path)
+				require.Error(t, err)
+
+				var validationErrs config.ValidationErrors
+				require.True(t, errors.As(err, &validationErrs))
+				require.Len(t, validationErrs, 1)
+				assert.Contains(t, validationErrs[0].Error(), "lumi"+fixture.ext+":"+fixture.position+`: unknown key "server.colour"`)
+			})
+
+			t.Run("overlays use the same format", func(t *testing.T) {
+				dir := t.TempDir()
+				path := writeConfigFile(t, dir, "lumi"+fixture.ext, fixture.valid)
+				writeConfigFile(t, dir, "lumi.staging"+fixture.ext, fixture.overlay)
+
+				cfg, err := config.NewConfigLoader().LoadFromFile(path)
+				require.NoError(t, err)
+				assert.Equal(t, "8100", cfg.Server.HTTPPort)
+				assert.Equal(t, 30, cfg.Middleware.RateLimitRate)
+			})
+		})
+	}
+}
+
+func TestConfigFormats_StrictSyntaxErrors(t *testing.T) {
+	tests := map[string]string{
+		"lumi.yaml": "service:\n  name: [unterminated\n",
+		"lumi.toml": "[service]\nname = \n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := writeConfigFile(t, t.TempDir(), name, content)
+
+			loader := config.NewConfigLoader()
+			loader.SetStrict(true)
+			_, err := loader.LoadFromFile(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), name)
+		})
+	}
+}
+
+func TestConfigFormats_UnsupportedExtension(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "lumi.ini", "[service]\nname = x\n")
+
+	_, err := config.NewConfigLoader().LoadFromFile(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unsupported config file format ".ini"`)
+}
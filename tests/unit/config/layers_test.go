@@ -0,0 +1,142 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLayers writes config files into a temp dir and returns the base file path
+func writeLayers(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return filepath.Join(dir, "lumi.json")
+}
+
+// settingSource returns the source recorded for a key
+func settingSource(settings []config.Setting, key string) string {
+	for _, s := range settings {
+		if s.Key == key {
+			return s.Source
+		}
+	}
+	return ""
+}
+
+func TestLayeredConfig(t *testing.T) {
+	base := `{
+		"service": {"name": "base-service", "environment": "staging"},
+		"server": {"httpPort": "8000", "rpcPort": "8001"},
+		"middleware": {"corsAllowOrigins": ["https://a.example.com", "https://b.example.com"], "rateLimitRate": 100}
+	}`
+	staging := `{
+		"server": {"httpPort": "8100"},
+		"middleware": {"corsAllowOrigins": ["https://staging.example.com"]}
+	}`
+	production := `{"server": {"httpPort": "8200"}}`
+	local := `{"middleware": {"rateLimitRate": 5}}`
+
+	t.Run("overlays are deep merged with lists replaced", func(t *testing.T) {
+		path := writeLayers(t, map[string]string{
+			"lumi.json":            base,
+			"lumi.staging.json":    staging,
+			"lumi.production.json": production,
+			"lumi.local.json":      local,
+		})
+
+		loader := config.NewConfigLoader()
+		cfg, err := loader.LoadFromFile(path)
+		require.NoError(t, err)
+
+		// Nested sections keep the keys the overlay does not set
+		assert.Equal(t, "base-service", cfg.Service.Name)
+		assert.Equal(t, "8100", cfg.Server.HTTPPort)
+		assert.Equal(t, "8001", cfg.Server.RPCPort)
+
+		// Lists are replaced, not appended
+		assert.Equal(t, []string{"https://staging.example.com"}, cfg.Middleware.CORSAllowOrigins)
+
+		// The local override wins over the environment overlay and base
+		assert.Equal(t, 5, cfg.Middleware.RateLimitRate)
+
+		settings := loader.Settings(cfg)
+		assert.Equal(t, path, settingSource(settings, "service.name"))
+		assert.Equal(t, filepath.Join(filepath.Dir(path), "lumi.staging.json"), settingSource(settings, "server.httpPort"))
+		assert.Equal(t, filepath.Join(filepath.Dir(path), "lumi.local.json"), settingSource(settings, "middleware.rateLimitRate"))
+		assert.Equal(t, "default", settingSource(settings, "service.logLevel"))
+	})
+
+	t.Run("environment override selects the overlay", func(t *testing.T) {
+		path := writeLayers(t, map[string]string{
+			"lumi.json":            base,
+			"lumi.staging.json":    staging,
+			"lumi.production.json": production,
+		})
+
+		parser := config.NewParser()
+		parser.SetEnvironment("production")
+		cfg, err := parser.LoadConfig(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "production", cfg.Service.Environment)
+		assert.Equal(t, "8200", cfg.Server.HTTPPort)
+		assert.Len(t, cfg.Middleware.CORSAllowOrigins, 2)
+		assert.Equal(t, "flag -env", settingSource(parser.Settings(cfg), "service.environment"))
+	})
+
+	t.Run("environment variables override every file", func(t *testing.T) {
+		path := writeLayers(t, map[string]string{
+			"lumi.json":         base,
+			"lumi.staging.json": staging,
+			"lumi.local.json":   local,
+		})
+		t.Setenv("LUMI_SERVER_HTTPPORT", "9999")
+
+		loader := config.NewConfigLoader()
+		cfg, err := loader.LoadFromFile(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "9999", cfg.Server.HTTPPort)
+		assert.Equal(t, "env LUMI_SERVER_HTTPPORT", settingSource(loader.Settings(cfg), "server.httpPort"))
+	})
+
+	t.Run("overlays are optional", func(t *testing.T) {
+		path := writeLayers(t, map[string]string{"lumi.json": base})
+
+		loader := config.NewConfigLoader()
+		cfg, err := loader.LoadFromFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "8000", cfg.Server.HTTPPort)
+
+		dir := filepath.Dir(path)
+		assert.Equal(t, []string{
+			path,
+			filepath.Join(dir, "lumi.staging.json"),
+			filepath.Join(dir, "lumi.local.json"),
+		}, loader.GetConfigFiles())
+	})
+}
+
+func TestWriteSettings(t *testing.T) {
+	cfg, err := config.LoadWithDefaults()
+	require.NoError(t, err)
+	cfg.Clients.Database.URL = "postgres://user:s3cret@db:5432/app"
+
+	var buf bytes.Buffer
+	require.NoError(t, config.WriteSettings(&buf, config.NewParser().Settings(cfg)))
+
+	output := buf.String()
+	assert.Contains(t, output, "KEY")
+	assert.Regexp(t, `server\.httpPort\s+"8080"\s+default`, output)
+	assert.Regexp(t, `server\.httpReadTimeout\s+"15s"\s+default`, output)
+	assert.Contains(t, output, `"[REDACTED]"`)
+	assert.NotContains(t, output, "s3cret")
+}
@@ -0,0 +1,148 @@
+package config_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaProperty returns the schema of a dotted key path
+func schemaProperty(t *testing.T, schema map[string]interface{}, path ...string) map[string]interface{} {
+	t.Helper()
+	node := schema
+	for _, key := range path {
+		properties, ok := node["properties"].(map[string]interface{})
+		require.True(t, ok, "no properties at %s", key)
+		node, ok = properties[key].(map[string]interface{})
+		require.True(t, ok, "no property %s", key)
+	}
+	return node
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := config.JSONSchema()
+	require.NoError(t, err)
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	environment := schemaProperty(t, schema, "service", "environment")
+	assert.Equal(t, "string", environment["type"])
+	assert.Equal(t, "development", environment["default"])
+	assert.Equal(t, []interface{}{"development", "staging", "production"}, environment["enum"])
+
+	rateLimitType := schemaProperty(t, schema, "middleware", "rateLimitType")
+	assert.Equal(t, []interface{}{"ip", "user", "api_key"}, rateLimitType["enum"])
+
+	rateLimitRate := schemaProperty(t, schema, "middleware", "rateLimitRate")
+	assert.Equal(t, "integer", rateLimitRate["type"])
+	assert.Equal(t, float64(60), rateLimitRate["default"])
+
+	timeout := schemaProperty(t, schema, "server", "httpReadTimeout")
+	assert.Equal(t, "string", timeout["type"])
+	assert.Equal(t, "15s", timeout["default"])
+	assert.NotEmpty(t, timeout["pattern"])
+
+	origins := schemaProperty(t, schema, "middleware", "corsAllowOrigins")
+	assert.Equal(t, "array", origins["type"])
+
+	database := schemaProperty(t, schema, "clients", "database")
+	assert.Equal(t, false, database["additionalProperties"])
+}
+
+func TestJSONSchemaIsUpToDate(t *testing.T) {
+	generated, err := config.JSONSchema()
+	require.NoError(t, err)
+
+	committed, err := os.ReadFile("../../../cmd/server/schema/lumi.schema.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(generated), string(committed), "run go generate ./internal/config to update lumi.schema.json")
+}
+
+func TestStrictMode(t *testing.T) {
+	load := func(t *testing.T, strict bool, content string) (*config.Config, error) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "lumi.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		loader := config.NewConfigLoader()
+		loader.SetStrict(strict)
+		return loader.LoadFromFile(path)
+	}
+
+	t.Run("unknown keys are reported with their position", func(t *testing.T) {
+		_, err := load(t, true, `{
+  "service": {
+    "name": "strict-test",
+    "colour": "blue"
+  },
+  "middleware": {"corsOrigins": ["https://example.com"]},
+  "typo": true
+}`)
+		require.Error(t, err)
+
+		var validationErrs config.ValidationErrors
+		require.True(t, errors.As(err, &validationErrs))
+		require.Len(t, validationErrs, 3)
+		assert.Contains(t, validationErrs[0].Error(), `lumi.json:4:5: unknown key "service.colour"`)
+		assert.Contains(t, validationErrs[1].Error(), `lumi.json:6:18: unknown key "middleware.corsOrigins"`)
+		assert.Contains(t, validationErrs[2].Error(), `lumi.json:7:3: unknown key "typo"`)
+	})
+
+	t.Run("known keys and $schema are accepted", func(t *testing.T) {
+		cfg, err := load(t, true, `{
+  "$schema": "./lumi.schema.json",
+  "service": {"name": "strict-test"},
+  "server": {"HTTPPORT": "9000"}
+}`)
+		require.NoError(t, err)
+		assert.Equal(t, "strict-test", cfg.Service.Name)
+		assert.Equal(t, "9000", cfg.Server.HTTPPort)
+	})
+
+	t.Run("syntax errors are reported with their position", func(t *testing.T) {
+		_, err := load(t, true, "{\n  \"service\": {\"name\": \"x\",}\n}")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "lumi.json:2:")
+	})
+
+	t.Run("unknown keys are ignored when not strict", func(t *testing.T) {
+		cfg, err := load(t, false, `{"service": {"name": "lenient", "colour": "blue"}}`)
+		require.NoError(t, err)
+		assert.Equal(t, "lenient", cfg.Service.Name)
+	})
+}
+
+func TestConfig_ValidateAggregatesErrors(t *testing.T) {
+	cfg := &config.Config{
+		Service: config.ServiceConfig{
+			Environment: "invalid",
+			LogLevel:    "verbose",
+		},
+		Server: config.ServerConfig{
+			HTTPPort: "8080",
+			RPCPort:  "0",
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+
+	var validationErrs config.ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+	assert.Len(t, validationErrs, 4)
+	assert.Contains(t, err.Error(), "4 configuration errors")
+	assert.Contains(t, err.Error(), "service name is required")
+	assert.Contains(t, err.Error(), "invalid environment: invalid")
+	assert.Contains(t, err.Error(), "invalid log level: verbose")
+	assert.Contains(t, err.Error(), "invalid RPC port")
+}
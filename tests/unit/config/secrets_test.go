@@ -0,0 +1,163 @@
+package config_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticSecretProvider serves secrets from a map, like a Vault backend would
+type staticSecretProvider map[string]string
+
+func (staticSecretProvider) Scheme() string { return "static" }
+
+func (p staticSecretProvider) Resolve(_ context.Context, ref string) (string, error) {
+	value, ok := p[ref]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", ref)
+	}
+	return value, nil
+}
+
+func TestSecretResolver(t *testing.T) {
+	ctx := context.Background()
+	secretFile := filepath.Join(t.TempDir(), "db_url")
+	require.NoError(t, os.WriteFile(secretFile, []byte("postgres://user:file-pass@db:5432/app\n"), 0o600))
+	t.Setenv("LUMI_TEST_REDIS_URL", "redis://:env-pass@redis:6379/0")
+
+	resolver := config.DefaultSecretResolver()
+	resolver.Register(staticSecretProvider{"db": "postgres://user:static-pass@db:5432/app"})
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "file reference", value: "file://" + secretFile, want: "postgres://user:file-pass@db:5432/app"},
+		{name: "env reference", value: "env://LUMI_TEST_REDIS_URL", want: "redis://:env-pass@redis:6379/0"},
+		{name: "custom provider", value: "static://db", want: "postgres://user:static-pass@db:5432/app"},
+		{name: "plain connection string", value: "postgres://localhost:5432/app", want: "postgres://localhost:5432/app"},
+		{name: "empty value", value: "", want: ""},
+		{name: "missing file", value: "file:///nonexistent/secret", wantErr: true},
+		{name: "missing env var", value: "env://LUMI_TEST_UNSET_SECRET", wantErr: true},
+		{name: "empty reference", value: "env://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(ctx, tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadResolvesSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "db_url")
+	require.NoError(t, os.WriteFile(secretFile, []byte("postgres://user:s3cret@db:5432/app\n"), 0o600))
+	t.Setenv("LUMI_TEST_REDIS_URL", "redis://:hunter2@redis:6379/0")
+
+	configPath := filepath.Join(dir, "lumi.json")
+	writeConfig := func(dbURL string) {
+		content := fmt.Sprintf(`{
+			"clients": {
+				"database": {"enabled": true, "url": %q},
+				"redis": {"enabled": true, "url": "env://LUMI_TEST_REDIS_URL"}
+			}
+		}`, dbURL)
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0o600))
+	}
+
+	t.Run("references are resolved", func(t *testing.T) {
+		writeConfig("file://" + secretFile)
+
+		cfg, err := config.NewConfigLoader().LoadFromFile(configPath)
+		require.NoError(t, err)
+
+		dbURL, _ := cfg.GetDatabaseURL()
+		redisURL, _ := cfg.GetRedisURL()
+		assert.Equal(t, "postgres://user:s3cret@db:5432/app", dbURL)
+		assert.Equal(t, "redis://:hunter2@redis:6379/0", redisURL)
+	})
+
+	t.Run("unresolvable reference fails the load", func(t *testing.T) {
+		writeConfig("file://" + filepath.Join(dir, "missing"))
+
+		_, err := config.NewConfigLoader().LoadFromFile(configPath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "clients.database.url")
+	})
+
+	t.Run("references are resolved again on reload", func(t *testing.T) {
+		writeConfig("file://" + secretFile)
+		loader := config.NewConfigLoader()
+		_, err := loader.LoadFromFile(configPath)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(secretFile, []byte("postgres://user:rotated@db:5432/app"), 0o600))
+		cfg, err := loader.Reload()
+		require.NoError(t, err)
+		assert.Equal(t, "postgres://user:rotated@db:5432/app", cfg.Clients.Database.URL)
+	})
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := &config.Config{
+		Clients: config.ClientsConfig{
+			Database: config.DatabaseClientConfig{Enabled: true, URL: "postgres://user:s3cret@db:5432/app"},
+			Redis:    config.RedisClientConfig{Enabled: true},
+			Tracing:  config.TracingClientConfig{Enabled: true, Endpoint: "otel:4317"},
+		},
+		Middleware: config.MiddlewareConfig{
+			MaintenanceAllowAPIKeys: []string{"ops-key"},
+		},
+	}
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, "[REDACTED]", redacted.Clients.Database.URL)
+	assert.Empty(t, redacted.Clients.Redis.URL)
+	assert.Equal(t, "otel:4317", redacted.Clients.Tracing.Endpoint)
+	assert.Equal(t, []string{"[REDACTED]"}, redacted.Middleware.MaintenanceAllowAPIKeys)
+
+	// The original is untouched
+	assert.Equal(t, "postgres://user:s3cret@db:5432/app", cfg.Clients.Database.URL)
+	assert.Equal(t, []string{"ops-key"}, cfg.Middleware.MaintenanceAllowAPIKeys)
+}
+
+func TestConfig_LogConfigNeverPrintsSecrets(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "log.json")
+	require.NoError(t, logger.Initialize(logger.Config{
+		Level:            "info",
+		Format:           "json",
+		SampleInitial:    100,
+		SampleThereafter: 100,
+		OutputPaths:      []string{logFile},
+	}))
+
+	cfg, err := config.LoadWithDefaults()
+	require.NoError(t, err)
+	cfg.Clients.Database = config.DatabaseClientConfig{Enabled: true, URL: "postgres://user:s3cret@db:5432/app"}
+	cfg.Clients.Redis = config.RedisClientConfig{Enabled: true, URL: "redis://:hunter2@redis:6379/0"}
+
+	cfg.LogConfig(context.Background())
+	require.NoError(t, logger.Sync())
+
+	output, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "Configuration loaded")
+	assert.NotContains(t, string(output), "s3cret")
+	assert.NotContains(t, string(output), "hunter2")
+}
@@ -0,0 +1,227 @@
+package config_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const watcherConfigTemplate = `{
+	"service": {"name": "watch-test", "environment": "development", "logLevel": "info"},
+	"observability": {"logLevel": "%s"},
+	"middleware": {"rateLimitRate": %d, "rateLimitBurst": 10, "corsAllowOrigins": ["https://a.example.com"]}
+}`
+
+func writeWatcherConfig(t *testing.T, path, logLevel string, rate int) {
+	t.Helper()
+	content := []byte(fmt.Sprintf(watcherConfigTemplate, logLevel, rate))
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+}
+
+// newTestWatcher loads a config file from a temp dir and watches it
+func newTestWatcher(t *testing.T) (*config.Watcher, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lumi.json")
+	writeWatcherConfig(t, path, "info", 100)
+
+	loader := config.NewConfigLoader()
+	cfg, err := loader.LoadFromFile(path)
+	require.NoError(t, err)
+
+	return config.NewWatcher(loader, cfg), path
+}
+
+// eventRecorder collects published events
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []config.Event
+}
+
+func (r *eventRecorder) record(event config.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) get() []config.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]config.Event(nil), r.events...)
+}
+
+func TestDiff(t *testing.T) {
+	old := &config.Config{
+		Observability: config.ObservabilityConfig{LogLevel: "info"},
+		Middleware: config.MiddlewareConfig{
+			RateLimitRate:    100,
+			RateLimitBurst:   10,
+			RateLimitType:    "ip",
+			CORSAllowOrigins: []string{"https://a.example.com"},
+		},
+	}
+
+	t.Run("no changes", func(t *testing.T) {
+		events := config.Diff(old, old)
+		require.Len(t, events, 1)
+		reloaded, ok := events[0].(config.Reloaded)
+		require.True(t, ok)
+		assert.Empty(t, reloaded.RestartRequired)
+	})
+
+	t.Run("runtime changes", func(t *testing.T) {
+		changed := *old
+		changed.Observability.LogLevel = "debug"
+		changed.Middleware.RateLimitRate = 200
+		changed.Middleware.CORSAllowOrigins = []string{"https://b.example.com"}
+		changed.Features.MaintenanceMode = true
+
+		events := config.Diff(old, &changed)
+		require.Len(t, events, 5)
+		assert.Equal(t, config.LogLevelChanged{Old: "info", New: "debug"}, events[0])
+		assert.Equal(t, config.RateLimitChanged{Rate: 200, Burst: 10, Type: "ip"}, events[1])
+		assert.Equal(t, config.CORSOriginsChanged{Origins: []string{"https://b.example.com"}}, events[2])
+		assert.Equal(t, config.FeaturesChanged{New: config.FeaturesConfig{MaintenanceMode: true}}, events[3])
+
+		reloaded := events[4].(config.Reloaded)
+		assert.Empty(t, reloaded.RestartRequired)
+	})
+
+	t.Run("restart required", func(t *testing.T) {
+		changed := *old
+		changed.Server.HTTPPort = "9999"
+		changed.Middleware.RateLimitEnabled = true
+
+		events := config.Diff(old, &changed)
+		require.Len(t, events, 1)
+		assert.Equal(t, []string{"server", "middleware"}, events[0].(config.Reloaded).RestartRequired)
+	})
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	watcher, path := newTestWatcher(t)
+	recorder := &eventRecorder{}
+	watcher.Subscribe(recorder.record)
+
+	writeWatcherConfig(t, path, "debug", 100)
+	require.NoError(t, watcher.Reload(context.Background()))
+
+	assert.Equal(t, "debug", watcher.Config().Observability.LogLevel)
+	events := recorder.get()
+	require.Len(t, events, 2)
+	assert.Equal(t, config.LogLevelChanged{Old: "info", New: "debug"}, events[0])
+	assert.IsType(t, config.Reloaded{}, events[1])
+}
+
+func TestWatcher_ReloadRejectsInvalidConfig(t *testing.T) {
+	watcher, path := newTestWatcher(t)
+	previous := watcher.Config()
+	recorder := &eventRecorder{}
+	watcher.Subscribe(recorder.record)
+
+	// Invalid log level fails validation
+	writeWatcherConfig(t, path, "verbose", 500)
+	assert.Error(t, watcher.Reload(context.Background()))
+
+	// Malformed JSON fails parsing
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+	assert.Error(t, watcher.Reload(context.Background()))
+
+	assert.Same(t, previous, watcher.Config())
+	assert.Empty(t, recorder.get())
+}
+
+func TestWatcher_Update(t *testing.T) {
+	watcher, _ := newTestWatcher(t)
+	previous := watcher.Config()
+	recorder := &eventRecorder{}
+	watcher.Subscribe(recorder.record)
+
+	require.NoError(t, watcher.Update(context.Background(), func(cfg *config.Config) {
+		*cfg.Features.Flags()["maintenanceMode"] = true
+	}))
+
+	assert.True(t, watcher.Config().Features.MaintenanceMode)
+	assert.False(t, previous.Features.MaintenanceMode, "the previous configuration must not change")
+	events := recorder.get()
+	require.Len(t, events, 2)
+	assert.Equal(t, config.FeaturesChanged{Old: previous.Features, New: watcher.Config().Features}, events[0])
+
+	// Invalid updates are rejected
+	err := watcher.Update(context.Background(), func(cfg *config.Config) {
+		cfg.Service.LogLevel = "verbose"
+	})
+	assert.Error(t, err)
+	assert.Equal(t, "info", watcher.Config().Service.LogLevel)
+	assert.Len(t, recorder.get(), 2)
+}
+
+func TestWatcher_Unsubscribe(t *testing.T) {
+	watcher, path := newTestWatcher(t)
+	recorder := &eventRecorder{}
+	unsubscribe := watcher.Subscribe(recorder.record)
+	unsubscribe()
+
+	writeWatcherConfig(t, path, "debug", 100)
+	require.NoError(t, watcher.Reload(context.Background()))
+	assert.Empty(t, recorder.get())
+}
+
+func TestWatcher_RunReloadsOnFileChange(t *testing.T) {
+	watcher, path := newTestWatcher(t)
+	recorder := &eventRecorder{}
+	watcher.Subscribe(recorder.record)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	// Give the watcher time to register before writing
+	time.Sleep(50 * time.Millisecond)
+	writeWatcherConfig(t, path, "info", 250)
+
+	assert.Eventually(t, func() bool {
+		return watcher.Config().Middleware.RateLimitRate == 250
+	}, 2*time.Second, 10*time.Millisecond)
+	events := recorder.get()
+	require.NotEmpty(t, events)
+	rateLimitChanged, ok := events[0].(config.RateLimitChanged)
+	require.True(t, ok)
+	assert.Equal(t, 250, rateLimitChanged.Rate)
+}
+
+func TestWatcher_RunReloadsOnOverlayCreation(t *testing.T) {
+	watcher, path := newTestWatcher(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	// Give the watcher time to register before writing
+	time.Sleep(50 * time.Millisecond)
+	local := filepath.Join(filepath.Dir(path), "lumi.local.json")
+	require.NoError(t, os.WriteFile(local, []byte(`{"middleware": {"rateLimitRate": 42}}`), 0o600))
+
+	assert.Eventually(t, func() bool {
+		return watcher.Config().Middleware.RateLimitRate == 42
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,227 @@
+package features_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/features"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func percentage(p float64) *float64 {
+	return &p
+}
+
+// staticProvider serves a fixed set of flags
+type staticProvider map[string]features.Flag
+
+func (p staticProvider) Flag(name string) (features.Flag, bool) {
+	flag, ok := p[name]
+	return flag, ok
+}
+
+func (p staticProvider) Flags() []features.Flag {
+	var flags []features.Flag
+	for _, flag := range p {
+		flags = append(flags, flag)
+	}
+	return flags
+}
+
+func TestFlag_Evaluate(t *testing.T) {
+	t.Run("disabled flags are off", func(t *testing.T) {
+		flag := features.Flag{Name: "f", Users: []string{"alice"}}
+		assert.False(t, flag.Evaluate(features.Target{UserID: "alice"}))
+	})
+
+	t.Run("enabled flags without rules are on", func(t *testing.T) {
+		flag := features.Flag{Name: "f", Enabled: true}
+		assert.True(t, flag.Evaluate(features.Target{}))
+	})
+
+	t.Run("targeting", func(t *testing.T) {
+		flag := features.Flag{
+			Name:    "f",
+			Enabled: true,
+			Users:   []string{"alice"},
+			Tenants: []string{"acme"},
+			Headers: map[string][]string{"X-Beta": {"1"}},
+		}
+		assert.True(t, flag.Evaluate(features.Target{UserID: "alice"}))
+		assert.True(t, flag.Evaluate(features.Target{UserID: "bob", TenantID: "acme"}))
+		assert.True(t, flag.Evaluate(features.Target{Headers: http.Header{"X-Beta": {"1"}}}))
+		assert.False(t, flag.Evaluate(features.Target{UserID: "bob", Headers: http.Header{"X-Beta": {"0"}}}))
+	})
+
+	t.Run("percentage rollout is stable and proportional", func(t *testing.T) {
+		flag := features.Flag{Name: "f", Enabled: true, Percentage: percentage(25)}
+
+		on := 0
+		for i := 0; i < 10000; i++ {
+			target := features.Target{UserID: fmt.Sprintf("user-%d", i)}
+			result := flag.Evaluate(target)
+			assert.Equal(t, result, flag.Evaluate(target))
+			if result {
+				on++
+			}
+		}
+		assert.InDelta(t, 2500, on, 250)
+
+		// Raising the percentage keeps everyone already included
+		wider := features.Flag{Name: "f", Enabled: true, Percentage: percentage(50)}
+		for i := 0; i < 1000; i++ {
+			target := features.Target{UserID: fmt.Sprintf("user-%d", i)}
+			if flag.Evaluate(target) {
+				assert.True(t, wider.Evaluate(target))
+			}
+		}
+	})
+
+	t.Run("rollout without a user or tenant", func(t *testing.T) {
+		assert.False(t, features.Flag{Name: "f", Enabled: true, Percentage: percentage(99)}.Evaluate(features.Target{}))
+		assert.True(t, features.Flag{Name: "f", Enabled: true, Percentage: percentage(100)}.Evaluate(features.Target{}))
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		assert.Error(t, features.Flag{}.Validate())
+		assert.Error(t, features.Flag{Name: "f", Percentage: percentage(101)}.Validate())
+		assert.NoError(t, features.Flag{Name: "f", Percentage: percentage(0)}.Validate())
+	})
+}
+
+func TestTargetFromContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.UserIDKey, "alice")
+	ctx = context.WithValue(ctx, logger.TenantIDKey, "acme")
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-beta", "1"))
+
+	target := features.TargetFromContext(ctx)
+	assert.Equal(t, "alice", target.UserID)
+	assert.Equal(t, "acme", target.TenantID)
+	assert.Equal(t, "1", target.Headers.Get("X-Beta"))
+}
+
+func TestEvaluator(t *testing.T) {
+	evaluator := features.NewEvaluator(
+		staticProvider{"shared": {Name: "shared", Enabled: true}},
+		staticProvider{"shared": {Name: "shared"}, "other": {Name: "other"}},
+	)
+
+	before := testutil.ToFloat64(metrics.Get().FeatureFlagEvaluations.WithLabelValues("shared", "on"))
+	assert.True(t, evaluator.Enabled(context.Background(), "shared"), "first provider wins")
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.Get().FeatureFlagEvaluations.WithLabelValues("shared", "on")))
+
+	assert.False(t, evaluator.Enabled(context.Background(), "other"))
+	assert.False(t, evaluator.Enabled(context.Background(), "unknown"))
+
+	flags := evaluator.Flags()
+	require.Len(t, flags, 2)
+	assert.Equal(t, "other", flags[0].Name)
+	assert.True(t, flags[1].Enabled)
+}
+
+func TestConfigProvider(t *testing.T) {
+	cfg := &config.Config{Features: config.FeaturesConfig{EnableNewAPI: true}}
+	provider := features.NewConfigProvider(config.NewWatcherWithSource(nil, nil, cfg))
+
+	flag, ok := provider.Flag("enableNewAPI")
+	require.True(t, ok)
+	assert.True(t, flag.Evaluate(features.Target{}))
+
+	_, ok = provider.Flag("flagsFile")
+	assert.False(t, ok)
+	assert.Len(t, provider.Flags(), 3)
+}
+
+func writeFlags(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+
+	t.Run("loads definitions", func(t *testing.T) {
+		writeFlags(t, path, `{"flags": {"newCheckout": {"enabled": true, "users": ["alice"], "percentage": 10}}}`)
+		provider, err := features.NewFileProvider(path)
+		require.NoError(t, err)
+
+		flag, ok := provider.Flag("newCheckout")
+		require.True(t, ok)
+		assert.Equal(t, "newCheckout", flag.Name)
+		assert.Equal(t, []string{"alice"}, flag.Users)
+		assert.Equal(t, 10.0, *flag.Percentage)
+		assert.Len(t, provider.Flags(), 1)
+	})
+
+	t.Run("rejects invalid definitions", func(t *testing.T) {
+		writeFlags(t, path, `{"flags": {"newCheckout": {"enabled": true, "percentage": 150}}}`)
+		_, err := features.NewFileProvider(path)
+		assert.Error(t, err)
+
+		_, err = features.NewFileProvider(filepath.Join(t.TempDir(), "missing.json"))
+		assert.Error(t, err)
+	})
+
+	t.Run("reloads on change", func(t *testing.T) {
+		writeFlags(t, path, `{"flags": {"newCheckout": {"enabled": false}}}`)
+		provider, err := features.NewFileProvider(path)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- provider.Run(ctx) }()
+		defer func() {
+			cancel()
+			assert.NoError(t, <-done)
+		}()
+
+		// Give the watcher time to start before changing the file
+		time.Sleep(50 * time.Millisecond)
+		writeFlags(t, path, `{"flags": {"newCheckout": {"enabled": true}}}`)
+		assert.Eventually(t, func() bool {
+			flag, _ := provider.Flag("newCheckout")
+			return flag.Enabled
+		}, 2*time.Second, 20*time.Millisecond)
+
+		// Invalid edits keep the previous flags
+		writeFlags(t, path, `{"flags": `)
+		time.Sleep(300 * time.Millisecond)
+		flag, ok := provider.Flag("newCheckout")
+		assert.True(t, ok && flag.Enabled)
+	})
+}
+
+func TestRequire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	evaluator := features.NewEvaluator(staticProvider{
+		"beta": {Name: "beta", Enabled: true, Headers: map[string][]string{"X-Beta": {"1"}}},
+	})
+
+	router := gin.New()
+	router.GET("/beta", features.Require(evaluator, "beta"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/beta", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/beta", nil)
+	req.Header.Set("X-Beta", "1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
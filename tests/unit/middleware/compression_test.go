@@ -0,0 +1,241 @@
+package middleware_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var largeBody = strings.Repeat(`{"message":"hello world"}`, 100)
+
+// newCompressionRouter serves bodies of various types and sizes and echoes
+// request bodies
+func newCompressionRouter(config middleware.CompressionConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.CompressionWithConfig(config))
+	router.GET("/large", func(c *gin.Context) {
+		c.Header("Content-Length", "2500")
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(largeBody))
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte(largeBody))
+	})
+	router.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "gzip")
+		c.Data(http.StatusOK, "application/json", []byte(largeBody))
+	})
+	router.GET("/events", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		for i := 0; i < 100; i++ {
+			c.SSEvent("message", largeBody)
+		}
+	})
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain")
+		c.Writer.WriteString("first chunk")
+		c.Writer.Flush()
+		c.Writer.WriteString(largeBody)
+	})
+	router.POST("/echo", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, "%d", len(body))
+	})
+	return router
+}
+
+func get(router http.Handler, path, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decode decompresses a response body for the given encoding
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		r = gz
+	case "br":
+		r = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	}
+	decoded, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(decoded)
+}
+
+func TestCompression(t *testing.T) {
+	router := newCompressionRouter(middleware.DefaultCompressionConfig())
+
+	t.Run("negotiates the encoding", func(t *testing.T) {
+		for acceptEncoding, expected := range map[string]string{
+			"gzip":                     "gzip",
+			"zstd":                     "zstd",
+			"br":                       "br",
+			"gzip, deflate, br, zstd":  "br",
+			"br;q=0, gzip;q=0.5, zstd": "zstd",
+			"*":                        "br",
+			"*, br;q=0":                "zstd",
+			"deflate":                  "",
+			"gzip;q=0":                 "",
+		} {
+			w := get(router, "/large", acceptEncoding)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, expected, w.Header().Get("Content-Encoding"), acceptEncoding)
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"), acceptEncoding)
+			if expected == "" {
+				assert.Equal(t, largeBody, w.Body.String(), acceptEncoding)
+				continue
+			}
+			assert.Empty(t, w.Header().Get("Content-Length"), acceptEncoding)
+			assert.Less(t, w.Body.Len(), len(largeBody), acceptEncoding)
+			assert.Equal(t, largeBody, decode(t, expected, w.Body.Bytes()), acceptEncoding)
+		}
+	})
+
+	t.Run("skips small bodies", func(t *testing.T) {
+		w := get(router, "/small", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Header().Get("Vary"))
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+	})
+
+	t.Run("skips types outside the allowlist", func(t *testing.T) {
+		w := get(router, "/image", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Header().Get("Vary"))
+		assert.Equal(t, largeBody, w.Body.String())
+	})
+
+	t.Run("skips encoded responses", func(t *testing.T) {
+		w := get(router, "/encoded", "br")
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeBody, w.Body.String())
+	})
+
+	t.Run("skips server-sent events", func(t *testing.T) {
+		w := get(router, "/events", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Body.String(), "event:message")
+	})
+
+	t.Run("skips responses flushed before the threshold", func(t *testing.T) {
+		w := get(router, "/stream", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "first chunk"+largeBody, w.Body.String())
+	})
+
+	t.Run("skips HEAD requests", func(t *testing.T) {
+		router := newCompressionRouter(middleware.DefaultCompressionConfig())
+		router.HEAD("/large", func(c *gin.Context) {
+			c.Data(http.StatusOK, "application/json", []byte(largeBody))
+		})
+		req := httptest.NewRequest(http.MethodHead, "/large", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("skips configured paths", func(t *testing.T) {
+		config := middleware.DefaultCompressionConfig()
+		config.SkipPaths = []string{"/large"}
+		w := get(newCompressionRouter(config), "/large", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "2500", w.Header().Get("Content-Length"))
+	})
+}
+
+// encode compresses body with the given encoding
+func encode(t *testing.T, encoding string, body []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		w = zw
+	}
+	_, err := w.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func post(router http.Handler, encoding string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body))
+	req.Header.Set("Content-Encoding", encoding)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDecompression(t *testing.T) {
+	config := middleware.DefaultCompressionConfig()
+	config.MaxDecompressedSize = 64 << 10
+	router := newCompressionRouter(config)
+
+	t.Run("decompresses request bodies", func(t *testing.T) {
+		for _, encoding := range []string{"gzip", "br", "zstd"} {
+			w := post(router, encoding, encode(t, encoding, []byte(largeBody)))
+			assert.Equal(t, http.StatusOK, w.Code, encoding)
+			assert.Equal(t, "2500", w.Body.String(), encoding)
+		}
+	})
+
+	t.Run("rejects bodies over the limit", func(t *testing.T) {
+		bomb := make([]byte, 1<<20)
+		for _, encoding := range []string{"gzip", "br", "zstd"} {
+			compressed := encode(t, encoding, bomb)
+			require.Less(t, len(compressed), 64<<10)
+
+			w := post(router, encoding, compressed)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, encoding)
+			assert.Contains(t, w.Body.String(), "request_too_large", encoding)
+		}
+	})
+
+	t.Run("rejects malformed and unsupported bodies", func(t *testing.T) {
+		w := post(router, "gzip", []byte("not gzip"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = post(router, "deflate", []byte("data"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Contains(t, w.Body.String(), "unsupported_encoding")
+	})
+}
@@ -323,3 +323,36 @@ func TestCORSWithDefaults(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
 }
+
+func TestCORSOriginList(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	origins := middleware.NewOriginList([]string{"http://a.example.com"})
+
+	router := gin.New()
+	router.Use(middleware.CORS(middleware.CORSConfig{
+		Enabled:         true,
+		AllowOrigins:    []string{"http://ignored.example.com"},
+		AllowOriginList: origins,
+		AllowMethods:    []string{"GET"},
+	}))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	allowedOrigin := func(origin string) string {
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	assert.Equal(t, "http://a.example.com", allowedOrigin("http://a.example.com"))
+	assert.Empty(t, allowedOrigin("http://ignored.example.com"))
+
+	// Origins can be replaced at runtime
+	origins.Set([]string{"http://b.example.com"})
+	assert.Empty(t, allowedOrigin("http://a.example.com"))
+	assert.Equal(t, "http://b.example.com", allowedOrigin("http://b.example.com"))
+	assert.Equal(t, []string{"http://b.example.com"}, origins.Origins())
+}
@@ -0,0 +1,166 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/lumitut/lumi-go/internal/observability/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testUnaryInfo = &grpc.UnaryServerInfo{FullMethod: "/lumigo.api.v1.ExampleService/GetExample"}
+
+// fakeServerStream is a minimal grpc.ServerStream for interceptor tests
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent int
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+func (s *fakeServerStream) SendMsg(m interface{}) error {
+	s.sent++
+	return nil
+}
+
+func TestSplitMethodName(t *testing.T) {
+	service, method := middleware.SplitMethodName("/lumigo.api.v1.ExampleService/GetExample")
+	assert.Equal(t, "lumigo.api.v1.ExampleService", service)
+	assert.Equal(t, "GetExample", method)
+
+	service, method = middleware.SplitMethodName("Bare")
+	assert.Equal(t, "unknown", service)
+	assert.Equal(t, "Bare", method)
+}
+
+func TestUnaryRecoveryInterceptor(t *testing.T) {
+	interceptor := middleware.UnaryRecoveryInterceptor(middleware.DefaultRecoveryConfig())
+
+	resp, err := interceptor(context.Background(), nil, testUnaryInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestStreamRecoveryInterceptor(t *testing.T) {
+	interceptor := middleware.StreamRecoveryInterceptor(middleware.DefaultRecoveryConfig())
+	info := &grpc.StreamServerInfo{FullMethod: "/lumigo.api.v1.ExampleService/StreamExamples", IsServerStream: true}
+
+	err := interceptor(nil, &fakeServerStream{ctx: context.Background()}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		panic("boom")
+	})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestUnaryCorrelationInterceptor(t *testing.T) {
+	interceptor := middleware.UnaryCorrelationInterceptor()
+
+	t.Run("propagates IDs from metadata", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"x-request-id", "req-123",
+			"x-correlation-id", "corr-456",
+			"x-user-id", "user-789",
+		))
+
+		_, err := interceptor(ctx, nil, testUnaryInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+			assert.Equal(t, "req-123", ctx.Value(logger.RequestIDKey))
+			assert.Equal(t, "corr-456", ctx.Value(logger.CorrelationIDKey))
+			assert.Equal(t, "user-789", ctx.Value(logger.UserIDKey))
+			return nil, nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("generates IDs when missing", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, testUnaryInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+			requestID := middleware.RequestIDFromContext(ctx)
+			assert.NotEmpty(t, requestID)
+			assert.Equal(t, requestID, middleware.CorrelationIDFromContext(ctx))
+			return nil, nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestStreamCorrelationInterceptor(t *testing.T) {
+	interceptor := middleware.StreamCorrelationInterceptor()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "req-123"))
+	info := &grpc.StreamServerInfo{FullMethod: "/lumigo.api.v1.ExampleService/StreamExamples"}
+
+	err := interceptor(nil, &fakeServerStream{ctx: ctx}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		assert.Equal(t, "req-123", middleware.RequestIDFromContext(ss.Context()))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestStreamMetricsInterceptor(t *testing.T) {
+	interceptor := middleware.StreamMetricsInterceptor(middleware.MetricsConfig{})
+	stream := &fakeServerStream{ctx: context.Background()}
+	info := &grpc.StreamServerInfo{FullMethod: "/lumigo.api.v1.ExampleService/StreamExamples"}
+
+	err := interceptor(nil, stream, info, func(srv interface{}, ss grpc.ServerStream) error {
+		for i := 0; i < 3; i++ {
+			if err := ss.SendMsg(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, stream.sent)
+}
+
+func TestGRPCRateLimitInterceptor(t *testing.T) {
+	t.Run("blocks requests over limit", func(t *testing.T) {
+		interceptor := middleware.UnaryRateLimitInterceptor(middleware.GRPCRateLimitConfig{
+			Enabled: true,
+			Rate:    10,
+			Burst:   2,
+			KeyFunc: func(ctx context.Context, fullMethod string) string { return "client" },
+		})
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+		for i := 0; i < 2; i++ {
+			resp, err := interceptor(context.Background(), nil, testUnaryInfo, handler)
+			require.NoError(t, err)
+			assert.Equal(t, "ok", resp)
+		}
+
+		_, err := interceptor(context.Background(), nil, testUnaryInfo, handler)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("skips configured methods", func(t *testing.T) {
+		interceptor := middleware.UnaryRateLimitInterceptor(middleware.GRPCRateLimitConfig{
+			Enabled:   true,
+			Rate:      1,
+			Burst:     1,
+			KeyFunc:   func(ctx context.Context, fullMethod string) string { return "client" },
+			SkipPaths: []string{testUnaryInfo.FullMethod},
+		})
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+		for i := 0; i < 5; i++ {
+			_, err := interceptor(context.Background(), nil, testUnaryInfo, handler)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("keys by API key metadata", func(t *testing.T) {
+		keyFunc := middleware.GRPCRateLimitKeyFunc("api_key")
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "secret"))
+		assert.Equal(t, "api:secret", keyFunc(ctx, testUnaryInfo.FullMethod))
+	})
+}
@@ -0,0 +1,133 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func newMaintenanceRouter(m *middleware.MaintenanceMode) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Maintenance(m))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return router
+}
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	config := middleware.MaintenanceConfig{
+		RetryAfter:   90 * time.Second,
+		AllowIPs:     []string{"10.1.0.0/16", "192.168.1.5", "not-an-ip"},
+		AllowAPIKeys: []string{"ops-key"},
+	}
+
+	t.Run("rejects requests when enabled", func(t *testing.T) {
+		router := newMaintenanceRouter(middleware.NewMaintenanceMode(config, true))
+
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "203.0.113.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "90", w.Header().Get("Retry-After"))
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "maintenance", body["error"])
+		assert.NotEmpty(t, body["message"])
+		assert.Equal(t, float64(90), body["retry_after"])
+	})
+
+	t.Run("allowlisted clients bypass", func(t *testing.T) {
+		router := newMaintenanceRouter(middleware.NewMaintenanceMode(config, true))
+
+		for name, setup := range map[string]func(r *http.Request){
+			"CIDR":    func(r *http.Request) { r.RemoteAddr = "10.1.2.3:1234" },
+			"IP":      func(r *http.Request) { r.RemoteAddr = "192.168.1.5:1234" },
+			"API key": func(r *http.Request) { r.Header.Set("X-API-Key", "ops-key") },
+		} {
+			t.Run(name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/test", nil)
+				req.RemoteAddr = "203.0.113.1:1234"
+				setup(req)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				assert.Equal(t, http.StatusOK, w.Code)
+			})
+		}
+	})
+
+	t.Run("toggles at runtime", func(t *testing.T) {
+		m := middleware.NewMaintenanceMode(config, false)
+		router := newMaintenanceRouter(m)
+		serve := func() int {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+			return w.Code
+		}
+
+		assert.Equal(t, http.StatusOK, serve())
+		m.SetEnabled(true)
+		assert.True(t, m.Enabled())
+		assert.Equal(t, http.StatusServiceUnavailable, serve())
+		m.SetEnabled(false)
+		assert.Equal(t, http.StatusOK, serve())
+	})
+}
+
+func TestMaintenanceInterceptors(t *testing.T) {
+	m := middleware.NewMaintenanceMode(middleware.MaintenanceConfig{
+		AllowIPs:     []string{"10.0.0.0/8"},
+		AllowAPIKeys: []string{"ops-key"},
+		SkipMethods:  []string{"/grpc.health.v1.Health/Check"},
+	}, true)
+	unary := middleware.UnaryMaintenanceInterceptor(m)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	fromPeer := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 1234},
+		})
+	}
+
+	t.Run("rejects calls with Unavailable", func(t *testing.T) {
+		_, err := unary(fromPeer("203.0.113.1"), nil, testUnaryInfo, handler)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+
+		stream := middleware.StreamMaintenanceInterceptor(m)
+		info := &grpc.StreamServerInfo{FullMethod: "/lumigo.api.v1.ExampleService/StreamExamples", IsServerStream: true}
+		err = stream(nil, &fakeServerStream{ctx: fromPeer("203.0.113.1")}, info, func(srv interface{}, ss grpc.ServerStream) error {
+			return nil
+		})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("allowlisted clients and skipped methods pass", func(t *testing.T) {
+		resp, err := unary(fromPeer("10.2.3.4"), nil, testUnaryInfo, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+
+		ctx := metadata.NewIncomingContext(fromPeer("203.0.113.1"), metadata.Pairs("x-api-key", "ops-key"))
+		_, err = unary(ctx, nil, testUnaryInfo, handler)
+		require.NoError(t, err)
+
+		_, err = unary(fromPeer("203.0.113.1"), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,128 @@
+package middleware_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/config"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimitRedis(t *testing.T) {
+	cfg := &config.Config{
+		Clients: config.ClientsConfig{Redis: config.RedisClientConfig{Enabled: true, URL: "redis://127.0.0.1:6379"}},
+		Middleware: config.MiddlewareConfig{
+			RateLimitEnabled: true,
+			RateLimitStore:   "memory",
+		},
+	}
+	assert.Nil(t, middleware.NewRateLimitRedis(cfg), "the memory store needs no client")
+
+	cfg.Middleware.RateLimitStore = "redis"
+	client := middleware.NewRateLimitRedis(cfg)
+	require.NotNil(t, client)
+	assert.NoError(t, client.Close())
+
+	cfg.Clients.Redis.URL = "not a url"
+	assert.Nil(t, middleware.NewRateLimitRedis(cfg), "an invalid URL limits each replica separately")
+}
+
+func TestRateLimiters(t *testing.T) {
+	cfg := config.MiddlewareConfig{
+		RateLimitRate:        60,
+		RateLimitBurst:       2,
+		RateLimitAlgorithm:   middleware.RateLimitTokenBucket,
+		RateLimitFailureMode: middleware.FailLocal,
+	}
+
+	t.Run("in memory without a client", func(t *testing.T) {
+		limiters := middleware.NewRateLimiters(cfg, nil, "test:")
+		defer limiters.Close()
+		assert.IsType(t, &middleware.TokenBucketLimiter{}, limiters.Limiter())
+	})
+
+	t.Run("in Redis under the prefix", func(t *testing.T) {
+		server, client := newRedis(t)
+		replicaA := middleware.NewRateLimiters(cfg, client, "test:http:")
+		defer replicaA.Close()
+		replicaB := middleware.NewRateLimiters(cfg, client, "test:http:")
+		defer replicaB.Close()
+
+		allowed, _ := replicaA.Limiter().Allow("client")
+		assert.True(t, allowed)
+		allowed, _ = replicaB.Limiter().Allow("client")
+		assert.True(t, allowed)
+		allowed, _ = replicaA.Limiter().Allow("client")
+		assert.False(t, allowed, "replicas share one budget")
+		assert.True(t, server.Exists("test:http:global:client"))
+
+		replicaA.SetLimits(60, 5)
+		for i := 0; i < 5; i++ {
+			allowed, _ = replicaA.Limiter().Allow("other")
+			assert.True(t, allowed, "the reloaded burst applies")
+		}
+	})
+}
+
+func TestNewRateLimitPoliciesFromConfig(t *testing.T) {
+	cfg := config.MiddlewareConfig{
+		RateLimitType: middleware.IdentityIP,
+		RateLimitPolicies: []config.RateLimitPolicyConfig{
+			{Name: "writes", Methods: []string{http.MethodPost}, Rate: 1},
+		},
+		RateLimitPlans:       map[string]config.RateLimitPlanConfig{"free": {Rate: 1, Window: time.Hour}},
+		RateLimitAPIKeyPlans: []string{"free-key=free"},
+		RateLimitFailureMode: middleware.FailLocal,
+	}
+	client := middleware.RateLimitIdentity{IP: "10.0.0.1"}
+	write := middleware.RateLimitRequest{Route: "/api/v1/examples", Method: http.MethodPost, Identity: client}
+
+	t.Run("in memory without a client", func(t *testing.T) {
+		global := middleware.NewTokenBucketLimiter(100, 100, time.Minute, time.Minute)
+		defer global.Close()
+		policies, err := middleware.NewRateLimitPoliciesFromConfig(cfg, global, nil, "test:http:")
+		require.NoError(t, err)
+		defer policies.Close()
+
+		assert.True(t, policies.Check(write).Allowed)
+		decision := policies.Check(write)
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, "writes", decision.Policy)
+
+		decision = policies.Check(middleware.RateLimitRequest{Route: "/", Method: http.MethodGet, Identity: client})
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, "global", decision.Policy)
+		assert.Len(t, global.Snapshot(), 1, "the global policy uses the given limiter")
+
+		planned := middleware.RateLimitIdentity{IP: "10.0.0.2", APIKey: "free-key"}
+		assert.True(t, policies.Check(middleware.RateLimitRequest{Route: "/", Method: http.MethodGet, Identity: planned}).Allowed)
+		assert.False(t, policies.Check(middleware.RateLimitRequest{Route: "/", Method: http.MethodGet, Identity: planned}).Allowed,
+			"API keys get their plan's limit")
+	})
+
+	t.Run("in Redis under the prefix", func(t *testing.T) {
+		server, redisClient := newRedis(t)
+		global := middleware.NewTokenBucketLimiter(100, 100, time.Minute, time.Minute)
+		defer global.Close()
+		policies, err := middleware.NewRateLimitPoliciesFromConfig(cfg, global, redisClient, "test:http:")
+		require.NoError(t, err)
+		defer policies.Close()
+
+		policies.Check(write)
+		require.NotEmpty(t, server.Keys())
+		for _, key := range server.Keys() {
+			assert.True(t, strings.HasPrefix(key, "test:http:policy:writes:"), key)
+		}
+	})
+
+	t.Run("rejects invalid policies", func(t *testing.T) {
+		invalid := cfg
+		invalid.RateLimitPolicies = []config.RateLimitPolicyConfig{{Name: "writes"}}
+		_, err := middleware.NewRateLimitPoliciesFromConfig(invalid, nil, nil, "test:http:")
+		assert.Error(t, err)
+	})
+}
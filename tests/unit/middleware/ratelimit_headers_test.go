@@ -0,0 +1,192 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// staleLimiter rejects every request with a reset time already in the past
+type staleLimiter struct{}
+
+func (staleLimiter) Allow(key string) (bool, middleware.RateLimitInfo) {
+	return false, middleware.RateLimitInfo{Limit: 5, ResetTime: time.Now().Add(-time.Second), Window: time.Minute}
+}
+
+func (staleLimiter) Reset(key string) {}
+
+// recordingTransportStream records the metadata an interceptor sends
+type recordingTransportStream struct {
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *recordingTransportStream) Method() string { return "/test.Service/Method" }
+
+func (s *recordingTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *recordingTransportStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *recordingTransportStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(config middleware.RateLimitConfig) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware.RateLimit(config))
+		router.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return router
+	}
+	request := func(router *gin.Engine) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+		return w
+	}
+
+	for format, want := range map[string]struct{ legacy, ietf bool }{
+		"":                                {legacy: true},
+		middleware.RateLimitHeadersLegacy: {legacy: true},
+		middleware.RateLimitHeadersIETF:   {ietf: true},
+		middleware.RateLimitHeadersBoth:   {legacy: true, ietf: true},
+	} {
+		t.Run("format "+format, func(t *testing.T) {
+			config := middleware.DefaultRateLimitConfig()
+			config.Rate = 10
+			config.Burst = 2
+			config.Headers = format
+			w := request(newRouter(config))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, want.legacy, w.Header().Get("X-RateLimit-Limit") == "10")
+			assert.Equal(t, want.legacy, w.Header().Get("X-RateLimit-Remaining") == "1")
+			if want.ietf {
+				assert.Equal(t, `"default";r=1;t=6`, w.Header().Get("RateLimit"))
+				assert.Equal(t, `"default";q=10;w=60`, w.Header().Get("RateLimit-Policy"))
+			} else {
+				assert.Empty(t, w.Header().Get("RateLimit"))
+				assert.Empty(t, w.Header().Get("RateLimit-Policy"))
+			}
+		})
+	}
+
+	t.Run("names the matching policy", func(t *testing.T) {
+		config := middleware.DefaultRateLimitConfig()
+		config.Headers = middleware.RateLimitHeadersIETF
+		config.Policies = newPolicies(t, middleware.RateLimitPoliciesConfig{
+			Policies: []middleware.RateLimitPolicy{
+				{Name: "hourly", Algorithm: middleware.RateLimitSlidingWindow, Rate: 100, Window: time.Hour},
+			},
+		})
+		w := request(newRouter(config))
+
+		assert.Equal(t, `"hourly";r=99;t=3600`, w.Header().Get("RateLimit"))
+		assert.Equal(t, `"hourly";q=100;w=3600`, w.Header().Get("RateLimit-Policy"))
+	})
+
+	t.Run("rounds Retry-After up", func(t *testing.T) {
+		config := middleware.DefaultRateLimitConfig()
+		config.Rate = 10
+		config.Burst = 1
+		config.Headers = middleware.RateLimitHeadersBoth
+		router := newRouter(config)
+		require.Equal(t, http.StatusOK, request(router).Code)
+
+		w := request(router)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "6", w.Header().Get("Retry-After"), "the next token is just under 6s away")
+		assert.Equal(t, `"default";r=0;t=6`, w.Header().Get("RateLimit"))
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(6), response["retry_after"])
+	})
+
+	t.Run("clamps Retry-After", func(t *testing.T) {
+		config := middleware.DefaultRateLimitConfig()
+		config.Limiter = staleLimiter{}
+		config.Headers = middleware.RateLimitHeadersIETF
+		w := request(newRouter(config))
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		assert.Equal(t, `"default";r=0;t=0`, w.Header().Get("RateLimit"))
+	})
+
+	t.Run("counts decisions", func(t *testing.T) {
+		allowed := metrics.Get().RateLimitDecisions.WithLabelValues("http", "per-user", "user", "allowed")
+		denied := metrics.Get().RateLimitDecisions.WithLabelValues("http", "per-user", "user", "denied")
+		beforeAllowed, beforeDenied := testutil.ToFloat64(allowed), testutil.ToFloat64(denied)
+
+		config := middleware.DefaultRateLimitConfig()
+		config.Policies = newPolicies(t, middleware.RateLimitPoliciesConfig{
+			Policies: []middleware.RateLimitPolicy{
+				{Name: "per-user", Identity: middleware.IdentityUser, Rate: 1},
+			},
+		})
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set("user_id", "alice")
+			c.Next()
+		})
+		router.Use(middleware.RateLimit(config))
+		router.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+		request(router)
+		request(router)
+
+		assert.Equal(t, beforeAllowed+1, testutil.ToFloat64(allowed))
+		assert.Equal(t, beforeDenied+1, testutil.ToFloat64(denied))
+	})
+}
+
+func TestGRPCRateLimitMetadata(t *testing.T) {
+	interceptor := middleware.UnaryRateLimitInterceptor(middleware.GRPCRateLimitConfig{
+		Enabled: true,
+		Rate:    10,
+		Burst:   1,
+		KeyFunc: func(ctx context.Context, fullMethod string) string { return "client" },
+		Headers: middleware.RateLimitHeadersBoth,
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+	call := func() (*recordingTransportStream, error) {
+		stream := &recordingTransportStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		_, err := interceptor(ctx, nil, info, handler)
+		return stream, err
+	}
+
+	stream, err := call()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10"}, stream.trailer.Get("x-ratelimit-limit"))
+	assert.Equal(t, []string{`"default";r=0;t=6`}, stream.trailer.Get("ratelimit"))
+	assert.Equal(t, []string{`"default";q=10;w=60`}, stream.trailer.Get("ratelimit-policy"))
+	assert.Equal(t, stream.trailer, stream.header, "headers and trailers carry the same information")
+	assert.Empty(t, stream.trailer.Get("retry-after"))
+
+	stream, err = call()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"6"}, stream.trailer.Get("retry-after"))
+	assert.Equal(t, []string{"0"}, stream.trailer.Get("x-ratelimit-remaining"))
+}
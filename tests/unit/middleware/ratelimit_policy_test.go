@@ -0,0 +1,241 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newPolicies(t *testing.T, config middleware.RateLimitPoliciesConfig) *middleware.RateLimitPolicies {
+	t.Helper()
+	policies, err := middleware.NewRateLimitPolicies(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { policies.Close() })
+	return policies
+}
+
+func TestRateLimitPolicies(t *testing.T) {
+	client := middleware.RateLimitIdentity{IP: "10.0.0.1"}
+
+	t.Run("most restrictive policy wins", func(t *testing.T) {
+		policies := newPolicies(t, middleware.RateLimitPoliciesConfig{
+			Policies: []middleware.RateLimitPolicy{
+				{Name: "all", Rate: 100},
+				{Name: "writes", Routes: []string{"/api/v1/examples*"}, Methods: []string{"post"}, Rate: 2},
+			},
+		})
+		write := middleware.RateLimitRequest{Route: "/api/v1/examples", Method: http.MethodPost, Identity: client}
+
+		decision := policies.Check(write)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, "writes", decision.Policy)
+		assert.Equal(t, 1, decision.Info.Remaining)
+
+		policies.Check(write)
+		decision = policies.Check(write)
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, "writes", decision.Policy)
+		assert.Equal(t, "10.0.0.1", decision.Key)
+
+		// Reads only match the broader policy
+		decision = policies.Check(middleware.RateLimitRequest{Route: "/api/v1/examples", Method: http.MethodGet, Identity: client})
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, "all", decision.Policy)
+		assert.Equal(t, 96, decision.Info.Remaining)
+	})
+
+	t.Run("matches routes and paths", func(t *testing.T) {
+		policies := newPolicies(t, middleware.RateLimitPoliciesConfig{
+			Policies: []middleware.RateLimitPolicy{
+				{Name: "example", Routes: []string{"/api/v1/examples/:id"}, Rate: 10},
+				{Name: "rpc", Routes: []string{"/lumigo.api.v1.ExampleService/*"}, Rate: 10},
+			},
+		})
+
+		decision := policies.Check(middleware.RateLimitRequest{Route: "/api/v1/examples/:id", Path: "/api/v1/examples/42", Method: http.MethodGet, Identity: client})
+		assert.Equal(t, "example", decision.Policy)
+		decision = policies.Check(middleware.RateLimitRequest{Route: "/lumigo.api.v1.ExampleService/GetExample", Identity: client})
+		assert.Equal(t, "rpc", decision.Policy)
+		decision = policies.Check(middleware.RateLimitRequest{Route: "/api/v1/*path", Path: "/api/v1/other", Method: http.MethodGet, Identity: client})
+		assert.True(t, decision.Allowed)
+		assert.Empty(t, decision.Policy, "no policy matches")
+	})
+
+	t.Run("keys budgets by identity", func(t *testing.T) {
+		policies := newPolicies(t, middleware.RateLimitPoliciesConfig{
+			Policies: []middleware.RateLimitPolicy{
+				{Name: "tenants", Identity: middleware.IdentityTenant, Rate: 1},
+			},
+		})
+
+		for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+			decision := policies.Check(middleware.RateLimitRequest{Identity: middleware.RateLimitIdentity{IP: ip, TenantID: "acme"}})
+			assert.Equal(t, "tenant:acme", decision.Key)
+			assert.Equal(t, ip == "10.0.0.1", decision.Allowed, "the tenant shares one budget across clients")
+		}
+
+		decision := policies.Check(middleware.RateLimitRequest{Identity: client})
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, "10.0.0.1", decision.Key, "requests without a tenant are limited per IP")
+	})
+
+	t.Run("applies API key plans", func(t *testing.T) {
+		policies := newPolicies(t, middleware.RateLimitPoliciesConfig{
+			Plans: map[string]middleware.RateLimitPlan{
+				"free": {Rate: 2, Window: time.Hour},
+				"pro":  {Rate: 100, Window: time.Hour},
+			},
+			APIKeyPlans: map[string]string{"free-key": "free", "pro-key": "pro"},
+		})
+
+		free := middleware.RateLimitRequest{Identity: middleware.RateLimitIdentity{IP: "10.0.0.1", APIKey: "free-key"}}
+		pro := middleware.RateLimitRequest{Identity: middleware.RateLimitIdentity{IP: "10.0.0.1", BearerToken: "pro-key"}}
+		for i := 0; i < 2; i++ {
+			assert.True(t, policies.Check(free).Allowed)
+		}
+		decision := policies.Check(free)
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, "plan:free", decision.Policy)
+		assert.Equal(t, "api:free-key", decision.Key)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), decision.Info.ResetTime, time.Second)
+
+		decision = policies.Check(pro)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, "plan:pro", decision.Policy)
+		assert.Equal(t, "bearer:pro-key", decision.Key)
+		assert.Equal(t, 99, decision.Info.Remaining)
+
+		decision = policies.Check(middleware.RateLimitRequest{Identity: middleware.RateLimitIdentity{IP: "10.0.0.1", APIKey: "unknown"}})
+		assert.Empty(t, decision.Policy, "keys without a plan are not limited by plans")
+	})
+
+	t.Run("supports sliding windows and given limiters", func(t *testing.T) {
+		global := middleware.NewTokenBucketLimiter(60, 1, time.Minute, 5*time.Minute)
+		defer global.Close()
+		policies := newPolicies(t, middleware.RateLimitPoliciesConfig{
+			Policies: []middleware.RateLimitPolicy{
+				{Name: "global", Limiter: global},
+				{Name: "window", Algorithm: middleware.RateLimitSlidingWindow, Rate: 5, Window: time.Second},
+			},
+		})
+
+		assert.True(t, policies.Check(middleware.RateLimitRequest{Identity: client}).Allowed)
+		decision := policies.Check(middleware.RateLimitRequest{Identity: client})
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, "global", decision.Policy)
+
+		require.NoError(t, policies.Close())
+		allowed, _ := global.Allow("other")
+		assert.True(t, allowed, "given limiters are left open")
+	})
+
+	t.Run("snapshots and resets every policy", func(t *testing.T) {
+		policies := newPolicies(t, middleware.RateLimitPoliciesConfig{
+			Policies: []middleware.RateLimitPolicy{
+				{Name: "all", Rate: 100},
+				{Name: "window", Algorithm: middleware.RateLimitSlidingWindow, Rate: 5},
+			},
+			Plans:       map[string]middleware.RateLimitPlan{"free": {Rate: 2}},
+			APIKeyPlans: map[string]string{"free-key": "free"},
+		})
+		policies.Check(middleware.RateLimitRequest{Identity: client})
+		policies.Check(middleware.RateLimitRequest{Identity: middleware.RateLimitIdentity{IP: "10.0.0.2", APIKey: "free-key"}})
+
+		var buckets []string
+		for _, state := range policies.Snapshot() {
+			buckets = append(buckets, state.Policy+" "+state.Key)
+		}
+		assert.Equal(t, []string{"all 10.0.0.1", "window 10.0.0.1", "all 10.0.0.2", "window 10.0.0.2", "plan:free api:free-key"}, buckets)
+
+		policies.Reset("10.0.0.1")
+		for _, state := range policies.Snapshot() {
+			assert.NotEqual(t, "10.0.0.1", state.Key)
+		}
+	})
+
+	t.Run("rejects invalid policies", func(t *testing.T) {
+		for name, config := range map[string]middleware.RateLimitPoliciesConfig{
+			"missing name": {Policies: []middleware.RateLimitPolicy{{Rate: 1}}},
+			"duplicate":    {Policies: []middleware.RateLimitPolicy{{Name: "a", Rate: 1}, {Name: "a", Rate: 1}}},
+			"identity":     {Policies: []middleware.RateLimitPolicy{{Name: "a", Rate: 1, Identity: "session"}}},
+			"algorithm":    {Policies: []middleware.RateLimitPolicy{{Name: "a", Rate: 1, Algorithm: "leaky"}}},
+			"rate":         {Policies: []middleware.RateLimitPolicy{{Name: "a"}}},
+			"unknown plan": {APIKeyPlans: map[string]string{"key": "gold"}},
+		} {
+			_, err := middleware.NewRateLimitPolicies(config)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestRateLimitWithPolicies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policies := newPolicies(t, middleware.RateLimitPoliciesConfig{
+		Policies: []middleware.RateLimitPolicy{
+			{Name: "all", Rate: 100},
+			{Name: "item", Routes: []string{"/items/:id"}, Identity: middleware.IdentityUser, Rate: 1},
+		},
+	})
+
+	config := middleware.DefaultRateLimitConfig()
+	config.Policies = policies
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-User-ID"))
+		c.Next()
+	})
+	router.Use(middleware.RateLimit(config))
+	router.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+		req.Header.Set("X-User-ID", userID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"), "headers describe the most restrictive policy")
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = request("alice")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "rate_limit_exceeded")
+
+	assert.Equal(t, http.StatusOK, request("bob").Code, "each user has a budget")
+}
+
+func TestGRPCRateLimitWithPolicies(t *testing.T) {
+	policies := newPolicies(t, middleware.RateLimitPoliciesConfig{
+		Policies: []middleware.RateLimitPolicy{
+			{Name: "create", Routes: []string{"/test.Service/Create"}, Identity: middleware.IdentityAPIKey, Rate: 1},
+		},
+	})
+	interceptor := middleware.UnaryRateLimitInterceptor(middleware.GRPCRateLimitConfig{
+		Enabled:  true,
+		Policies: policies,
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "key"))
+
+	call := func(method string) error {
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	require.NoError(t, call("/test.Service/Create"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(call("/test.Service/Create")))
+	assert.NoError(t, call("/test.Service/Get"), "calls matching no policy are not limited")
+}
@@ -0,0 +1,218 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+// newRedis starts an in-process Redis and returns a client for it
+func newRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	server.SetTime(time.Now())
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+func newDistributedLimiter(client redis.UniversalClient, modify func(*middleware.DistributedRateLimitConfig)) *middleware.DistributedRateLimiter {
+	config := middleware.DefaultDistributedRateLimitConfig()
+	config.Client = client
+	config.Rate = 60
+	config.Burst = 3
+	if modify != nil {
+		modify(&config)
+	}
+	return middleware.NewDistributedRateLimiter(config)
+}
+
+func TestDistributedRateLimiter_TokenBucket(t *testing.T) {
+	t.Run("replicas share one budget", func(t *testing.T) {
+		server, client := newRedis(t)
+		replicaA := newDistributedLimiter(client, nil)
+		replicaB := newDistributedLimiter(client, nil)
+
+		for i, limiter := range []*middleware.DistributedRateLimiter{replicaA, replicaB, replicaA} {
+			allowed, info := limiter.Allow("client")
+			assert.True(t, allowed, "request %d", i+1)
+			assert.Equal(t, 2-i, info.Remaining)
+		}
+		allowed, info := replicaB.Allow("client")
+		assert.False(t, allowed)
+		assert.Equal(t, 0, info.Remaining)
+		assert.WithinDuration(t, time.Now().Add(time.Second), info.ResetTime, 100*time.Millisecond)
+
+		// 60 requests per minute refill a token every second
+		server.SetTime(time.Now().Add(time.Second))
+		allowed, _ = replicaA.Allow("client")
+		assert.True(t, allowed)
+
+		allowed, _ = replicaA.Allow("other")
+		assert.True(t, allowed, "keys have separate limits")
+		assert.True(t, server.Exists("ratelimit:client"))
+	})
+
+	t.Run("reset and set limits", func(t *testing.T) {
+		_, client := newRedis(t)
+		limiter := newDistributedLimiter(client, nil)
+
+		for i := 0; i < 3; i++ {
+			limiter.Allow("client")
+		}
+		limiter.Reset("client")
+		allowed, _ := limiter.Allow("client")
+		assert.True(t, allowed)
+
+		limiter.SetLimits(60, 5)
+		limiter.Reset("client")
+		for i := 0; i < 5; i++ {
+			allowed, _ := limiter.Allow("client")
+			assert.True(t, allowed, "request %d", i+1)
+		}
+		allowed, _ = limiter.Allow("client")
+		assert.False(t, allowed)
+	})
+
+	t.Run("snapshots the keys in Redis", func(t *testing.T) {
+		_, client := newRedis(t)
+		replicaA := newDistributedLimiter(client, nil)
+		replicaB := newDistributedLimiter(client, nil)
+		other := newDistributedLimiter(client, func(config *middleware.DistributedRateLimitConfig) {
+			config.Prefix = "other:"
+		})
+		replicaA.Allow("b")
+		replicaB.Allow("a")
+		replicaA.Allow("a")
+		other.Allow("c")
+
+		buckets := replicaB.Snapshot()
+		require.Len(t, buckets, 2, "keys under other prefixes are left out")
+		assert.Equal(t, "a", buckets[0].Key)
+		assert.Equal(t, 1, buckets[0].Tokens, "both replicas' requests count")
+		assert.WithinDuration(t, time.Now().Add(2*time.Second), buckets[0].ResetTime, 100*time.Millisecond)
+		assert.Equal(t, "b", buckets[1].Key)
+		assert.Equal(t, 2, buckets[1].Tokens)
+
+		replicaB.Reset("a")
+		buckets = replicaA.Snapshot()
+		require.Len(t, buckets, 1)
+		assert.Equal(t, "b", buckets[0].Key)
+	})
+}
+
+func TestDistributedRateLimiter_SlidingWindow(t *testing.T) {
+	server, client := newRedis(t)
+	limiter := newDistributedLimiter(client, func(config *middleware.DistributedRateLimitConfig) {
+		config.Algorithm = middleware.RateLimitSlidingWindow
+		config.Rate = 3
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		server.SetTime(start.Add(time.Duration(i) * 10 * time.Second))
+		allowed, info := limiter.Allow("client")
+		assert.True(t, allowed, "request %d", i+1)
+		assert.Equal(t, 2-i, info.Remaining)
+	}
+	allowed, _ := limiter.Allow("client")
+	assert.False(t, allowed)
+
+	// The first request leaves the window after a minute
+	server.SetTime(start.Add(61 * time.Second))
+	allowed, info := limiter.Allow("client")
+	assert.True(t, allowed)
+	assert.Equal(t, 0, info.Remaining)
+
+	buckets := limiter.Snapshot()
+	require.Len(t, buckets, 1)
+	assert.Equal(t, 0, buckets[0].Tokens)
+	assert.WithinDuration(t, start.Add(61*time.Second), buckets[0].LastFill, time.Millisecond)
+	assert.WithinDuration(t, start.Add(70*time.Second), buckets[0].ResetTime, time.Millisecond, "the second request leaves the window next")
+}
+
+func TestDistributedRateLimiter_FailureModes(t *testing.T) {
+	newUnavailable := func(t *testing.T, mode string) *middleware.DistributedRateLimiter {
+		server, client := newRedis(t)
+		server.Close()
+		return newDistributedLimiter(client, func(config *middleware.DistributedRateLimitConfig) {
+			config.FailureMode = mode
+		})
+	}
+
+	t.Run("local falls back to an in-memory limiter", func(t *testing.T) {
+		limiter := newUnavailable(t, middleware.FailLocal)
+		for i := 0; i < 3; i++ {
+			allowed, _ := limiter.Allow("client")
+			assert.True(t, allowed, "request %d", i+1)
+		}
+		allowed, _ := limiter.Allow("client")
+		assert.False(t, allowed)
+		assert.False(t, limiter.Available())
+
+		buckets := limiter.Snapshot()
+		require.Len(t, buckets, 1, "the fallback buckets are listed")
+		assert.Equal(t, "client", buckets[0].Key)
+	})
+
+	t.Run("open allows requests", func(t *testing.T) {
+		limiter := newUnavailable(t, middleware.FailOpen)
+		for i := 0; i < 10; i++ {
+			allowed, _ := limiter.Allow("client")
+			assert.True(t, allowed)
+		}
+	})
+
+	t.Run("closed rejects requests", func(t *testing.T) {
+		limiter := newUnavailable(t, middleware.FailClosed)
+		allowed, _ := limiter.Allow("client")
+		assert.False(t, allowed)
+	})
+
+	t.Run("recovers when Redis is back", func(t *testing.T) {
+		server, client := newRedis(t)
+		limiter := newDistributedLimiter(client, func(config *middleware.DistributedRateLimitConfig) {
+			config.FailureMode = middleware.FailClosed
+			config.RetryInterval = 50 * time.Millisecond
+		})
+
+		server.SetError("LOADING Redis is loading the dataset in memory")
+		allowed, _ := limiter.Allow("client")
+		assert.False(t, allowed)
+
+		server.SetError("")
+		require.Eventually(t, func() bool {
+			allowed, _ := limiter.Allow("client")
+			return allowed
+		}, time.Second, 20*time.Millisecond)
+		assert.True(t, limiter.Available())
+	})
+}
+
+func TestDistributedRateLimiter_Close(t *testing.T) {
+	_, client := newRedis(t)
+	// Connect first so the Redis connection is not reported as a leak
+	require.NoError(t, client.Ping(context.Background()).Err())
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	fallback := middleware.NewTokenBucketLimiter(60, 3, time.Minute, time.Minute)
+	defer fallback.Close()
+	given := newDistributedLimiter(client, func(config *middleware.DistributedRateLimitConfig) {
+		config.Fallback = fallback
+	})
+	owning := newDistributedLimiter(client, nil)
+
+	assert.NoError(t, given.Close())
+	assert.NoError(t, owning.Close())
+	// The Redis client belongs to the caller
+	assert.NoError(t, client.Ping(context.Background()).Err())
+	allowed, _ := fallback.Allow("client")
+	assert.True(t, allowed)
+}
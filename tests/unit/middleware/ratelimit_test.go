@@ -1,10 +1,13 @@
 package middleware_test
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,6 +15,8 @@ import (
 	"github.com/lumitut/lumi-go/internal/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
 )
 
 func TestTokenBucketLimiter(t *testing.T) {
@@ -69,6 +74,102 @@ func TestTokenBucketLimiter(t *testing.T) {
 		allowed, _ = limiter.Allow("test-key")
 		assert.True(t, allowed)
 	})
+
+	t.Run("set limits changes rate and burst", func(t *testing.T) {
+		limiter := middleware.NewTokenBucketLimiter(10, 2, time.Minute, 5*time.Minute)
+
+		limiter.SetLimits(20, 4)
+
+		for i := 0; i < 4; i++ {
+			allowed, info := limiter.Allow("test-key")
+			assert.True(t, allowed, "Request %d should be allowed", i+1)
+			assert.Equal(t, 20, info.Limit)
+		}
+		allowed, _ := limiter.Allow("test-key")
+		assert.False(t, allowed)
+	})
+
+	t.Run("buckets snapshot the state per key", func(t *testing.T) {
+		limiter := middleware.NewTokenBucketLimiter(10, 5, time.Minute, 5*time.Minute)
+		limiter.Allow("b")
+		limiter.Allow("a")
+		limiter.Allow("a")
+
+		buckets := limiter.Snapshot()
+		require.Len(t, buckets, 2)
+		assert.Equal(t, "a", buckets[0].Key)
+		assert.Equal(t, 3, buckets[0].Tokens)
+		assert.Equal(t, "b", buckets[1].Key)
+
+		bucket, ok := limiter.Bucket("b")
+		require.True(t, ok)
+		assert.Equal(t, 4, bucket.Tokens)
+
+		limiter.Reset("b")
+		_, ok = limiter.Bucket("b")
+		assert.False(t, ok)
+	})
+
+	t.Run("refills fractional tokens within a second", func(t *testing.T) {
+		// 600 requests per minute refill a token every 100ms
+		limiter := middleware.NewTokenBucketLimiter(600, 1, time.Minute, 5*time.Minute)
+
+		allowed, info := limiter.Allow("test-key")
+		assert.True(t, allowed)
+		assert.WithinDuration(t, time.Now().Add(100*time.Millisecond), info.ResetTime, 20*time.Millisecond, "full again after one token")
+
+		allowed, info = limiter.Allow("test-key")
+		assert.False(t, allowed)
+		assert.WithinDuration(t, time.Now().Add(100*time.Millisecond), info.ResetTime, 20*time.Millisecond, "next token")
+
+		time.Sleep(time.Until(info.ResetTime))
+		allowed, _ = limiter.Allow("test-key")
+		assert.True(t, allowed)
+	})
+
+	t.Run("concurrent requests never exceed the burst", func(t *testing.T) {
+		limiter := middleware.NewTokenBucketLimiter(1, 50, time.Hour, 5*time.Minute)
+
+		var mu sync.Mutex
+		allowed := 0
+		var wg sync.WaitGroup
+		for i := 0; i < 200; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if ok, _ := limiter.Allow("shared"); ok {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 50, allowed)
+	})
+}
+
+// BenchmarkTokenBucketLimiter measures Allow throughput from parallel
+// goroutines as the number of distinct keys grows
+func BenchmarkTokenBucketLimiter(b *testing.B) {
+	for _, cardinality := range []int{1, 1000, 100000} {
+		keys := make([]string, cardinality)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("client-%d", i)
+		}
+
+		b.Run(fmt.Sprintf("keys=%d", cardinality), func(b *testing.B) {
+			limiter := middleware.NewTokenBucketLimiter(6000, 100, time.Minute, 5*time.Minute)
+			var next atomic.Uint64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					limiter.Allow(keys[next.Add(1)%uint64(cardinality)])
+				}
+			})
+		})
+	}
 }
 
 func TestRateLimitMiddleware(t *testing.T) {
@@ -377,3 +478,64 @@ func TestConcurrentRateLimiting(t *testing.T) {
 	assert.LessOrEqual(t, successCount, 10)
 	assert.GreaterOrEqual(t, successCount, 8) // Allow some variance for timing
 }
+
+func TestRateLimiterClose(t *testing.T) {
+	t.Run("stops limiter cleanup goroutines", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		tokenBucket := middleware.NewTokenBucketLimiter(10, 5, time.Minute, time.Millisecond)
+		slidingWindow := middleware.NewSlidingWindowLimiter(10, time.Second, time.Millisecond)
+		tokenBucket.Allow("key")
+		slidingWindow.Allow("key")
+
+		assert.NoError(t, tokenBucket.Close())
+		assert.NoError(t, slidingWindow.Close())
+		// Closing again is a no-op
+		assert.NoError(t, tokenBucket.Close())
+		assert.NoError(t, slidingWindow.Close())
+	})
+
+	t.Run("releases limiters created by middleware", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		config := middleware.DefaultRateLimitConfig()
+		config.KeyFunc = func(c *gin.Context) string { return "client" }
+		rateLimit := middleware.NewRateLimit(config)
+		byEndpoint := middleware.NewRateLimitByEndpoint(map[string]int{"/api/a": 10, "/api/b": 20})
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(rateLimit.Handler(), byEndpoint.Handler())
+		router.GET("/api/a", func(c *gin.Context) { c.Status(http.StatusOK) })
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/a", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		assert.NoError(t, rateLimit.Close())
+		assert.NoError(t, byEndpoint.Close())
+	})
+
+	t.Run("leaves limiters it was given open", func(t *testing.T) {
+		limiter := middleware.NewTokenBucketLimiter(10, 1, time.Minute, 5*time.Minute)
+		defer limiter.Close()
+
+		config := middleware.DefaultRateLimitConfig()
+		config.Limiter = limiter
+		require.NoError(t, middleware.NewRateLimit(config).Close())
+
+		allowed, _ := limiter.Allow("key")
+		assert.True(t, allowed)
+	})
+
+	t.Run("releases limiters created by gRPC interceptors", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		rateLimit := middleware.NewGRPCRateLimit(middleware.GRPCRateLimitConfig{Enabled: true, Rate: 10, Burst: 1})
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+		_, err := rateLimit.UnaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, handler)
+		assert.NoError(t, err)
+
+		assert.NoError(t, rateLimit.Close())
+		assert.NoError(t, rateLimit.Close())
+	})
+}
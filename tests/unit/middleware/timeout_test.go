@@ -0,0 +1,189 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lumitut/lumi-go/internal/middleware"
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTimeoutRouter serves /slow, which waits for its context, and /fast
+func newTimeoutRouter(config middleware.TimeoutConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Timeout(config))
+	router.GET("/slow", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(5 * time.Second):
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		}
+	})
+	router.GET("/fast", func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"deadline": ok, "remaining_ms": time.Until(deadline).Milliseconds()})
+	})
+	return router
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	t.Run("responds 504 when the deadline passes", func(t *testing.T) {
+		router := newTimeoutRouter(middleware.TimeoutConfig{Default: 50 * time.Millisecond})
+		before := testutil.ToFloat64(metrics.Get().RequestTimeouts.WithLabelValues("http", "/slow"))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "timeout", body["error"])
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.Get().RequestTimeouts.WithLabelValues("http", "/slow")))
+	})
+
+	t.Run("responds 504 behind compression after a status alone", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(middleware.Compression(), middleware.Timeout(middleware.TimeoutConfig{Default: 50 * time.Millisecond}))
+		router.GET("/slow", func(c *gin.Context) {
+			c.Status(http.StatusCreated)
+			<-c.Request.Context().Done()
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Contains(t, w.Body.String(), `"error":"timeout"`)
+	})
+
+	t.Run("route overrides", func(t *testing.T) {
+		router := newTimeoutRouter(middleware.TimeoutConfig{
+			Default: 10 * time.Second,
+			Routes:  map[string]time.Duration{"/sl": time.Second, "/slow": 50 * time.Millisecond, "/fast": 0},
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code, "longest prefix wins")
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+		assert.Contains(t, w.Body.String(), `"deadline":false`, "0 disables the timeout")
+	})
+
+	t.Run("client timeouts can only shorten the deadline", func(t *testing.T) {
+		router := newTimeoutRouter(middleware.TimeoutConfig{Default: time.Second})
+
+		for header, maxRemaining := range map[string]int64{"200ms": 200, "60": 1000, "9223372036854775807": 1000, "invalid": 1000} {
+			req := httptest.NewRequest(http.MethodGet, "/fast", nil)
+			req.Header.Set(middleware.HeaderRequestTimeout, header)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, true, body["deadline"], header)
+			assert.LessOrEqual(t, int64(body["remaining_ms"].(float64)), maxRemaining, header)
+			assert.Greater(t, int64(body["remaining_ms"].(float64)), maxRemaining-100, header)
+		}
+	})
+}
+
+func TestParseRequestTimeout(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"5":     5 * time.Second,
+		"1.5s":  1500 * time.Millisecond,
+		"250ms": 250 * time.Millisecond,
+	} {
+		d, ok := middleware.ParseRequestTimeout(value, 0)
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, d, value)
+	}
+
+	for _, value := range []string{"", "0", "-1s", "soon"} {
+		_, ok := middleware.ParseRequestTimeout(value, 0)
+		assert.False(t, ok, value)
+	}
+
+	t.Run("caps at the limit", func(t *testing.T) {
+		for _, value := range []string{"9223372036854775807", "9223372036", "1h"} {
+			d, ok := middleware.ParseRequestTimeout(value, 10*time.Second)
+			assert.True(t, ok, value)
+			assert.Equal(t, 10*time.Second, d, value)
+		}
+
+		d, ok := middleware.ParseRequestTimeout("9223372036854775807", 0)
+		assert.True(t, ok)
+		assert.Positive(t, d, "huge values must not overflow")
+	})
+}
+
+func TestUnaryTimeoutInterceptor(t *testing.T) {
+	interceptor := middleware.UnaryTimeoutInterceptor(middleware.TimeoutConfig{Default: 50 * time.Millisecond})
+
+	t.Run("converts deadline errors", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, testUnaryInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("keeps shorter client deadlines", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		clientDeadline, _ := ctx.Deadline()
+
+		_, err := interceptor(ctx, nil, testUnaryInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.Equal(t, clientDeadline, deadline)
+			return "ok", nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestDeadlineTransport(t *testing.T) {
+	var received string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(middleware.HeaderRequestTimeout)
+	}))
+	defer downstream.Close()
+	client := &http.Client{Transport: &middleware.DeadlineTransport{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downstream.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	remaining, ok := middleware.ParseRequestTimeout(received, 0)
+	require.True(t, ok, received)
+	assert.InDelta(t, 2*time.Second, remaining, float64(500*time.Millisecond))
+	assert.Empty(t, req.Header.Get(middleware.HeaderRequestTimeout), "the caller's request is not modified")
+
+	// Requests without a deadline are sent unchanged
+	req, err = http.NewRequest(http.MethodGet, downstream.URL, nil)
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, received)
+}
@@ -101,6 +101,27 @@ func TestLoggerLevels(t *testing.T) {
 	assert.NotNil(t, logger.Get())
 }
 
+func TestLoggerSetLevel(t *testing.T) {
+	err := logger.Initialize(logger.Config{
+		Level:  "info",
+		Format: "json",
+	})
+	require.NoError(t, err)
+	defer func() { _ = logger.SetLevel("info") }()
+
+	assert.Equal(t, "info", logger.GetLevel())
+	assert.False(t, logger.Get().Core().Enabled(zapcore.DebugLevel))
+
+	// Level changes apply to the existing logger
+	require.NoError(t, logger.SetLevel("debug"))
+	assert.Equal(t, "debug", logger.GetLevel())
+	assert.True(t, logger.Get().Core().Enabled(zapcore.DebugLevel))
+
+	// Invalid levels are rejected and the current level is kept
+	assert.Error(t, logger.SetLevel("verbose"))
+	assert.Equal(t, "debug", logger.GetLevel())
+}
+
 func TestLoggerContext(t *testing.T) {
 	// Initialize logger
 	err := logger.Initialize(logger.Config{
@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/observability/metrics"
+	"github.com/lumitut/lumi-go/internal/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthChecker_Criticality(t *testing.T) {
+	checker := service.NewHealthChecker(0)
+	checker.Register("ok", func(ctx context.Context) error { return nil }, service.DefaultCheckConfig())
+	checker.Register("optional", func(ctx context.Context) error {
+		return errors.New("unavailable")
+	}, service.CheckConfig{Critical: false})
+
+	checks, ready := checker.Check(context.Background())
+	assert.True(t, ready, "a failing non-critical check must not affect readiness")
+	assert.Equal(t, "ready", checks["ok"].Status)
+	assert.Equal(t, "not_ready", checks["optional"].Status)
+	assert.Equal(t, "unavailable", checks["optional"].Message)
+	assert.False(t, checks["optional"].Critical)
+
+	checker.Register("required", func(ctx context.Context) error {
+		return errors.New("unavailable")
+	}, service.DefaultCheckConfig())
+
+	checks, ready = checker.Check(context.Background())
+	assert.False(t, ready)
+	assert.True(t, checks["required"].Critical)
+	assert.Equal(t, []string{"ok", "optional", "required"}, checker.Names())
+
+	checker.Unregister("required")
+	_, ready = checker.Check(context.Background())
+	assert.True(t, ready)
+}
+
+func TestHealthChecker_Timeout(t *testing.T) {
+	checker := service.NewHealthChecker(0)
+
+	// A check that ignores its context still cannot block readiness
+	block := make(chan struct{})
+	defer close(block)
+	checker.Register("slow", func(ctx context.Context) error {
+		<-block
+		return nil
+	}, service.CheckConfig{Timeout: 50 * time.Millisecond, Critical: true})
+
+	start := time.Now()
+	checks, ready := checker.Check(context.Background())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.False(t, ready)
+	assert.Contains(t, checks["slow"].Message, "timed out")
+}
+
+func TestHealthChecker_Panic(t *testing.T) {
+	checker := service.NewHealthChecker(0)
+	checker.Register("broken", func(ctx context.Context) error {
+		panic("nil client")
+	}, service.DefaultCheckConfig())
+
+	checks, ready := checker.Check(context.Background())
+	assert.False(t, ready)
+	assert.Contains(t, checks["broken"].Message, "nil client")
+}
+
+func TestHealthChecker_Cache(t *testing.T) {
+	checker := service.NewHealthChecker(time.Hour)
+
+	var calls atomic.Int32
+	checker.Register("counted", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}, service.DefaultCheckConfig())
+
+	for i := 0; i < 5; i++ {
+		checker.Check(context.Background())
+	}
+	assert.Equal(t, int32(1), calls.Load(), "results within the TTL must be reused")
+
+	// Re-registering resets the cached result
+	checker.Register("counted", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}, service.DefaultCheckConfig())
+	checker.Check(context.Background())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestHealthChecker_CallerCancelled(t *testing.T) {
+	checker := service.NewHealthChecker(time.Hour)
+	checker.Register("ping", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+			return nil
+		}
+	}, service.DefaultCheckConfig())
+
+	// A probe that gave up must not cache a failure for the other probes
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	checks, ready := checker.Check(ctx)
+	assert.True(t, ready)
+	assert.Equal(t, "ready", checks["ping"].Status)
+
+	checks, ready = checker.Check(context.Background())
+	assert.True(t, ready)
+	assert.Empty(t, checks["ping"].Message)
+}
+
+func TestHealthChecker_Metrics(t *testing.T) {
+	checker := service.NewHealthChecker(0)
+	checker.Register("metrics-up", func(ctx context.Context) error { return nil }, service.DefaultCheckConfig())
+	checker.Register("metrics-down", func(ctx context.Context) error {
+		return errors.New("down")
+	}, service.DefaultCheckConfig())
+
+	checker.Check(context.Background())
+
+	gauge := metrics.Get().ReadinessChecks
+	assert.Equal(t, float64(1), testutil.ToFloat64(gauge.WithLabelValues("metrics-up")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(gauge.WithLabelValues("metrics-down")))
+}
+
+func TestDialCheck(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+
+	check := service.DialCheck("postgres://user:secret@"+addr+"/app", "5432")
+	assert.NoError(t, check(context.Background()))
+
+	require.NoError(t, lis.Close())
+	err = check(context.Background())
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+
+	assert.Error(t, service.DialCheck("", "6379")(context.Background()))
+}
@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lumitut/lumi-go/internal/service"
+	"github.com/lumitut/lumi-go/tests/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycle(t *testing.T) {
+	lifecycle := service.NewLifecycle()
+	lifecycle.Register("http")
+	lifecycle.Register("rpc")
+	assert.Equal(t, service.StateStarting, lifecycle.State())
+	assert.Equal(t, []string{"http", "rpc"}, lifecycle.Pending())
+
+	lifecycle.MarkInitialized("http")
+	assert.Equal(t, service.StateStarting, lifecycle.State(), "must wait for every component")
+	assert.Equal(t, []string{"rpc"}, lifecycle.Pending())
+
+	lifecycle.MarkInitialized("rpc")
+	assert.Equal(t, service.StateReady, lifecycle.State())
+	assert.Equal(t, map[string]bool{"http": true, "rpc": true}, lifecycle.Components())
+
+	// Late components are listed but don't send the service back to starting
+	lifecycle.Register("worker")
+	assert.Equal(t, service.StateReady, lifecycle.State())
+	assert.Equal(t, []string{"worker"}, lifecycle.Pending())
+
+	lifecycle.Drain()
+	assert.Equal(t, service.StateDraining, lifecycle.State())
+
+	lifecycle.Stop()
+	assert.Equal(t, service.StateStopped, lifecycle.State())
+
+	// States are never re-entered
+	lifecycle.Drain()
+	lifecycle.MarkInitialized("worker")
+	assert.Equal(t, service.StateStopped, lifecycle.State())
+	assert.Equal(t, "stopped", lifecycle.State().String())
+}
+
+func TestLifecycle_DrainWhileStarting(t *testing.T) {
+	lifecycle := service.NewLifecycle()
+	lifecycle.Register("http")
+	lifecycle.Drain()
+
+	lifecycle.MarkInitialized("http")
+	assert.Equal(t, service.StateDraining, lifecycle.State())
+}
+
+func TestWatchdog(t *testing.T) {
+	watchdog := service.NewWatchdog()
+	assert.Empty(t, watchdog.Stalled())
+
+	heartbeat, err := watchdog.Register("loop", 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.Empty(t, watchdog.Stalled())
+
+	// A second owner cannot take over the name
+	_, err = watchdog.Register("loop", time.Hour)
+	assert.Error(t, err)
+
+	// A loop that stops beating is reported as stalled
+	time.Sleep(40 * time.Millisecond)
+	stalled := watchdog.Stalled()
+	require.Contains(t, stalled, "loop")
+	assert.GreaterOrEqual(t, stalled["loop"], 20*time.Millisecond)
+
+	heartbeat.Beat()
+	assert.Empty(t, watchdog.Stalled())
+
+	watchdog.Unregister("loop")
+	time.Sleep(40 * time.Millisecond)
+	assert.Empty(t, watchdog.Stalled())
+
+	_, err = watchdog.Register("loop", time.Hour)
+	assert.NoError(t, err, "the name is free again once unregistered")
+}
+
+func TestWatchdog_Run(t *testing.T) {
+	watchdog := service.NewWatchdog()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		watchdog.Run(ctx, 5*time.Millisecond)
+		close(done)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, watchdog.Stalled(), "the runtime heartbeat must keep beating")
+
+	cancel()
+	<-done
+}
+
+func TestHealthService_Probes(t *testing.T) {
+	health := service.NewHealthServiceWithConfig(helpers.TestConfig(), service.HealthConfig{})
+	health.Lifecycle().Register("http")
+	ctx := context.Background()
+
+	startup, err := health.GetStartup(ctx)
+	require.NoError(t, err)
+	assert.False(t, startup.Started)
+	assert.Equal(t, "starting", startup.State)
+
+	readiness, err := health.GetReadiness(ctx)
+	require.NoError(t, err)
+	assert.False(t, readiness.Ready)
+	assert.Equal(t, "Service is starting, waiting for http", readiness.Checks["lifecycle"].Message)
+
+	health.Lifecycle().MarkInitialized("http")
+
+	startup, err = health.GetStartup(ctx)
+	require.NoError(t, err)
+	assert.True(t, startup.Started)
+
+	readiness, err = health.GetReadiness(ctx)
+	require.NoError(t, err)
+	assert.True(t, readiness.Ready)
+
+	// Draining fails readiness but not liveness
+	health.Lifecycle().Drain()
+	readiness, err = health.GetReadiness(ctx)
+	require.NoError(t, err)
+	assert.False(t, readiness.Ready)
+	assert.Equal(t, "Service is draining", readiness.Checks["lifecycle"].Message)
+
+	liveness, err := health.GetHealth(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "healthy", liveness.Status)
+
+	// A wedged event loop fails liveness
+	health.Watchdog().Register("wedged", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	liveness, err = health.GetHealth(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "unhealthy", liveness.Status)
+	assert.Contains(t, liveness.Details["stalled_loops"], "wedged")
+}